@@ -5,7 +5,9 @@ go 1.15
 require (
 	github.com/cilium/cilium v1.8.5
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/go-asn1-ber/asn1-ber v1.5.1
 	github.com/go-chi/chi v4.1.2+incompatible
+	github.com/go-ldap/ldap/v3 v3.4.1
 	github.com/go-logr/logr v0.1.0
 	github.com/googleapis/gnostic v0.4.1 // indirect
 	github.com/jessevdk/go-flags v1.4.0 // indirect
@@ -22,7 +24,7 @@ require (
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.6.1
 	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
-	golang.org/x/net v0.0.0-20200707034311-ab3426394381 // indirect
+	golang.org/x/net v0.0.0-20200707034311-ab3426394381
 	golang.org/x/oauth2 v0.0.0-20191202225959-858c2ad4c8b6 // indirect
 	golang.org/x/sys v0.0.0-20200622214017-ed371f2e16b4 // indirect
 	golang.org/x/time v0.0.0-20191024005414-555d28b269f0 // indirect
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/services"
 	"github.com/go-chi/chi"
+	"golang.org/x/net/websocket"
 )
 
 // HandleCreateApp creates an app
@@ -21,14 +27,8 @@ func (root *Root) HandleCreateApp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// check if the project exists
-	project, err := root.ProjectSvc.Get(r.Context(), userName, projectName)
-	if err != nil {
-		root.HandleError(w, r, err)
-		return
-	}
-	if project == nil {
-		root.HandleError(w, r, fmt.Errorf("project not found: %s", projectName))
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleDeveloper) {
 		return
 	}
 
@@ -55,14 +55,8 @@ func (root *Root) HandleUpdateApp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// check if the project exists
-	project, err := root.ProjectSvc.Get(r.Context(), userName, projectName)
-	if err != nil {
-		root.HandleError(w, r, err)
-		return
-	}
-	if project == nil {
-		root.HandleError(w, r, fmt.Errorf("project not found: %s", projectName))
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleDeveloper) {
 		return
 	}
 
@@ -80,18 +74,32 @@ func (root *Root) HandleListApps(w http.ResponseWriter, r *http.Request) {
 	projectName := chi.URLParam(r, "project")
 	userName := r.Context().Value(CtxKey("userName")).(string)
 
-	// check if the project exists
-	project, err := root.ProjectSvc.Get(r.Context(), userName, projectName)
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleViewer) {
+		return
+	}
+
+	respData, err := root.AppSvc.List(r.Context(), projectName)
 	if err != nil {
 		root.HandleError(w, r, err)
 		return
 	}
-	if project == nil {
-		root.HandleError(w, r, fmt.Errorf("project not found: %s", projectName))
+
+	JSONOk(w, respData)
+}
+
+// HandleGetApp returns an app with its pods, events and rollout state
+func (root *Root) HandleGetApp(w http.ResponseWriter, r *http.Request) {
+	projectName := chi.URLParam(r, "project")
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	appName := chi.URLParam(r, "app")
+
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleViewer) {
 		return
 	}
 
-	respData, err := root.AppSvc.List(r.Context(), projectName)
+	respData, err := root.AppSvc.Get(r.Context(), projectName, appName)
 	if err != nil {
 		root.HandleError(w, r, err)
 		return
@@ -106,18 +114,81 @@ func (root *Root) HandleRestartApp(w http.ResponseWriter, r *http.Request) {
 	userName := r.Context().Value(CtxKey("userName")).(string)
 	appName := chi.URLParam(r, "app")
 
-	// check if the project exists
-	project, err := root.ProjectSvc.Get(r.Context(), userName, projectName)
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleDeveloper) {
+		return
+	}
+
+	err := root.AppSvc.Restart(r.Context(), projectName, appName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// HandleAttachAppDomain attaches a custom domain to an app
+func (root *Root) HandleAttachAppDomain(w http.ResponseWriter, r *http.Request) {
+	projectName := chi.URLParam(r, "project")
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	appName := chi.URLParam(r, "app")
+
+	reqData := &requests.AttachDomain{}
+
+	err := readJSON(r, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleDeveloper) {
+		return
+	}
+
+	err = root.AppSvc.AttachDomain(r.Context(), projectName, appName, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// HandleDetachAppDomain detaches a custom domain from an app
+func (root *Root) HandleDetachAppDomain(w http.ResponseWriter, r *http.Request) {
+	projectName := chi.URLParam(r, "project")
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	appName := chi.URLParam(r, "app")
+	domain := chi.URLParam(r, "domain")
+
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleDeveloper) {
+		return
+	}
+
+	err := root.AppSvc.DetachDomain(r.Context(), projectName, appName, domain)
 	if err != nil {
 		root.HandleError(w, r, err)
 		return
 	}
-	if project == nil {
-		root.HandleError(w, r, fmt.Errorf("project not found: %s", projectName))
+
+	JSONOk(w, &struct{}{})
+}
+
+// HandleDeleteApp deletes an app
+func (root *Root) HandleDeleteApp(w http.ResponseWriter, r *http.Request) {
+	projectName := chi.URLParam(r, "project")
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	appName := chi.URLParam(r, "app")
+
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleDeveloper) {
 		return
 	}
 
-	err = root.AppSvc.Restart(r.Context(), projectName, appName)
+	err := root.AppSvc.Delete(r.Context(), projectName, appName)
 	if err != nil {
 		root.HandleError(w, r, err)
 		return
@@ -125,3 +196,271 @@ func (root *Root) HandleRestartApp(w http.ResponseWriter, r *http.Request) {
 
 	JSONOk(w, &struct{}{})
 }
+
+// HandleAppLogs streams the logs of the app pods as chunked plain text
+func (root *Root) HandleAppLogs(w http.ResponseWriter, r *http.Request) {
+	projectName := chi.URLParam(r, "project")
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	appName := chi.URLParam(r, "app")
+
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleViewer) {
+		return
+	}
+
+	reqData, err := readAppLogs(r)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	fw := &flushWriter{w: w}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	err = root.AppSvc.Logs(r.Context(), projectName, appName, reqData, fw)
+	if err != nil && !fw.written {
+		root.HandleError(w, r, err)
+		return
+	}
+}
+
+// readAppLogs reads the logs options from the query string
+func readAppLogs(r *http.Request) (*requests.AppLogs, error) {
+	q := r.URL.Query()
+
+	reqData := &requests.AppLogs{
+		Container: q.Get("container"),
+	}
+
+	if follow := q.Get("follow"); follow != "" {
+		b, err := strconv.ParseBool(follow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid follow: %s", follow)
+		}
+		reqData.Follow = b
+	}
+
+	if tail := q.Get("tail"); tail != "" {
+		n, err := strconv.ParseInt(tail, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid tail: %s", tail)
+		}
+		reqData.TailLines = &n
+	}
+
+	if since := q.Get("since"); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid since: %s", since)
+		}
+		// the api server only accepts whole seconds
+		seconds := int64((d + time.Second - 1) / time.Second)
+		reqData.SinceSeconds = &seconds
+	}
+
+	return reqData, nil
+}
+
+// HandleAppExec runs a command in an app container and relays its streams over a websocket
+func (root *Root) HandleAppExec(w http.ResponseWriter, r *http.Request) {
+	projectName := chi.URLParam(r, "project")
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	appName := chi.URLParam(r, "app")
+
+	// websockets are opened with a GET, which read only tokens are allowed
+	if accessToken := RequestAccessToken(r); accessToken != nil && accessToken.ReadOnly {
+		JSONError(w, "read only token", http.StatusUnauthorized)
+		return
+	}
+
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleDeveloper) {
+		return
+	}
+
+	reqData, err := readAppExec(r)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	// don't start a session that can't be relayed
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		root.HandleError(w, r, fmt.Errorf("websocket upgrade required"))
+		return
+	}
+
+	// reject cross site upgrades from browsers
+	if !websocketOriginAllowed(r) {
+		JSONError(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	session, err := root.AppSvc.Exec(r.Context(), projectName, appName, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+	defer session.Close()
+
+	// clients can only write to stdin and resize the tty
+	serveChannelSession(w, r, services.ExecProtocol, session, services.ExecStreamStdin, services.ExecStreamResize)
+}
+
+// readAppExec reads the exec options from the query string
+func readAppExec(r *http.Request) (*requests.AppExec, error) {
+	q := r.URL.Query()
+
+	reqData := &requests.AppExec{
+		Pod:       q.Get("pod"),
+		Container: q.Get("container"),
+		Command:   q["command"],
+	}
+
+	for name, value := range map[string]*bool{"stdin": &reqData.Stdin, "tty": &reqData.TTY} {
+		if s := q.Get(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", name, s)
+			}
+			*value = b
+		}
+	}
+
+	return reqData, nil
+}
+
+// HandleAppPortForward tunnels a connection to an app port over a websocket
+func (root *Root) HandleAppPortForward(w http.ResponseWriter, r *http.Request) {
+	projectName := chi.URLParam(r, "project")
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	appName := chi.URLParam(r, "app")
+
+	// websockets are opened with a GET, which read only tokens are allowed
+	if accessToken := RequestAccessToken(r); accessToken != nil && accessToken.ReadOnly {
+		JSONError(w, "read only token", http.StatusUnauthorized)
+		return
+	}
+
+	// check if the project exists and the user can access it
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleDeveloper) {
+		return
+	}
+
+	reqData, err := readAppPortForward(r)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	// don't open a connection that can't be relayed
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		root.HandleError(w, r, fmt.Errorf("websocket upgrade required"))
+		return
+	}
+
+	// reject cross site upgrades from browsers
+	if !websocketOriginAllowed(r) {
+		JSONError(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	session, err := root.AppSvc.PortForward(r.Context(), projectName, appName, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+	defer session.Close()
+
+	serveChannelSession(w, r, services.PortForwardProtocol, session, services.PortForwardStreamData)
+}
+
+// readAppPortForward reads the port forward options from the query string
+func readAppPortForward(r *http.Request) (*requests.AppPortForward, error) {
+	q := r.URL.Query()
+
+	port, err := strconv.ParseInt(q.Get("port"), 10, 32)
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port: %s", q.Get("port"))
+	}
+
+	reqData := &requests.AppPortForward{
+		Pod:  q.Get("pod"),
+		Port: int32(port),
+	}
+
+	return reqData, nil
+}
+
+// channelSession is a stream multiplexed session, such as exec or port forward
+type channelSession interface {
+	Receive() ([]byte, error)
+	Send(msg []byte) error
+	Close() error
+}
+
+// serveChannelSession upgrades the request to a websocket with protocol and relays the session over it
+func serveChannelSession(w http.ResponseWriter, r *http.Request, protocol string, session channelSession, clientStreams ...byte) {
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			if !websocketOriginAllowed(r) {
+				return fmt.Errorf("origin not allowed")
+			}
+			for _, p := range config.Protocol {
+				if p == protocol {
+					config.Protocol = []string{protocol}
+					return nil
+				}
+			}
+			return fmt.Errorf("unsupported protocol")
+		},
+		Handler: func(ws *websocket.Conn) {
+			relaySession(ws, session, clientStreams)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// relaySession copies the messages between the client and the session until either side closes,
+// clients can only write to clientStreams
+func relaySession(ws *websocket.Conn, session channelSession, clientStreams []byte) {
+	ws.PayloadType = websocket.BinaryFrame
+
+	// unblocks the client receive once the session is over
+	defer ws.Close()
+
+	go func() {
+		// unblocks the session receive once the client is gone
+		defer session.Close()
+
+		for {
+			var msg []byte
+			err := websocket.Message.Receive(ws, &msg)
+			if err != nil {
+				return
+			}
+
+			if len(msg) == 0 || bytes.IndexByte(clientStreams, msg[0]) < 0 {
+				continue
+			}
+
+			err = session.Send(msg)
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		msg, err := session.Receive()
+		if err != nil {
+			return
+		}
+
+		err = websocket.Message.Send(ws, msg)
+		if err != nil {
+			return
+		}
+	}
+}
@@ -4,20 +4,27 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	api "github.com/didil/kubexcloud/kxc-api"
 	"github.com/didil/kubexcloud/kxc-api/handlers"
 	"github.com/didil/kubexcloud/kxc-api/requests"
 	"github.com/didil/kubexcloud/kxc-api/responses"
+	"github.com/didil/kubexcloud/kxc-api/services"
 	"github.com/didil/kubexcloud/kxc-api/testsupport"
 	"github.com/didil/kubexcloud/kxc-api/testsupport/auth"
 	"github.com/didil/kubexcloud/kxc-api/testsupport/mocks"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/websocket"
 )
 
 type AppTestSuite struct {
@@ -38,15 +45,142 @@ func (suite *AppTestSuite) Test_HandleCreateApp_Ok() {
 
 	appSvc := new(mocks.AppSvc)
 	projectSvc := new(mocks.ProjectSvc)
-	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc}
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	reqData := &requests.CreateApp{
+		Name: "app-a",
+	}
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("Create", mock.AnythingOfType("*context.valueCtx"), projName, reqData).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	var b bytes.Buffer
+	json.NewEncoder(&b).Encode(reqData)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/projects/project-a/apps", &b)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleCreateApp_Env() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	reqData := &requests.CreateApp{
+		Name: "app-a",
+		Containers: []requests.Container{
+			requests.Container{
+				Image: "busybox",
+				Name:  "container-a",
+				Env: []requests.EnvVar{
+					requests.EnvVar{Name: "LOG_LEVEL", Value: "debug"},
+					requests.EnvVar{Name: "DB_PASSWORD", SecretKeyRef: &requests.KeyRef{Name: "db-credentials", Key: "password"}},
+					requests.EnvVar{Name: "FEATURE_FLAGS", ConfigMapKeyRef: &requests.KeyRef{Name: "app-config", Key: "flags"}},
+				},
+			},
+		},
+	}
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("Create", mock.AnythingOfType("*context.valueCtx"), projName, reqData).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	var b bytes.Buffer
+	json.NewEncoder(&b).Encode(reqData)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/projects/project-a/apps", &b)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleCreateApp_Resources() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
 
 	reqData := &requests.CreateApp{
 		Name: "app-a",
+		Containers: []requests.Container{
+			requests.Container{
+				Image: "busybox",
+				Name:  "container-a",
+				Resources: &requests.Resources{
+					Requests: &requests.ResourceList{CPU: "100m", Memory: "64Mi"},
+					Limits:   &requests.ResourceList{CPU: "500m", Memory: "128Mi"},
+				},
+			},
+		},
 	}
 
 	projName := "project-a"
 	proj := &responses.Project{
 		Name: projName,
+		Role: services.ProjectRoleDeveloper,
 	}
 
 	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
@@ -86,16 +220,32 @@ func (suite *AppTestSuite) Test_HandleUpdateApp_Ok() {
 
 	appSvc := new(mocks.AppSvc)
 	projectSvc := new(mocks.ProjectSvc)
-	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc}
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
 
 	appName := "app-a"
 	reqData := &requests.UpdateApp{
 		Replicas: 6,
+		Containers: []requests.Container{
+			requests.Container{
+				Image: "postgres",
+				Name:  "db",
+				VolumeMounts: []requests.VolumeMount{
+					requests.VolumeMount{Name: "data", MountPath: "/var/lib/postgresql/data"},
+				},
+			},
+		},
+		Volumes: []requests.Volume{
+			requests.Volume{Name: "data", Size: "1Gi", ReclaimPolicy: "Retain"},
+		},
 	}
 
 	projName := "project-a"
 	proj := &responses.Project{
 		Name: projName,
+		Role: services.ProjectRoleDeveloper,
 	}
 
 	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
@@ -134,7 +284,10 @@ func (suite *AppTestSuite) Test_HandleListApps_Ok() {
 
 	appSvc := new(mocks.AppSvc)
 	projectSvc := new(mocks.ProjectSvc)
-	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc}
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
 
 	rawRespData := &responses.ListApp{
 		Apps: []responses.ListAppEntry{
@@ -147,6 +300,7 @@ func (suite *AppTestSuite) Test_HandleListApps_Ok() {
 	projName := "project-a"
 	proj := &responses.Project{
 		Name: projName,
+		Role: services.ProjectRoleViewer,
 	}
 
 	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
@@ -179,6 +333,120 @@ func (suite *AppTestSuite) Test_HandleListApps_Ok() {
 	appSvc.AssertExpectations(suite.T())
 }
 
+func (suite *AppTestSuite) Test_HandleGetApp_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	rawRespData := &responses.GetApp{
+		Name: appName,
+		Spec: responses.AppSpec{
+			Replicas:   2,
+			Containers: []requests.Container{{Name: "web", Image: "nginx"}},
+		},
+		Rollout: &responses.AppRollout{
+			Revision: "3",
+			Replicas: 2,
+			State:    "RollingOut",
+		},
+		Pods: []responses.AppPod{
+			{Name: "app-a-1", Phase: "Running", Restarts: 1},
+		},
+		Events: []responses.AppEvent{
+			{Type: "Normal", Reason: "ScalingReplicaSet", Object: "Deployment/app-a", Count: 1},
+		},
+	}
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleViewer,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), projName, appName).Return(rawRespData, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s", projName, appName), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var respData *responses.GetApp
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Equal(appName, respData.Name)
+	suite.Equal(int32(2), respData.Spec.Replicas)
+	suite.Equal("nginx", respData.Spec.Containers[0].Image)
+	suite.Equal("RollingOut", respData.Rollout.State)
+	suite.Len(respData.Pods, 1)
+	suite.Equal(int32(1), respData.Pods[0].Restarts)
+	suite.Len(respData.Events, 1)
+	suite.Equal("Deployment/app-a", respData.Events[0].Object)
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleGetApp_ProjectNotFound() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+	projName := "project-a"
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(nil, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s", projName, appName), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	var respData *handlers.JSONErr
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+	suite.Equal("project not found: project-a", respData.Err)
+
+	appSvc.AssertNotCalled(suite.T(), "Get", mock.Anything, projName, appName)
+}
+
 func (suite *AppTestSuite) Test_HandleRestartApp_Ok() {
 	userName := "test-user"
 	token, err := auth.Login(userName)
@@ -186,13 +454,17 @@ func (suite *AppTestSuite) Test_HandleRestartApp_Ok() {
 
 	appSvc := new(mocks.AppSvc)
 	projectSvc := new(mocks.ProjectSvc)
-	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc}
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
 
 	appName := "app-a"
 
 	projName := "project-a"
 	proj := &responses.Project{
 		Name: projName,
+		Role: services.ProjectRoleDeveloper,
 	}
 
 	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
@@ -220,3 +492,627 @@ func (suite *AppTestSuite) Test_HandleRestartApp_Ok() {
 
 	appSvc.AssertExpectations(suite.T())
 }
+
+func (suite *AppTestSuite) Test_HandleAttachAppDomain_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+	reqData := &requests.AttachDomain{
+		Domain: "www.example.com",
+	}
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("AttachDomain", mock.AnythingOfType("*context.valueCtx"), projName, appName, reqData).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	var b bytes.Buffer
+	json.NewEncoder(&b).Encode(reqData)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s/domains", projName, appName), &b)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleDetachAppDomain_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+	domain := "www.example.com"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("DetachDomain", mock.AnythingOfType("*context.valueCtx"), projName, appName, domain).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s/domains/%s", projName, appName, domain), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleDeleteApp_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("Delete", mock.AnythingOfType("*context.valueCtx"), projName, appName).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s", projName, appName), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleDeleteApp_Viewer() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleViewer,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s", projName, appName), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	appSvc.AssertNotCalled(suite.T(), "Delete", mock.Anything, projName, appName)
+}
+
+func (suite *AppTestSuite) Test_HandleAppLogs_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleViewer,
+	}
+
+	tailLines := int64(10)
+	sinceSeconds := int64(300)
+	reqData := &requests.AppLogs{
+		Container:    "web",
+		Follow:       true,
+		TailLines:    &tailLines,
+		SinceSeconds: &sinceSeconds,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("Logs", mock.AnythingOfType("*context.valueCtx"), projName, appName, reqData, mock.Anything).
+		Run(func(args mock.Arguments) {
+			w := args.Get(4).(io.Writer)
+			w.Write([]byte("[app-a-1] started\n"))
+			w.Write([]byte("[app-a-2] started\n"))
+		}).
+		Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s/logs?container=web&follow=true&tail=10&since=5m", projName, appName), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("[app-a-1] started\n[app-a-2] started\n", string(respData))
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleAppLogs_Error() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleViewer,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("Logs", mock.AnythingOfType("*context.valueCtx"), projName, appName, &requests.AppLogs{}, mock.Anything).
+		Return(fmt.Errorf("no pods found for app %s", appName))
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s/logs", projName, appName), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var respData *handlers.JSONErr
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+	suite.Equal("no pods found for app app-a", respData.Err)
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+// fakeExecSession echoes the first stdin message as stdout then exits
+type fakeExecSession struct {
+	sent     chan []byte
+	received int
+}
+
+func (s *fakeExecSession) Receive() ([]byte, error) {
+	s.received++
+	switch s.received {
+	case 1:
+		select {
+		case msg := <-s.sent:
+			return append([]byte{services.ExecStreamStdout}, msg[1:]...), nil
+		case <-time.After(5 * time.Second):
+			return nil, fmt.Errorf("no stdin")
+		}
+	case 2:
+		return append([]byte{services.ExecStreamError}, []byte(`{"metadata":{},"status":"Success"}`)...), nil
+	}
+	return nil, io.EOF
+}
+
+func (s *fakeExecSession) Send(msg []byte) error {
+	s.sent <- msg
+	return nil
+}
+
+func (s *fakeExecSession) Close() error {
+	return nil
+}
+
+func (suite *AppTestSuite) Test_HandleAppExec_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	reqData := &requests.AppExec{
+		Container: "web",
+		Command:   []string{"sh", "-c", "cat"},
+		Stdin:     true,
+		TTY:       true,
+	}
+
+	session := &fakeExecSession{sent: make(chan []byte, 10)}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("Exec", mock.AnythingOfType("*context.valueCtx"), projName, appName, reqData).Return(session, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	wsURL := strings.Replace(s.URL, "http://", "ws://", 1) + fmt.Sprintf("/v1/projects/%s/apps/%s/exec?container=web&command=sh&command=-c&command=cat&stdin=true&tty=true", projName, appName)
+	config, err := websocket.NewConfig(wsURL, s.URL)
+	suite.NoError(err)
+	config.Protocol = []string{services.ExecProtocol}
+	config.Header = http.Header{"Authorization": []string{"Bearer " + token}}
+
+	ws, err := websocket.DialConfig(config)
+	suite.NoError(err)
+	defer ws.Close()
+
+	// clients can't write to the output streams
+	err = websocket.Message.Send(ws, []byte{services.ExecStreamStdout, 'x'})
+	suite.NoError(err)
+	err = websocket.Message.Send(ws, []byte{services.ExecStreamStdin, 'h', 'i'})
+	suite.NoError(err)
+
+	var msg []byte
+	err = websocket.Message.Receive(ws, &msg)
+	suite.NoError(err)
+	suite.Equal([]byte{services.ExecStreamStdout, 'h', 'i'}, msg)
+
+	err = websocket.Message.Receive(ws, &msg)
+	suite.NoError(err)
+	suite.Equal(services.ExecStreamError, msg[0])
+
+	err = websocket.Message.Receive(ws, &msg)
+	suite.Equal(io.EOF, err)
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleAppExec_Viewer() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleViewer,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s/exec?command=sh", projName, appName), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	appSvc.AssertNotCalled(suite.T(), "Exec", mock.Anything, projName, appName, mock.Anything)
+}
+
+func (suite *AppTestSuite) Test_HandleAppExec_Origin() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	reqData := &requests.AppExec{
+		Command: []string{"sh"},
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("Exec", mock.AnythingOfType("*context.valueCtx"), projName, appName, reqData).Return(&fakeExecSession{sent: make(chan []byte, 10)}, nil)
+
+	os.Setenv("ALLOWED_ORIGINS", "https://console.example.com")
+	defer os.Unsetenv("ALLOWED_ORIGINS")
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	dial := func(origin string) error {
+		wsURL := strings.Replace(s.URL, "http://", "ws://", 1) + fmt.Sprintf("/v1/projects/%s/apps/%s/exec?command=sh", projName, appName)
+		config, err := websocket.NewConfig(wsURL, origin)
+		suite.NoError(err)
+		config.Protocol = []string{services.ExecProtocol}
+		config.Header = http.Header{"Authorization": []string{"Bearer " + token}}
+
+		ws, err := websocket.DialConfig(config)
+		if err != nil {
+			return err
+		}
+		return ws.Close()
+	}
+
+	// pages of other sites can't open sessions with the credentials of the browser
+	suite.Error(dial("https://evil.example.com"))
+	appSvc.AssertNotCalled(suite.T(), "Exec", mock.Anything, projName, appName, mock.Anything)
+
+	suite.NoError(dial("https://console.example.com"))
+	appSvc.AssertExpectations(suite.T())
+}
+
+// fakePortForwardSession echoes the data it receives until closed
+type fakePortForwardSession struct {
+	sent   chan []byte
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (s *fakePortForwardSession) Receive() ([]byte, error) {
+	select {
+	case msg := <-s.sent:
+		return msg, nil
+	case <-s.closed:
+		return nil, io.EOF
+	}
+}
+
+func (s *fakePortForwardSession) Send(msg []byte) error {
+	s.sent <- msg
+	return nil
+}
+
+func (s *fakePortForwardSession) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+func (suite *AppTestSuite) Test_HandleAppPortForward_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	reqData := &requests.AppPortForward{
+		Pod:  "app-a-1",
+		Port: 5432,
+	}
+
+	session := &fakePortForwardSession{sent: make(chan []byte, 10), closed: make(chan struct{})}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+	appSvc.On("PortForward", mock.AnythingOfType("*context.valueCtx"), projName, appName, reqData).Return(session, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	wsURL := strings.Replace(s.URL, "http://", "ws://", 1) + fmt.Sprintf("/v1/projects/%s/apps/%s/port-forward?pod=app-a-1&port=5432", projName, appName)
+	config, err := websocket.NewConfig(wsURL, s.URL)
+	suite.NoError(err)
+	config.Protocol = []string{services.PortForwardProtocol}
+	config.Header = http.Header{"Authorization": []string{"Bearer " + token}}
+
+	ws, err := websocket.DialConfig(config)
+	suite.NoError(err)
+
+	// clients can't write to the error stream
+	err = websocket.Message.Send(ws, []byte{services.PortForwardStreamError, 'x'})
+	suite.NoError(err)
+	err = websocket.Message.Send(ws, []byte{services.PortForwardStreamData, 'p', 'g'})
+	suite.NoError(err)
+
+	var msg []byte
+	err = websocket.Message.Receive(ws, &msg)
+	suite.NoError(err)
+	suite.Equal([]byte{services.PortForwardStreamData, 'p', 'g'}, msg)
+
+	// closing the client closes the session
+	ws.Close()
+	select {
+	case <-session.closed:
+	case <-time.After(5 * time.Second):
+		suite.Fail("session not closed")
+	}
+
+	appSvc.AssertExpectations(suite.T())
+}
+
+func (suite *AppTestSuite) Test_HandleAppPortForward_InvalidPort() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{AppSvc: appSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	appName := "app-a"
+
+	projName := "project-a"
+	proj := &responses.Project{
+		Name: projName,
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, projName).Return(proj, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+fmt.Sprintf("/v1/projects/%s/apps/%s/port-forward?port=70000", projName, appName), nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	var respData *handlers.JSONErr
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+	suite.Equal("invalid port: 70000", respData.Err)
+
+	appSvc.AssertNotCalled(suite.T(), "PortForward", mock.Anything, projName, appName, mock.Anything)
+}
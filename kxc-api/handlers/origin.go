@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// AllowedOrigins returns the browser origins allowed besides the api host, set by ALLOWED_ORIGINS
+// as a comma separated list such as https://console.example.com
+func AllowedOrigins() []string {
+	origins := []string{}
+
+	for _, origin := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return origins
+}
+
+// OriginAllowed returns true if origin is the api host itself or one of the allowed origins
+func OriginAllowed(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range AllowedOrigins() {
+		if strings.EqualFold(strings.TrimSuffix(origin, "/"), allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// websocketOriginAllowed checks the origin of websocket upgrades, which browsers send cross site without cors checks.
+// Requests without an origin come from non browser clients such as the cli
+func websocketOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || OriginAllowed(r, origin)
+}
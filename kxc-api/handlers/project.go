@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/go-chi/chi"
 )
 
 // HandleCreateProject creates a project
@@ -18,6 +21,12 @@ func (root *Root) HandleCreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// project limited tokens can't create projects
+	if accessToken := RequestAccessToken(r); accessToken != nil && len(accessToken.Projects) > 0 {
+		JSONError(w, "not authorized", http.StatusUnauthorized)
+		return
+	}
+
 	err = root.ProjectSvc.Create(r.Context(), userName, reqData)
 	if err != nil {
 		root.HandleError(w, r, err)
@@ -37,5 +46,176 @@ func (root *Root) HandleListProjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if accessToken := RequestAccessToken(r); accessToken != nil {
+		projects := respData.Projects[:0]
+		for _, proj := range respData.Projects {
+			if accessToken.AllowsProject(proj.Name) {
+				projects = append(projects, proj)
+			}
+		}
+		respData.Projects = projects
+	}
+
 	JSONOk(w, respData)
 }
+
+// HandleDeleteProject deletes a project
+func (root *Root) HandleDeleteProject(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	projectName := chi.URLParam(r, "project")
+
+	if !tokenAllowsProject(r, projectName) {
+		JSONError(w, "not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := root.ProjectSvc.Delete(r.Context(), userName, projectName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// HandleSetProjectQuota sets the quota of a project
+func (root *Root) HandleSetProjectQuota(w http.ResponseWriter, r *http.Request) {
+	projectName := chi.URLParam(r, "project")
+
+	reqData := &requests.SetProjectQuota{}
+
+	err := readJSON(r, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	err = root.ProjectSvc.SetQuota(r.Context(), projectName, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// HandleGetProject gets a project
+func (root *Root) HandleGetProject(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	projectName := chi.URLParam(r, "project")
+
+	if !tokenAllowsProject(r, projectName) {
+		JSONError(w, "not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	respData, err := root.ProjectSvc.Get(r.Context(), userName, projectName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+	if respData == nil {
+		root.HandleError(w, r, fmt.Errorf("project not found: %s", projectName))
+		return
+	}
+
+	JSONOk(w, respData)
+}
+
+// HandleAddProjectMember adds a member to a project
+func (root *Root) HandleAddProjectMember(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	projectName := chi.URLParam(r, "project")
+
+	reqData := &requests.AddProjectMember{}
+
+	err := readJSON(r, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleOwner) {
+		return
+	}
+
+	err = root.ProjectSvc.AddMember(r.Context(), projectName, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// HandleUpdateProjectMember changes the role of a project member
+func (root *Root) HandleUpdateProjectMember(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	projectName := chi.URLParam(r, "project")
+	memberName := chi.URLParam(r, "member")
+
+	reqData := &requests.UpdateProjectMember{}
+
+	err := readJSON(r, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleOwner) {
+		return
+	}
+
+	err = root.ProjectSvc.UpdateMember(r.Context(), projectName, memberName, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// HandleRemoveProjectMember removes a member from a project
+func (root *Root) HandleRemoveProjectMember(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	projectName := chi.URLParam(r, "project")
+	memberName := chi.URLParam(r, "member")
+
+	if !root.authorizeProject(w, r, userName, projectName, services.ProjectRoleOwner) {
+		return
+	}
+
+	err := root.ProjectSvc.RemoveMember(r.Context(), projectName, memberName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// authorizeProject checks that the user is a member of the project with at least minRole
+// and renders the error otherwise
+func (root *Root) authorizeProject(w http.ResponseWriter, r *http.Request, userName, projectName, minRole string) bool {
+	if !tokenAllowsProject(r, projectName) {
+		JSONError(w, "not authorized", http.StatusUnauthorized)
+		return false
+	}
+
+	project, err := root.ProjectSvc.Get(r.Context(), userName, projectName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return false
+	}
+	if project == nil {
+		root.HandleError(w, r, fmt.Errorf("project not found: %s", projectName))
+		return false
+	}
+
+	if !services.ProjectRoleAllows(project.Role, minRole) {
+		JSONError(w, "not authorized", http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
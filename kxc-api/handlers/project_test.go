@@ -12,6 +12,7 @@ import (
 	"github.com/didil/kubexcloud/kxc-api/handlers"
 	"github.com/didil/kubexcloud/kxc-api/requests"
 	"github.com/didil/kubexcloud/kxc-api/responses"
+	"github.com/didil/kubexcloud/kxc-api/services"
 	"github.com/didil/kubexcloud/kxc-api/testsupport"
 	"github.com/didil/kubexcloud/kxc-api/testsupport/auth"
 	"github.com/didil/kubexcloud/kxc-api/testsupport/mocks"
@@ -36,7 +37,10 @@ func (suite *ProjectTestSuite) Test_HandleCreateProject_Ok() {
 	suite.NoError(err)
 
 	projectSvc := new(mocks.ProjectSvc)
-	root := &handlers.Root{ProjectSvc: projectSvc}
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
 
 	reqData := &requests.CreateProject{
 		Name: "project-a",
@@ -77,7 +81,10 @@ func (suite *ProjectTestSuite) Test_HandleListProjects_Ok() {
 	suite.NoError(err)
 
 	projectSvc := new(mocks.ProjectSvc)
-	root := &handlers.Root{ProjectSvc: projectSvc}
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
 
 	rawRespData := &responses.ListProject{
 		Projects: []responses.ListProjectEntry{
@@ -135,3 +142,356 @@ func (suite *ProjectTestSuite) Test_HandleListProjects_NoAuth() {
 
 	projectSvc.AssertExpectations(suite.T())
 }
+
+func (suite *ProjectTestSuite) Test_HandleDeleteProject_Ok() {
+	userName := "test-user"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	projectSvc.On("Delete", mock.AnythingOfType("*context.valueCtx"), userName, "project-a").Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+"/v1/projects/project-a", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	projectSvc.AssertExpectations(suite.T())
+}
+
+func (suite *ProjectTestSuite) Test_HandleSetProjectQuota_Ok() {
+	userName := "adminUser"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	userSvc := new(mocks.UserSvc)
+	userSvc.On("HasRole", mock.AnythingOfType("*context.valueCtx"), userName, services.UserRoleAdmin).Return(true, nil)
+
+	projectSvc := new(mocks.ProjectSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	reqData := &requests.SetProjectQuota{
+		CPU:    "2",
+		Memory: "4Gi",
+		Pods:   10,
+		Apps:   3,
+	}
+
+	projectSvc.On("SetQuota", mock.AnythingOfType("*context.valueCtx"), "project-a", reqData).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	var b bytes.Buffer
+	json.NewEncoder(&b).Encode(reqData)
+
+	req, err := http.NewRequest(http.MethodPut, s.URL+"/v1/projects/project-a/quota", &b)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	projectSvc.AssertExpectations(suite.T())
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *ProjectTestSuite) Test_HandleSetProjectQuota_NotAdmin() {
+	userName := "regularUser"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	userSvc := new(mocks.UserSvc)
+	userSvc.On("HasRole", mock.AnythingOfType("*context.valueCtx"), userName, services.UserRoleAdmin).Return(false, nil)
+
+	projectSvc := new(mocks.ProjectSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodPut, s.URL+"/v1/projects/project-a/quota", bytes.NewBufferString(`{"pods":10}`))
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	projectSvc.AssertExpectations(suite.T())
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *ProjectTestSuite) Test_HandleGetProject_Ok() {
+	userName := "test-user"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	proj := &responses.Project{
+		Name: "project-a",
+		Role: services.ProjectRoleViewer,
+		Members: []responses.ProjectMember{
+			responses.ProjectMember{UserName: "owner-user", Role: services.ProjectRoleOwner},
+			responses.ProjectMember{UserName: userName, Role: services.ProjectRoleViewer},
+		},
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, "project-a").Return(proj, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/projects/project-a", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var respData *responses.Project
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Equal(proj, respData)
+
+	projectSvc.AssertExpectations(suite.T())
+}
+
+func (suite *ProjectTestSuite) Test_HandleAddProjectMember_Ok() {
+	userName := "test-user"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	reqData := &requests.AddProjectMember{
+		UserName: "user-b",
+		Role:     services.ProjectRoleDeveloper,
+	}
+
+	proj := &responses.Project{
+		Name: "project-a",
+		Role: services.ProjectRoleOwner,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, "project-a").Return(proj, nil)
+	projectSvc.On("AddMember", mock.AnythingOfType("*context.valueCtx"), "project-a", reqData).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/projects/project-a/members", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	projectSvc.AssertExpectations(suite.T())
+}
+
+func (suite *ProjectTestSuite) Test_HandleAddProjectMember_NotOwner() {
+	userName := "test-user"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	reqData := &requests.AddProjectMember{
+		UserName: "user-b",
+		Role:     services.ProjectRoleOwner,
+	}
+
+	proj := &responses.Project{
+		Name: "project-a",
+		Role: services.ProjectRoleDeveloper,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, "project-a").Return(proj, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/projects/project-a/members", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	projectSvc.AssertNotCalled(suite.T(), "AddMember", mock.Anything, "project-a", reqData)
+}
+
+func (suite *ProjectTestSuite) Test_HandleUpdateProjectMember_Ok() {
+	userName := "test-user"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	reqData := &requests.UpdateProjectMember{
+		Role: services.ProjectRoleViewer,
+	}
+
+	proj := &responses.Project{
+		Name: "project-a",
+		Role: services.ProjectRoleOwner,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, "project-a").Return(proj, nil)
+	projectSvc.On("UpdateMember", mock.AnythingOfType("*context.valueCtx"), "project-a", "user-b", reqData).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPut, s.URL+"/v1/projects/project-a/members/user-b", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	projectSvc.AssertExpectations(suite.T())
+}
+
+func (suite *ProjectTestSuite) Test_HandleRemoveProjectMember_Ok() {
+	userName := "test-user"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	proj := &responses.Project{
+		Name: "project-a",
+		Role: services.ProjectRoleOwner,
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, "project-a").Return(proj, nil)
+	projectSvc.On("RemoveMember", mock.AnythingOfType("*context.valueCtx"), "project-a", "user-b").Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+"/v1/projects/project-a/members/user-b", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	projectSvc.AssertExpectations(suite.T())
+}
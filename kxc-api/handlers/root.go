@@ -12,6 +12,8 @@ type Root struct {
 	ProjectSvc services.ProjectSvc
 	AppSvc     services.AppSvc
 	UserSvc    services.UserSvc
+	TokenSvc   services.TokenSvc
+	WatchSvc   services.WatchSvc
 }
 
 // HandleError handles errors
@@ -71,3 +73,20 @@ func readJSON(r *http.Request, v interface{}) error {
 
 // CtxKey context key
 type CtxKey string
+
+// flushWriter flushes every write to the client, for streamed responses
+type flushWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (fw *flushWriter) Write(b []byte) (int, error) {
+	fw.written = true
+
+	n, err := fw.w.Write(b)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	return n, err
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/go-chi/chi"
+)
+
+// HandleCreateToken creates a personal access token for the current user
+func (root *Root) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+
+	// tokens can't be used to create other tokens
+	if RequestAccessToken(r) != nil {
+		JSONError(w, "not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	reqData := &requests.CreateToken{}
+
+	err := readJSON(r, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	for _, projectName := range reqData.Projects {
+		project, err := root.ProjectSvc.Get(r.Context(), userName, projectName)
+		if err != nil {
+			root.HandleError(w, r, err)
+			return
+		}
+		if project == nil {
+			root.HandleError(w, r, fmt.Errorf("project not found: %s", projectName))
+			return
+		}
+	}
+
+	respData, err := root.TokenSvc.Create(r.Context(), userName, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, respData)
+}
+
+// HandleListTokens lists the personal access tokens of the current user
+func (root *Root) HandleListTokens(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+
+	respData, err := root.TokenSvc.List(r.Context(), userName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, respData)
+}
+
+// HandleRevokeToken revokes a personal access token of the current user
+func (root *Root) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+	tokenID := chi.URLParam(r, "token")
+
+	if RequestAccessToken(r) != nil {
+		JSONError(w, "not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := root.TokenSvc.Revoke(r.Context(), userName, tokenID)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// RequestAccessToken returns the personal access token the request was authenticated with, nil for sessions
+func RequestAccessToken(r *http.Request) *services.AccessToken {
+	accessToken, _ := r.Context().Value(CtxKey("accessToken")).(*services.AccessToken)
+	return accessToken
+}
+
+// tokenAllowsProject returns true if the request credentials give access to the project
+func tokenAllowsProject(r *http.Request, projectName string) bool {
+	accessToken := RequestAccessToken(r)
+	return accessToken == nil || accessToken.AllowsProject(projectName)
+}
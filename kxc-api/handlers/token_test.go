@@ -0,0 +1,266 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/didil/kubexcloud/kxc-api"
+	"github.com/didil/kubexcloud/kxc-api/handlers"
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	"github.com/didil/kubexcloud/kxc-api/testsupport/auth"
+	"github.com/didil/kubexcloud/kxc-api/testsupport/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type TokenTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TokenTestSuite) SetupSuite() {
+	testsupport.BootstrapTests("../.env.test")
+}
+func TestTokenTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenTestSuite))
+}
+
+func (suite *TokenTestSuite) Test_HandleCreateToken_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	tokenSvc := new(mocks.TokenSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{TokenSvc: tokenSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	reqData := &requests.CreateToken{
+		Name:          "ci",
+		ExpiresInDays: 30,
+		ReadOnly:      true,
+		Projects:      []string{"project-a"},
+	}
+
+	rawRespData := &responses.CreateToken{
+		ID:    "abcdef012345",
+		Name:  "ci",
+		Token: "kxc_abcdef012345_secret",
+	}
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, "project-a").Return(&responses.Project{Name: "project-a"}, nil)
+	tokenSvc.On("Create", mock.AnythingOfType("*context.valueCtx"), userName, reqData).Return(rawRespData, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/me/tokens", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var respData *responses.CreateToken
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Equal(rawRespData, respData)
+
+	tokenSvc.AssertExpectations(suite.T())
+	projectSvc.AssertExpectations(suite.T())
+}
+
+func (suite *TokenTestSuite) Test_HandleCreateToken_WithAccessToken() {
+	userName := "test-user"
+	token := "kxc_abcdef012345_secret"
+
+	tokenSvc := new(mocks.TokenSvc)
+	root := &handlers.Root{TokenSvc: tokenSvc}
+
+	reqData := &requests.CreateToken{
+		Name: "ci",
+	}
+
+	tokenSvc.On("Authenticate", mock.AnythingOfType("*context.valueCtx"), token).Return(&services.AccessToken{ID: "abcdef012345", UserName: userName}, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/me/tokens", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	tokenSvc.AssertNotCalled(suite.T(), "Create", mock.Anything, userName, mock.Anything)
+}
+
+func (suite *TokenTestSuite) Test_HandleListTokens_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	tokenSvc := new(mocks.TokenSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{TokenSvc: tokenSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	rawRespData := &responses.ListToken{
+		Tokens: []responses.ListTokenEntry{
+			responses.ListTokenEntry{
+				ID:       "abcdef012345",
+				Name:     "ci",
+				Projects: []string{},
+			},
+		},
+	}
+
+	tokenSvc.On("List", mock.AnythingOfType("*context.valueCtx"), userName).Return(rawRespData, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/users/me/tokens", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var respData *responses.ListToken
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Len(respData.Tokens, 1)
+	suite.Equal("abcdef012345", respData.Tokens[0].ID)
+	suite.Equal("ci", respData.Tokens[0].Name)
+
+	tokenSvc.AssertExpectations(suite.T())
+}
+
+func (suite *TokenTestSuite) Test_HandleRevokeToken_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	tokenSvc := new(mocks.TokenSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{TokenSvc: tokenSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	tokenSvc.On("Revoke", mock.AnythingOfType("*context.valueCtx"), userName, "abcdef012345").Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+"/v1/users/me/tokens/abcdef012345", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	tokenSvc.AssertExpectations(suite.T())
+}
+
+func (suite *TokenTestSuite) Test_ReadOnlyToken_Write() {
+	userName := "test-user"
+	token := "kxc_abcdef012345_secret"
+
+	tokenSvc := new(mocks.TokenSvc)
+	appSvc := new(mocks.AppSvc)
+	root := &handlers.Root{TokenSvc: tokenSvc, AppSvc: appSvc}
+
+	tokenSvc.On("Authenticate", mock.AnythingOfType("*context.valueCtx"), token).Return(&services.AccessToken{ID: "abcdef012345", UserName: userName, ReadOnly: true}, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+"/v1/projects/project-a/apps/app-a", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	appSvc.AssertNotCalled(suite.T(), "Delete", mock.Anything, "project-a", "app-a")
+}
+
+func (suite *TokenTestSuite) Test_ProjectLimitedToken_OtherProject() {
+	userName := "test-user"
+	token := "kxc_abcdef012345_secret"
+
+	tokenSvc := new(mocks.TokenSvc)
+	appSvc := new(mocks.AppSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	root := &handlers.Root{TokenSvc: tokenSvc, AppSvc: appSvc, ProjectSvc: projectSvc}
+
+	tokenSvc.On("Authenticate", mock.AnythingOfType("*context.valueCtx"), token).Return(&services.AccessToken{ID: "abcdef012345", UserName: userName, Projects: []string{"project-a"}}, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/projects/project-b/apps", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	appSvc.AssertNotCalled(suite.T(), "List", mock.Anything, "project-b")
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
+	"net"
 	"net/http"
 
 	"github.com/didil/kubexcloud/kxc-api/requests"
-	"github.com/didil/kubexcloud/kxc-api/responses"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/go-chi/chi"
 )
 
 // HandleLoginUser login user
@@ -16,19 +20,94 @@ func (root *Root) HandleLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := root.UserSvc.Login(r.Context(), reqData.Name, reqData.Password)
+	respData, err := root.UserSvc.Login(r.Context(), reqData.Name, reqData.Password, clientIP(r))
+	if err == services.ErrLoginRateLimited {
+		JSONError(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err == services.ErrInvalidCredentials {
+		JSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		// the details of failed logins stay in the logs, they can tell whether the user exists
+		log.Printf("login %s: %v\n", reqData.Name, err)
+		JSONError(w, "login failed", http.StatusInternalServerError)
+		return
+	}
+
+	JSONOk(w, respData)
+}
+
+// clientIP returns the ip of the client, the router resolves the headers of trusted proxies into RemoteAddr
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// HandleGetOIDCConfig returns the oidc provider used to log in
+func (root *Root) HandleGetOIDCConfig(w http.ResponseWriter, r *http.Request) {
+	respData, err := root.UserSvc.OIDCConfig(r.Context())
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, respData)
+}
+
+// HandleLoginUserOIDC exchanges an oidc id token for a session
+func (root *Root) HandleLoginUserOIDC(w http.ResponseWriter, r *http.Request) {
+	reqData := &requests.LoginUserOIDC{}
+	err := readJSON(r, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	respData, err := root.UserSvc.LoginOIDC(r.Context(), reqData.IDToken)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, respData)
+}
+
+// HandleRefreshUser exchanges a refresh token for a new session
+func (root *Root) HandleRefreshUser(w http.ResponseWriter, r *http.Request) {
+	reqData := &requests.RefreshUser{}
+	err := readJSON(r, reqData)
 	if err != nil {
 		root.HandleError(w, r, err)
 		return
 	}
 
-	respData := &responses.LoginUser{
-		Token: token,
+	respData, err := root.UserSvc.Refresh(r.Context(), reqData.RefreshToken)
+	if err != nil {
+		JSONError(w, err.Error(), http.StatusUnauthorized)
+		return
 	}
 
 	JSONOk(w, respData)
 }
 
+// HandleLogoutUser revokes all the sessions of the current user
+func (root *Root) HandleLogoutUser(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+
+	err := root.UserSvc.Logout(r.Context(), userName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
 // HandleCreateUser creates user
 func (root *Root) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	reqData := &requests.CreateUser{}
@@ -58,3 +137,35 @@ func (root *Root) HandleListUsers(w http.ResponseWriter, r *http.Request) {
 
 	JSONOk(w, respData)
 }
+
+// HandleDeleteUser deletes a user
+func (root *Root) HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	currentUserName := r.Context().Value(CtxKey("userName")).(string)
+	userName := chi.URLParam(r, "user")
+
+	if userName == currentUserName {
+		root.HandleError(w, r, fmt.Errorf("can't delete the current user"))
+		return
+	}
+
+	err := root.UserSvc.Delete(r.Context(), userName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
+
+// HandleUnlockUser lets a locked out user log in again
+func (root *Root) HandleUnlockUser(w http.ResponseWriter, r *http.Request) {
+	userName := chi.URLParam(r, "user")
+
+	err := root.UserSvc.Unlock(r.Context(), userName)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	JSONOk(w, &struct{}{})
+}
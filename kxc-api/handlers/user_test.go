@@ -3,9 +3,11 @@ package handlers_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	api "github.com/didil/kubexcloud/kxc-api"
@@ -41,8 +43,14 @@ func (suite *UserTestSuite) Test_HandleLoginUser_Ok() {
 	}
 
 	token := "TEST_AUTH_TOKEN"
+	refreshToken := "TEST_REFRESH_TOKEN"
 
-	userSvc.On("Login", mock.AnythingOfType("*context.valueCtx"), reqData.Name, reqData.Password).Return(token, nil)
+	rawRespData := &responses.LoginUser{
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
+
+	userSvc.On("Login", mock.AnythingOfType("*context.valueCtx"), reqData.Name, reqData.Password, "127.0.0.1").Return(rawRespData, nil)
 
 	r := api.BuildRouter(root)
 	s := httptest.NewServer(r)
@@ -68,10 +76,371 @@ func (suite *UserTestSuite) Test_HandleLoginUser_Ok() {
 	suite.NoError(err)
 
 	suite.Equal(token, respData.Token)
+	suite.Equal(refreshToken, respData.RefreshToken)
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_HandleLoginUser_ForwardedIP() {
+	reqData := &requests.LoginUser{
+		Name:     "test-user",
+		Password: "123456",
+	}
+
+	login := func(expectedIP string) {
+		userSvc := new(mocks.UserSvc)
+		root := &handlers.Root{UserSvc: userSvc}
+
+		userSvc.On("Login", mock.AnythingOfType("*context.valueCtx"), reqData.Name, reqData.Password, expectedIP).Return(&responses.LoginUser{}, nil)
+
+		r := api.BuildRouter(root)
+		s := httptest.NewServer(r)
+		defer s.Close()
+
+		var b bytes.Buffer
+		json.NewEncoder(&b).Encode(reqData)
+
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/login", &b)
+		suite.NoError(err)
+
+		req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+		req.Header.Set("X-Real-IP", "203.0.113.8")
+
+		resp, err := http.DefaultClient.Do(req)
+		suite.NoError(err)
+
+		defer resp.Body.Close()
+		suite.Equal(http.StatusOK, resp.StatusCode)
+
+		userSvc.AssertExpectations(suite.T())
+	}
+
+	// forwarded headers are ignored from untrusted peers
+	login("127.0.0.1")
+
+	// trusted proxies are skipped from the right of X-Forwarded-For
+	os.Setenv("TRUSTED_PROXIES", "127.0.0.1, 10.0.0.0/8")
+	defer os.Unsetenv("TRUSTED_PROXIES")
+	login("203.0.113.7")
+}
+
+func (suite *UserTestSuite) Test_HandleLoginUser_InvalidCredentials() {
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{UserSvc: userSvc}
+
+	reqData := &requests.LoginUser{
+		Name:     "test-user",
+		Password: "wrong-password",
+	}
+
+	userSvc.On("Login", mock.AnythingOfType("*context.valueCtx"), reqData.Name, reqData.Password, "127.0.0.1").Return(nil, services.ErrInvalidCredentials)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/login", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	var respData *handlers.JSONErr
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Equal("invalid user name or password", respData.Err)
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_HandleLoginUser_InternalError() {
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{UserSvc: userSvc}
+
+	reqData := &requests.LoginUser{
+		Name:     "test-user",
+		Password: "123456",
+	}
+
+	userSvc.On("Login", mock.AnythingOfType("*context.valueCtx"), reqData.Name, reqData.Password, "127.0.0.1").Return(nil, fmt.Errorf("get user secret: secrets \"kxc-user-test-user\" not found"))
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/login", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	var respData *handlers.JSONErr
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Equal("login failed", respData.Err)
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_HandleLoginUser_RateLimited() {
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{UserSvc: userSvc}
+
+	reqData := &requests.LoginUser{
+		Name:     "test-user",
+		Password: "123456",
+	}
+
+	userSvc.On("Login", mock.AnythingOfType("*context.valueCtx"), reqData.Name, reqData.Password, "10.1.2.3").Return(nil, services.ErrLoginRateLimited)
+
+	os.Setenv("TRUSTED_PROXIES", "127.0.0.1")
+	defer os.Unsetenv("TRUSTED_PROXIES")
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/login", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_HandleRefreshUser_Ok() {
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{UserSvc: userSvc}
+
+	reqData := &requests.RefreshUser{
+		RefreshToken: "TEST_REFRESH_TOKEN",
+	}
+
+	rawRespData := &responses.LoginUser{
+		Token:        "TEST_AUTH_TOKEN_2",
+		RefreshToken: "TEST_REFRESH_TOKEN_2",
+	}
+
+	userSvc.On("Refresh", mock.AnythingOfType("*context.valueCtx"), reqData.RefreshToken).Return(rawRespData, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/refresh", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var respData *responses.LoginUser
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Equal("TEST_AUTH_TOKEN_2", respData.Token)
+	suite.Equal("TEST_REFRESH_TOKEN_2", respData.RefreshToken)
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_HandleLogoutUser_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	userSvc.On("Logout", mock.AnythingOfType("*context.valueCtx"), userName).Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/me/logout", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("{}", string(respData))
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_RevokedSession() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	userSvc := new(mocks.UserSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	root := &handlers.Root{UserSvc: userSvc, ProjectSvc: projectSvc}
+
+	userSvc.On("ValidateSession", mock.AnythingOfType("*context.valueCtx"), userName, "", int64(0)).Return(fmt.Errorf("session revoked"))
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/projects", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	projectSvc.AssertNotCalled(suite.T(), "List", mock.Anything, userName)
+}
+
+func (suite *UserTestSuite) Test_HandleGetOIDCConfig_Ok() {
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{UserSvc: userSvc}
+
+	rawRespData := &responses.OIDCConfig{
+		Issuer:   "https://sso.example.com",
+		ClientID: "kxc",
+	}
+
+	userSvc.On("OIDCConfig", mock.AnythingOfType("*context.valueCtx")).Return(rawRespData, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/users/login/oidc", nil)
+	suite.NoError(err)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var respData *responses.OIDCConfig
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Equal("https://sso.example.com", respData.Issuer)
+	suite.Equal("kxc", respData.ClientID)
 
 	userSvc.AssertExpectations(suite.T())
 }
 
+func (suite *UserTestSuite) Test_HandleLoginUserOIDC_Ok() {
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{UserSvc: userSvc}
+
+	reqData := &requests.LoginUserOIDC{
+		IDToken: "TEST_ID_TOKEN",
+	}
+
+	rawRespData := &responses.LoginUser{
+		Token:        "TEST_AUTH_TOKEN",
+		RefreshToken: "TEST_REFRESH_TOKEN",
+	}
+
+	userSvc.On("LoginOIDC", mock.AnythingOfType("*context.valueCtx"), reqData.IDToken).Return(rawRespData, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	b, err := json.Marshal(reqData)
+	suite.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/login/oidc", bytes.NewBuffer(b))
+	suite.NoError(err)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var respData *responses.LoginUser
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+
+	suite.Equal("TEST_AUTH_TOKEN", respData.Token)
+	suite.Equal("TEST_REFRESH_TOKEN", respData.RefreshToken)
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_OIDCIDToken_Rejected() {
+	idToken := "TEST_ID_TOKEN"
+
+	userSvc := new(mocks.UserSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	root := &handlers.Root{UserSvc: userSvc, ProjectSvc: projectSvc}
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/projects", nil)
+	suite.NoError(err)
+
+	// id tokens have to be exchanged for a session first
+	req.Header.Set("Authorization", "Bearer "+idToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	userSvc.AssertExpectations(suite.T())
+	projectSvc.AssertExpectations(suite.T())
+}
+
 func (suite *UserTestSuite) Test_HandleCreateUser_Ok() {
 	userName := "adminUser"
 
@@ -83,6 +452,8 @@ func (suite *UserTestSuite) Test_HandleCreateUser_Ok() {
 
 	root := &handlers.Root{UserSvc: userSvc}
 
+	auth.MockSession(userSvc, userName)
+
 	reqData := &requests.CreateUser{
 		Name:     "test-user",
 		Password: "123456",
@@ -128,6 +499,8 @@ func (suite *UserTestSuite) Test_HandleCreateUser_NotAdmin() {
 
 	root := &handlers.Root{UserSvc: userSvc}
 
+	auth.MockSession(userSvc, userName)
+
 	reqData := &requests.CreateUser{
 		Name:     "test-user",
 		Password: "123456",
@@ -166,6 +539,8 @@ func (suite *UserTestSuite) Test_HandleListUsers_Ok() {
 
 	root := &handlers.Root{UserSvc: userSvc}
 
+	auth.MockSession(userSvc, userName)
+
 	rawRespData := &responses.ListUser{
 		Users: []responses.ListUserEntry{
 			responses.ListUserEntry{
@@ -204,3 +579,133 @@ func (suite *UserTestSuite) Test_HandleListUsers_Ok() {
 
 	userSvc.AssertExpectations(suite.T())
 }
+
+func (suite *UserTestSuite) Test_HandleDeleteUser_Ok() {
+	userName := "adminUser"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	userSvc := new(mocks.UserSvc)
+	userSvc.On("HasRole", mock.AnythingOfType("*context.valueCtx"), userName, services.UserRoleAdmin).Return(true, nil)
+
+	root := &handlers.Root{UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	userSvc.On("Delete", mock.AnythingOfType("*context.valueCtx"), "user-1").Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+"/v1/users/user-1", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_HandleDeleteUser_Self() {
+	userName := "adminUser"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	userSvc := new(mocks.UserSvc)
+	userSvc.On("HasRole", mock.AnythingOfType("*context.valueCtx"), userName, services.UserRoleAdmin).Return(true, nil)
+
+	root := &handlers.Root{UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, s.URL+"/v1/users/"+userName, nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_HandleUnlockUser_Ok() {
+	userName := "adminUser"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	userSvc := new(mocks.UserSvc)
+	userSvc.On("HasRole", mock.AnythingOfType("*context.valueCtx"), userName, services.UserRoleAdmin).Return(true, nil)
+
+	root := &handlers.Root{UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	userSvc.On("Unlock", mock.AnythingOfType("*context.valueCtx"), "user-1").Return(nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/user-1/unlock", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	userSvc.AssertExpectations(suite.T())
+}
+
+func (suite *UserTestSuite) Test_HandleUnlockUser_NotAdmin() {
+	userName := "test-user"
+
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	userSvc := new(mocks.UserSvc)
+	userSvc.On("HasRole", mock.AnythingOfType("*context.valueCtx"), userName, services.UserRoleAdmin).Return(false, nil)
+
+	root := &handlers.Root{UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/users/user-1/unlock", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	userSvc.AssertNotCalled(suite.T(), "Unlock", mock.Anything, "user-1")
+}
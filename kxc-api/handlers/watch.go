@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/services"
+)
+
+// watchKeepAlive is the interval of the comments keeping idle streams open through proxies
+const watchKeepAlive = 30 * time.Second
+
+// HandleWatch streams the app and project changes as server-sent events
+func (root *Root) HandleWatch(w http.ResponseWriter, r *http.Request) {
+	userName := r.Context().Value(CtxKey("userName")).(string)
+
+	q := r.URL.Query()
+	reqData := &requests.Watch{
+		Project: q.Get("project"),
+		App:     q.Get("app"),
+	}
+
+	// check if the project exists and the user can access it
+	if reqData.Project != "" && !root.authorizeProject(w, r, userName, reqData.Project, services.ProjectRoleViewer) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		root.HandleError(w, r, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	events, err := root.WatchSvc.Watch(r.Context(), userName, reqData)
+	if err != nil {
+		root.HandleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// disables the response buffering of nginx ingresses
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(watchKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// closed when the client falls behind, it reconnects to get a fresh snapshot
+				return
+			}
+
+			// tokens limited to some projects only see their events
+			if !tokenAllowsProject(r, event.Project) {
+				continue
+			}
+
+			b, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, b)
+			if err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			_, err := fmt.Fprintf(w, ": keep-alive\n\n")
+			if err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
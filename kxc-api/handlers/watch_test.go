@@ -0,0 +1,172 @@
+package handlers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/didil/kubexcloud/kxc-api"
+	"github.com/didil/kubexcloud/kxc-api/handlers"
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	"github.com/didil/kubexcloud/kxc-api/testsupport/auth"
+	"github.com/didil/kubexcloud/kxc-api/testsupport/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type WatchTestSuite struct {
+	suite.Suite
+}
+
+func (suite *WatchTestSuite) SetupSuite() {
+	testsupport.BootstrapTests("../.env.test")
+}
+func TestWatchTestSuite(t *testing.T) {
+	suite.Run(t, new(WatchTestSuite))
+}
+
+// readWatchEvents reads the server-sent events until the stream ends
+func readWatchEvents(suite *WatchTestSuite, resp *http.Response) []*responses.WatchEvent {
+	events := []*responses.WatchEvent{}
+
+	scanner := bufio.NewScanner(resp.Body)
+	eventType := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event := &responses.WatchEvent{}
+			err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), event)
+			suite.NoError(err)
+			suite.Equal(eventType, event.Type)
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+func (suite *WatchTestSuite) Test_HandleWatch_Ok() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	watchSvc := new(mocks.WatchSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{WatchSvc: watchSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	events := make(chan *responses.WatchEvent, 2)
+	events <- &responses.WatchEvent{Type: services.WatchEventAdded, Kind: services.WatchKindProject, Name: "project-a", Project: "project-a"}
+	events <- &responses.WatchEvent{Type: services.WatchEventModified, Kind: services.WatchKindApp, Name: "app-a", Project: "project-a", App: &responses.ListAppEntry{Name: "app-a", AvailableReplicas: 2}}
+	close(events)
+
+	watchSvc.On("Watch", mock.AnythingOfType("*context.valueCtx"), userName, &requests.Watch{}).Return((<-chan *responses.WatchEvent)(events), nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/watch", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	respEvents := readWatchEvents(suite, resp)
+	suite.Len(respEvents, 2)
+	suite.Equal(services.WatchKindProject, respEvents[0].Kind)
+	suite.Equal(services.WatchEventModified, respEvents[1].Type)
+	suite.Equal(int32(2), respEvents[1].App.AvailableReplicas)
+
+	watchSvc.AssertExpectations(suite.T())
+}
+
+func (suite *WatchTestSuite) Test_HandleWatch_ProjectLimitedToken() {
+	userName := "test-user"
+	token := "kxc_abcdef012345_secret"
+
+	tokenSvc := new(mocks.TokenSvc)
+	watchSvc := new(mocks.WatchSvc)
+	root := &handlers.Root{TokenSvc: tokenSvc, WatchSvc: watchSvc}
+
+	tokenSvc.On("Authenticate", mock.AnythingOfType("*context.valueCtx"), token).Return(&services.AccessToken{ID: "abcdef012345", UserName: userName, Projects: []string{"project-a"}}, nil)
+
+	events := make(chan *responses.WatchEvent, 2)
+	events <- &responses.WatchEvent{Type: services.WatchEventAdded, Kind: services.WatchKindApp, Name: "app-b", Project: "project-b"}
+	events <- &responses.WatchEvent{Type: services.WatchEventAdded, Kind: services.WatchKindApp, Name: "app-a", Project: "project-a"}
+	close(events)
+
+	watchSvc.On("Watch", mock.AnythingOfType("*context.valueCtx"), userName, &requests.Watch{}).Return((<-chan *responses.WatchEvent)(events), nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/watch", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	respEvents := readWatchEvents(suite, resp)
+	suite.Len(respEvents, 1)
+	suite.Equal("app-a", respEvents[0].Name)
+}
+
+func (suite *WatchTestSuite) Test_HandleWatch_ProjectNotFound() {
+	userName := "test-user"
+	token, err := auth.Login(userName)
+	suite.NoError(err)
+
+	watchSvc := new(mocks.WatchSvc)
+	projectSvc := new(mocks.ProjectSvc)
+	userSvc := new(mocks.UserSvc)
+	root := &handlers.Root{WatchSvc: watchSvc, ProjectSvc: projectSvc, UserSvc: userSvc}
+
+	auth.MockSession(userSvc, userName)
+
+	projectSvc.On("Get", mock.AnythingOfType("*context.valueCtx"), userName, "project-a").Return(nil, nil)
+
+	r := api.BuildRouter(root)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/watch?project=project-a", nil)
+	suite.NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	var respData *handlers.JSONErr
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	suite.NoError(err)
+	suite.Equal("project not found: project-a", respData.Err)
+
+	watchSvc.AssertNotCalled(suite.T(), "Watch", mock.Anything, userName, mock.Anything)
+}
@@ -25,11 +25,37 @@ func Authentication(root *handlers.Root) func(http.Handler) http.Handler {
 				}
 
 				token := authFields[1]
-				var err error
-				userName, err = services.ParseJWT(token)
-				if err != nil {
-					handlers.JSONError(w, fmt.Sprintf("invalid auth token"), http.StatusUnauthorized)
-					return
+				if services.IsAccessToken(token) {
+					accessToken, err := root.TokenSvc.Authenticate(r.Context(), token)
+					if err != nil {
+						handlers.JSONError(w, fmt.Sprintf("invalid auth token"), http.StatusUnauthorized)
+						return
+					}
+
+					if accessToken.ReadOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+						handlers.JSONError(w, "read only token", http.StatusUnauthorized)
+						return
+					}
+
+					userName = accessToken.UserName
+					r = r.WithContext(context.WithValue(r.Context(), handlers.CtxKey("accessToken"), accessToken))
+				} else {
+					// oidc id tokens aren't accepted here, they are exchanged for a session at login
+					var userUID string
+					var tokenVersion int64
+					var err error
+					userName, userUID, tokenVersion, err = services.ParseJWT(token, services.TokenTypeAccess)
+					if err != nil {
+						handlers.JSONError(w, fmt.Sprintf("invalid auth token"), http.StatusUnauthorized)
+						return
+					}
+
+					// deleted users and revoked sessions
+					err = root.UserSvc.ValidateSession(r.Context(), userName, userUID, tokenVersion)
+					if err != nil {
+						handlers.JSONError(w, fmt.Sprintf("invalid auth token"), http.StatusUnauthorized)
+						return
+					}
 				}
 			}
 
@@ -12,6 +12,12 @@ func Authorization(root *handlers.Root, role string) func(http.Handler) http.Han
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			userName := r.Context().Value(handlers.CtxKey("userName")).(string)
 
+			// project limited tokens can't be used for cluster wide operations
+			if accessToken := handlers.RequestAccessToken(r); accessToken != nil && len(accessToken.Projects) > 0 {
+				handlers.JSONError(w, "not authorized", http.StatusUnauthorized)
+				return
+			}
+
 			ok, err := root.UserSvc.HasRole(r.Context(), userName, role)
 			if err != nil {
 				root.HandleError(w, r, err)
@@ -3,17 +3,18 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/didil/kubexcloud/kxc-api/handlers"
 	"github.com/rs/cors"
 )
 
-// Cors middleware
+// Cors middleware, only the api host and the allowed origins can make credentialed cross origin requests
 func Cors(next http.Handler) http.Handler {
 
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedHeaders:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowCredentials: true,
+		AllowOriginRequestFunc: handlers.OriginAllowed,
+		AllowedHeaders:         []string{"*"},
+		AllowedMethods:         []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowCredentials:       true,
 	})
 
 	return c.Handler(next)
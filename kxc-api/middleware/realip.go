@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TrustedProxiesFromEnv reads the proxies allowed to forward client ips from TRUSTED_PROXIES,
+// a comma separated list of ips and cidrs
+func TrustedProxiesFromEnv() []*net.IPNet {
+	trusted := []*net.IPNet{}
+
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
+		}
+		trusted = append(trusted, ipNet)
+	}
+
+	return trusted
+}
+
+// RealIP middleware builder, sets RemoteAddr to the client ip forwarded in X-Forwarded-For or X-Real-IP.
+// The headers are only honoured when the request comes from a trusted proxy, so that clients can't pick their own ip
+func RealIP(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	isTrusted := func(ip net.IP) bool {
+		for _, ipNet := range trusted {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+
+			peer := net.ParseIP(host)
+			if peer == nil || !isTrusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var clientIP net.IP
+			if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+				// each proxy appends the ip it got the request from, the client is the last untrusted one
+				hops := strings.Split(xff, ",")
+				for i := len(hops) - 1; i >= 0; i-- {
+					ip := net.ParseIP(strings.TrimSpace(hops[i]))
+					if ip == nil {
+						break
+					}
+					clientIP = ip
+					if !isTrusted(ip) {
+						break
+					}
+				}
+			} else if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+				clientIP = ip
+			}
+
+			if clientIP != nil {
+				r.RemoteAddr = clientIP.String()
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
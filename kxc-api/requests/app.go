@@ -6,6 +6,11 @@ type CreateApp struct {
 	Replicas int32  `json:"replicas"`
 
 	Containers []Container `json:"containers"`
+	Volumes    []Volume    `json:"volumes,omitempty"`
+
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+
+	TLS *TLS `json:"tls,omitempty"`
 }
 
 // UpdateApp request
@@ -13,6 +18,11 @@ type UpdateApp struct {
 	Replicas int32 `json:"replicas"`
 
 	Containers []Container `json:"containers"`
+	Volumes    []Volume    `json:"volumes,omitempty"`
+
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+
+	TLS *TLS `json:"tls,omitempty"`
 }
 
 // Container object
@@ -21,6 +31,15 @@ type Container struct {
 	Name    string   `json:"name"`
 	Command []string `json:"command,omitempty"`
 	Ports   []Port   `json:"ports,omitempty"`
+	Env     []EnvVar `json:"env,omitempty"`
+
+	Resources *Resources `json:"resources,omitempty"`
+
+	LivenessProbe  *Probe `json:"livenessProbe,omitempty"`
+	ReadinessProbe *Probe `json:"readinessProbe,omitempty"`
+	StartupProbe   *Probe `json:"startupProbe,omitempty"`
+
+	VolumeMounts []VolumeMount `json:"volumeMounts,omitempty"`
 }
 
 // Port object
@@ -28,4 +47,124 @@ type Port struct {
 	Number           int32  `json:"number"`
 	Protocol         string `json:"protocol"`
 	ExposeExternally bool   `json:"exposeExternally"`
+	Path             string `json:"path,omitempty"`
+	HostSuffix       string `json:"hostSuffix,omitempty"`
+}
+
+// EnvVar object
+type EnvVar struct {
+	Name            string  `json:"name"`
+	Value           string  `json:"value,omitempty"`
+	SecretKeyRef    *KeyRef `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *KeyRef `json:"configMapKeyRef,omitempty"`
+}
+
+// KeyRef object
+type KeyRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// Resources object
+type Resources struct {
+	Requests *ResourceList `json:"requests,omitempty"`
+	Limits   *ResourceList `json:"limits,omitempty"`
+}
+
+// ResourceList object
+type ResourceList struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// Probe object
+type Probe struct {
+	HTTPGet   *HTTPGetProbe   `json:"httpGet,omitempty"`
+	TCPSocket *TCPSocketProbe `json:"tcpSocket,omitempty"`
+	Exec      *ExecProbe      `json:"exec,omitempty"`
+
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int32 `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      int32 `json:"timeoutSeconds,omitempty"`
+	FailureThreshold    int32 `json:"failureThreshold,omitempty"`
+}
+
+// HTTPGetProbe object
+type HTTPGetProbe struct {
+	Path string `json:"path,omitempty"`
+	Port int32  `json:"port"`
+}
+
+// TCPSocketProbe object
+type TCPSocketProbe struct {
+	Port int32 `json:"port"`
+}
+
+// ExecProbe object
+type ExecProbe struct {
+	Command []string `json:"command"`
+}
+
+// Volume object
+type Volume struct {
+	Name             string `json:"name"`
+	Size             string `json:"size"`
+	AccessMode       string `json:"accessMode,omitempty"`
+	StorageClassName string `json:"storageClassName,omitempty"`
+	ReclaimPolicy    string `json:"reclaimPolicy,omitempty"`
+}
+
+// VolumeMount object
+type VolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// Autoscaling object
+type Autoscaling struct {
+	MinReplicas                    int32 `json:"minReplicas"`
+	MaxReplicas                    int32 `json:"maxReplicas"`
+	TargetCPUUtilizationPercentage int32 `json:"targetCPUUtilizationPercentage"`
+}
+
+// TLS object
+type TLS struct {
+	Issuer        string `json:"issuer,omitempty"`
+	ClusterIssuer string `json:"clusterIssuer,omitempty"`
+}
+
+// AttachDomain request
+type AttachDomain struct {
+	Domain string `json:"domain"`
+}
+
+// AppLogs request, read from the query string
+type AppLogs struct {
+	// container to stream, required if the app has several
+	Container string
+	Follow    bool
+	// lines from the end of the logs of each pod, all the lines if nil
+	TailLines *int64
+	// only return the logs newer than SinceSeconds, all the logs if nil
+	SinceSeconds *int64
+}
+
+// AppExec request, read from the query string
+type AppExec struct {
+	// replica to run the command in, the first running one if empty
+	Pod string
+	// container to run the command in, required if the app has several
+	Container string
+	Command   []string
+	Stdin     bool
+	TTY       bool
+}
+
+// AppPortForward request, read from the query string
+type AppPortForward struct {
+	// replica to forward to, the first running one if empty
+	Pod string
+	// container port declared by the app
+	Port int32
 }
@@ -4,3 +4,26 @@ package requests
 type CreateProject struct {
 	Name string `json:"name"`
 }
+
+// SetProjectQuota request, an empty quota removes the limits
+type SetProjectQuota struct {
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Pods    int32  `json:"pods,omitempty"`
+	Storage string `json:"storage,omitempty"`
+	Apps    int32  `json:"apps,omitempty"`
+
+	DefaultRequests *ResourceList `json:"defaultRequests,omitempty"`
+	DefaultLimits   *ResourceList `json:"defaultLimits,omitempty"`
+}
+
+// AddProjectMember request
+type AddProjectMember struct {
+	UserName string `json:"userName"`
+	Role     string `json:"role"`
+}
+
+// UpdateProjectMember request
+type UpdateProjectMember struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,12 @@
+package requests
+
+// CreateToken request
+type CreateToken struct {
+	Name string `json:"name"`
+	// the token never expires if not set
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
+	// read only tokens can only be used for GET requests
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// projects the token is limited to, all the user projects if empty
+	Projects []string `json:"projects,omitempty"`
+}
@@ -12,3 +12,13 @@ type CreateUser struct {
 	Password string `json:"password"`
 	Role     string `json:"role"`
 }
+
+// RefreshUser request
+type RefreshUser struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// LoginUserOIDC request
+type LoginUserOIDC struct {
+	IDToken string `json:"idToken"`
+}
@@ -0,0 +1,9 @@
+package requests
+
+// Watch request, read from the query string
+type Watch struct {
+	// only stream the events of this project and its apps, all the visible projects if empty
+	Project string
+	// only stream the events of this app, requires Project
+	App string
+}
@@ -1,5 +1,11 @@
 package responses
 
+import (
+	"time"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+)
+
 // ListApp response
 type ListApp struct {
 	Apps []ListAppEntry `json:"apps"`
@@ -10,4 +16,111 @@ type ListAppEntry struct {
 	AvailableReplicas   int32  `json:"availableReplicas"`
 	UnavailableReplicas int32  `json:"unavailableReplicas"`
 	ExternalURL         string `json:"externalUrl,omitempty"`
+
+	ExternalURLs []string `json:"externalUrls,omitempty"`
+
+	// current/desired replicas are only reported when autoscaling
+	Autoscaling     bool  `json:"autoscaling"`
+	CurrentReplicas int32 `json:"currentReplicas,omitempty"`
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+
+	Volumes []AppVolume `json:"volumes"`
+	Domains []string    `json:"domains,omitempty"`
+
+	ObservedGeneration int64       `json:"observedGeneration"`
+	Conditions         []Condition `json:"conditions"`
+}
+
+// AppVolume object
+type AppVolume struct {
+	Name          string `json:"name"`
+	Size          string `json:"size"`
+	ReclaimPolicy string `json:"reclaimPolicy"`
+}
+
+// GetApp response
+type GetApp struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Spec   AppSpec   `json:"spec"`
+	Status AppStatus `json:"status"`
+
+	// nil until the operator created the deployment
+	Rollout *AppRollout `json:"rollout,omitempty"`
+
+	Pods []AppPod `json:"pods"`
+	// most recent events of the app, deployment, replica sets and pods, oldest first
+	Events []AppEvent `json:"events"`
+}
+
+// AppSpec object, holds the same objects as the create and update requests
+type AppSpec struct {
+	Replicas int32 `json:"replicas"`
+
+	Containers []requests.Container `json:"containers"`
+	Volumes    []requests.Volume    `json:"volumes,omitempty"`
+
+	Autoscaling *requests.Autoscaling `json:"autoscaling,omitempty"`
+
+	TLS *requests.TLS `json:"tls,omitempty"`
+
+	Domains []string `json:"domains,omitempty"`
+}
+
+// AppStatus object
+type AppStatus struct {
+	ExternalURL  string   `json:"externalUrl,omitempty"`
+	ExternalURLs []string `json:"externalUrls,omitempty"`
+
+	AvailableReplicas   int32 `json:"availableReplicas"`
+	UnavailableReplicas int32 `json:"unavailableReplicas"`
+
+	// current/desired replicas are only reported when autoscaling
+	CurrentReplicas int32 `json:"currentReplicas,omitempty"`
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+
+	ObservedGeneration int64       `json:"observedGeneration"`
+	Conditions         []Condition `json:"conditions"`
+}
+
+// AppRollout object, the progress of the app deployment
+type AppRollout struct {
+	Revision string `json:"revision"`
+
+	Replicas            int32 `json:"replicas"`
+	UpdatedReplicas     int32 `json:"updatedReplicas"`
+	ReadyReplicas       int32 `json:"readyReplicas"`
+	AvailableReplicas   int32 `json:"availableReplicas"`
+	UnavailableReplicas int32 `json:"unavailableReplicas"`
+
+	// Available, RollingOut, ReplicasUnavailable or the reason the deployment stopped progressing
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// AppPod object
+type AppPod struct {
+	Name  string `json:"name"`
+	Phase string `json:"phase"`
+	// why containers are waiting or terminated, such as CrashLoopBackOff
+	Reason string `json:"reason,omitempty"`
+
+	ReadyContainers int32 `json:"readyContainers"`
+	Containers      int32 `json:"containers"`
+	Restarts        int32 `json:"restarts"`
+
+	Node      string    `json:"node,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AppEvent object
+type AppEvent struct {
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Object  string `json:"object"`
+	Message string `json:"message"`
+
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
 }
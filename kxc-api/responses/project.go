@@ -1,5 +1,7 @@
 package responses
 
+import "github.com/didil/kubexcloud/kxc-api/requests"
+
 // ListProject response
 type ListProject struct {
 	Projects []ListProjectEntry `json:"projects"`
@@ -7,8 +9,56 @@ type ListProject struct {
 
 type ListProjectEntry struct {
 	Name string `json:"name"`
+
+	// role of the current user
+	Role    string          `json:"role"`
+	Members []ProjectMember `json:"members"`
+
+	Quota *ProjectQuota `json:"quota,omitempty"`
+	Usage *ProjectUsage `json:"usage,omitempty"`
+
+	ObservedGeneration int64       `json:"observedGeneration"`
+	Conditions         []Condition `json:"conditions"`
 }
 
 type Project struct {
 	Name string `json:"name"`
+
+	// role of the current user
+	Role    string          `json:"role"`
+	Members []ProjectMember `json:"members"`
+
+	Quota *ProjectQuota `json:"quota,omitempty"`
+	Usage *ProjectUsage `json:"usage,omitempty"`
+
+	ObservedGeneration int64       `json:"observedGeneration"`
+	Conditions         []Condition `json:"conditions"`
+}
+
+// ProjectQuota object
+type ProjectQuota struct {
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Pods    int32  `json:"pods,omitempty"`
+	Storage string `json:"storage,omitempty"`
+	Apps    int32  `json:"apps,omitempty"`
+
+	// container requests and limits applied when an app doesn't set them
+	DefaultRequests *requests.ResourceList `json:"defaultRequests,omitempty"`
+	DefaultLimits   *requests.ResourceList `json:"defaultLimits,omitempty"`
+}
+
+// ProjectUsage object
+type ProjectUsage struct {
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Pods    int32  `json:"pods,omitempty"`
+	Storage string `json:"storage,omitempty"`
+	Apps    int32  `json:"apps,omitempty"`
+}
+
+// ProjectMember object
+type ProjectMember struct {
+	UserName string `json:"userName"`
+	Role     string `json:"role"`
 }
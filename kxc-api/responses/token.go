@@ -0,0 +1,25 @@
+package responses
+
+import "time"
+
+// CreateToken response, the token can't be retrieved later
+type CreateToken struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// ListToken response
+type ListToken struct {
+	Tokens []ListTokenEntry `json:"tokens"`
+}
+
+type ListTokenEntry struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	ReadOnly  bool       `json:"readOnly"`
+	Projects  []string   `json:"projects"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
@@ -1,8 +1,14 @@
 package responses
 
+import "time"
+
 // LoginUser response
 type LoginUser struct {
-	Token string `json:"token"`
+	// short lived access token
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// used to get a new access token once it expires
+	RefreshToken string `json:"refreshToken"`
 }
 
 // ListUser response
@@ -13,4 +19,15 @@ type ListUser struct {
 type ListUserEntry struct {
 	Name string `json:"name"`
 	Role string `json:"role"`
+	// set while password logins are locked out
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
+
+	ObservedGeneration int64       `json:"observedGeneration"`
+	Conditions         []Condition `json:"conditions"`
+}
+
+// OIDCConfig response
+type OIDCConfig struct {
+	Issuer   string `json:"issuer"`
+	ClientID string `json:"clientID"`
 }
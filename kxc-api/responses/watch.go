@@ -0,0 +1,16 @@
+package responses
+
+// WatchEvent response, streamed as a server-sent event named after its type
+type WatchEvent struct {
+	// ADDED, MODIFIED or DELETED
+	Type string `json:"type"`
+	// App or Project
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// project of the app, or the project name itself
+	Project string `json:"project"`
+
+	// state of the object, depending on Kind
+	App          *ListAppEntry     `json:"app,omitempty"`
+	ProjectState *ListProjectEntry `json:"projectState,omitempty"`
+}
@@ -15,7 +15,7 @@ func BuildRouter(root *handlers.Root) *chi.Mux {
 	mux.Use(mid.Cors)
 
 	mux.Use(middleware.RequestID)
-	mux.Use(middleware.RealIP)
+	mux.Use(mid.RealIP(mid.TrustedProxiesFromEnv()))
 	mux.Use(middleware.Logger)
 	mux.Use(middleware.Recoverer)
 	mux.Use(middleware.Heartbeat("/ping"))
@@ -29,6 +29,12 @@ func BuildRouter(root *handlers.Root) *chi.Mux {
 
 		// POST /v1/users/login
 		r.Post("/users/login", root.HandleLoginUser)
+		// GET /v1/users/login/oidc
+		r.Get("/users/login/oidc", root.HandleGetOIDCConfig)
+		// POST /v1/users/login/oidc
+		r.Post("/users/login/oidc", root.HandleLoginUserOIDC)
+		// POST /v1/users/refresh
+		r.Post("/users/refresh", root.HandleRefreshUser)
 
 		r.With(authentication).Route("/users", func(r chi.Router) {
 			// POST /v1/users
@@ -36,13 +42,49 @@ func BuildRouter(root *handlers.Root) *chi.Mux {
 
 			// GET /v1/users
 			r.With(adminOnly).Get("/", root.HandleListUsers)
+
+			// DELETE /v1/users/:user
+			r.With(adminOnly).Delete("/{user}", root.HandleDeleteUser)
+
+			// POST /v1/users/:user/unlock
+			r.With(adminOnly).Post("/{user}/unlock", root.HandleUnlockUser)
+
+			// POST /v1/users/me/logout
+			r.Post("/me/logout", root.HandleLogoutUser)
+
+			r.Route("/me/tokens", func(r chi.Router) {
+				// POST /v1/users/me/tokens
+				r.Post("/", root.HandleCreateToken)
+				// GET /v1/users/me/tokens
+				r.Get("/", root.HandleListTokens)
+				// DELETE /v1/users/me/tokens/:token
+				r.Delete("/{token}", root.HandleRevokeToken)
+			})
 		})
 
+		// GET /v1/watch (server-sent events)
+		r.With(authentication).Get("/watch", root.HandleWatch)
+
 		r.With(authentication).Route("/projects", func(r chi.Router) {
 			// Get /v1/projects
 			r.Get("/", root.HandleListProjects)
 			// POST /v1/projects
 			r.Post("/", root.HandleCreateProject)
+			// GET /v1/projects/:project
+			r.Get("/{project}", root.HandleGetProject)
+			// DELETE /v1/projects/:project
+			r.Delete("/{project}", root.HandleDeleteProject)
+			// PUT /v1/projects/:project/quota
+			r.With(adminOnly).Put("/{project}/quota", root.HandleSetProjectQuota)
+
+			r.Route("/{project}/members", func(r chi.Router) {
+				// POST /v1/projects/:project/members
+				r.Post("/", root.HandleAddProjectMember)
+				// PUT /v1/projects/:project/members/:member
+				r.Put("/{member}", root.HandleUpdateProjectMember)
+				// DELETE /v1/projects/:project/members/:member
+				r.Delete("/{member}", root.HandleRemoveProjectMember)
+			})
 
 			r.Route("/{project}/apps", func(r chi.Router) {
 				// POST /v1/projects/:project/apps/:app/restart
@@ -51,8 +93,22 @@ func BuildRouter(root *handlers.Root) *chi.Mux {
 				r.Post("/", root.HandleCreateApp)
 				// GET /v1/projects/:project/apps
 				r.Get("/", root.HandleListApps)
+				// GET /v1/projects/:project/apps/:app
+				r.Get("/{app}", root.HandleGetApp)
 				// PUT /v1/projects/:project/apps/:app
 				r.Put("/{app}", root.HandleUpdateApp)
+				// DELETE /v1/projects/:project/apps/:app
+				r.Delete("/{app}", root.HandleDeleteApp)
+				// GET /v1/projects/:project/apps/:app/logs
+				r.Get("/{app}/logs", root.HandleAppLogs)
+				// GET /v1/projects/:project/apps/:app/exec (websocket)
+				r.Get("/{app}/exec", root.HandleAppExec)
+				// GET /v1/projects/:project/apps/:app/port-forward (websocket)
+				r.Get("/{app}/port-forward", root.HandleAppPortForward)
+				// POST /v1/projects/:project/apps/:app/domains
+				r.Post("/{app}/domains", root.HandleAttachAppDomain)
+				// DELETE /v1/projects/:project/apps/:app/domains/:domain
+				r.Delete("/{app}/domains/{domain}", root.HandleDetachAppDomain)
 			})
 		})
 	})
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -36,11 +37,30 @@ func StartServer() error {
 	projectSvc := services.NewProjectService(k8sSvc)
 	appSvc := services.NewAppService(k8sSvc)
 	userSvc := services.NewUserService(k8sSvc)
+	tokenSvc := services.NewTokenService(k8sSvc)
+	watchSvc := services.NewWatchService(k8sSvc)
 
 	root := &handlers.Root{
 		ProjectSvc: projectSvc,
 		AppSvc:     appSvc,
 		UserSvc:    userSvc,
+		TokenSvc:   tokenSvc,
+		WatchSvc:   watchSvc,
+	}
+
+	// the k8s cache runs as long as the server
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = watchSvc.Start(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Syncing k8s cache ...\n")
+	cacheErrs, err := k8sSvc.StartCache(ctx)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("Initializing router ...\n")
@@ -49,5 +69,17 @@ func StartServer() error {
 
 	fmt.Printf("Listening on port %s\n", port)
 
-	return http.ListenAndServe(fmt.Sprintf(":%s", port), mux)
+	server := &http.Server{Addr: fmt.Sprintf(":%s", port), Handler: mux}
+	serverErrs := make(chan error, 1)
+	go func() {
+		serverErrs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErrs:
+		return err
+	case err := <-cacheErrs:
+		server.Close()
+		return fmt.Errorf("k8s cache: %v", err)
+	}
 }
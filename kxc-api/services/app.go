@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
 	"github.com/didil/kubexcloud/kxc-operator/controllers"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	validationutils "k8s.io/apimachinery/pkg/util/validation"
@@ -23,7 +25,14 @@ type AppSvc interface {
 	Create(ctx context.Context, projectName string, reqData *requests.CreateApp) error
 	Update(ctx context.Context, projectName, appName string, reqData *requests.UpdateApp) error
 	List(ctx context.Context, projectName string) (*responses.ListApp, error)
+	Get(ctx context.Context, projectName, appName string) (*responses.GetApp, error)
 	Restart(ctx context.Context, projectName, appName string) error
+	Delete(ctx context.Context, projectName, appName string) error
+	AttachDomain(ctx context.Context, projectName, appName string, reqData *requests.AttachDomain) error
+	DetachDomain(ctx context.Context, projectName, appName, domain string) error
+	Logs(ctx context.Context, projectName, appName string, reqData *requests.AppLogs, w io.Writer) error
+	Exec(ctx context.Context, projectName, appName string, reqData *requests.AppExec) (ExecSession, error)
+	PortForward(ctx context.Context, projectName, appName string, reqData *requests.AppPortForward) (PortForwardSession, error)
 }
 
 type AppService struct {
@@ -36,7 +45,7 @@ func NewAppService(k8sSvc K8sSvc) *AppService {
 	}
 }
 
-func (svc *AppService) validateCreateApp(reqData *requests.CreateApp) error {
+func (svc *AppService) validateCreateApp(projectName string, reqData *requests.CreateApp) error {
 	if reqData.Name == "" {
 		return fmt.Errorf("name is required")
 	}
@@ -45,13 +54,388 @@ func (svc *AppService) validateCreateApp(reqData *requests.CreateApp) error {
 		return fmt.Errorf("name: %s", strings.Join(errs, "."))
 	}
 
+	// the default host label is built from the app and project names
+	if errs := validationutils.IsDNS1123Label(controllers.AppHostLabel(projectName, reqData.Name)); len(errs) > 0 {
+		return fmt.Errorf("name: too long for project %s: %s", projectName, strings.Join(errs, "."))
+	}
+
+	err := svc.validateVolumes(reqData.Volumes)
+	if err != nil {
+		return err
+	}
+
+	err = svc.validateAutoscaling(reqData.Autoscaling, reqData.Containers)
+	if err != nil {
+		return err
+	}
+
+	err = svc.validateTLS(reqData.TLS)
+	if err != nil {
+		return err
+	}
+
+	err = svc.validateExposedPorts(controllers.AppHostLabel(projectName, reqData.Name), reqData.Containers)
+	if err != nil {
+		return err
+	}
+
+	return svc.validateContainers(reqData.Containers, reqData.Volumes)
+}
+
+func (svc *AppService) validateUpdateApp(reqData *requests.UpdateApp, app *cloudv1alpha1.App) error {
+	err := svc.validateVolumes(reqData.Volumes)
+	if err != nil {
+		return err
+	}
+
+	err = svc.validateAutoscaling(reqData.Autoscaling, reqData.Containers)
+	if err != nil {
+		return err
+	}
+
+	err = svc.validateTLS(reqData.TLS)
+	if err != nil {
+		return err
+	}
+
+	err = svc.validateExposedPorts(controllers.AppHostLabel(controllers.AppProjectName(app), app.Name), reqData.Containers)
+	if err != nil {
+		return err
+	}
+
+	// volume claims can be expanded but not shrunk, their access mode and storage class are immutable
+	for _, v := range reqData.Volumes {
+		for _, existingV := range app.Spec.Volumes {
+			if existingV.Name != v.Name {
+				continue
+			}
+
+			if volumeAccessMode(v.AccessMode) != volumeAccessMode(string(existingV.AccessMode)) {
+				return fmt.Errorf("volume %s: access mode can't be changed from %s to %s", v.Name, volumeAccessMode(string(existingV.AccessMode)), volumeAccessMode(v.AccessMode))
+			}
+			if v.StorageClassName != existingV.StorageClassName {
+				return fmt.Errorf("volume %s: storage class can't be changed from %q to %q", v.Name, existingV.StorageClassName, v.StorageClassName)
+			}
+
+			existingSize, err := resource.ParseQuantity(existingV.Size)
+			if err != nil {
+				// current size is invalid, let the new one replace it
+				continue
+			}
+
+			size := resource.MustParse(v.Size)
+			if size.Cmp(existingSize) < 0 {
+				return fmt.Errorf("volume %s: size can't be decreased from %s to %s", v.Name, existingV.Size, v.Size)
+			}
+		}
+	}
+
+	return svc.validateContainers(reqData.Containers, reqData.Volumes)
+}
+
+func (svc *AppService) validateAutoscaling(autoscaling *requests.Autoscaling, containers []requests.Container) error {
+	if autoscaling == nil {
+		return nil
+	}
+
+	if autoscaling.MinReplicas < 1 {
+		return fmt.Errorf("autoscaling: minReplicas must be at least 1")
+	}
+	if autoscaling.MaxReplicas < autoscaling.MinReplicas {
+		return fmt.Errorf("autoscaling: maxReplicas must be greater or equal to minReplicas")
+	}
+	if autoscaling.TargetCPUUtilizationPercentage < 1 {
+		return fmt.Errorf("autoscaling: targetCPUUtilizationPercentage must be at least 1")
+	}
+
+	// cpu utilization is computed relative to the containers cpu requests
+	for _, c := range containers {
+		if c.Resources == nil || c.Resources.Requests == nil || c.Resources.Requests.CPU == "" {
+			return fmt.Errorf("autoscaling: container %s: cpu request is required", c.Name)
+		}
+	}
+
+	return nil
+}
+
+func (svc *AppService) validateTLS(tls *requests.TLS) error {
+	if tls == nil {
+		return nil
+	}
+
+	if (tls.Issuer == "") == (tls.ClusterIssuer == "") {
+		return fmt.Errorf("tls: exactly one of issuer or clusterIssuer must be set")
+	}
+
+	return nil
+}
+
+func (svc *AppService) validateVolumes(volumes []requests.Volume) error {
+	names := map[string]bool{}
+
+	for _, v := range volumes {
+		if errs := validationutils.IsDNS1123Label(v.Name); len(errs) > 0 {
+			return fmt.Errorf("volume name %q: %s", v.Name, strings.Join(errs, "."))
+		}
+		if names[v.Name] {
+			return fmt.Errorf("volume %s: duplicate name", v.Name)
+		}
+		names[v.Name] = true
+
+		size, err := resource.ParseQuantity(v.Size)
+		if err != nil {
+			return fmt.Errorf("volume %s size %q: %v", v.Name, v.Size, err)
+		}
+		if size.Sign() <= 0 {
+			return fmt.Errorf("volume %s size %q: must be positive", v.Name, v.Size)
+		}
+
+		switch corev1.PersistentVolumeAccessMode(v.AccessMode) {
+		case "", corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany:
+		default:
+			return fmt.Errorf("volume %s: unknown access mode: %s", v.Name, v.AccessMode)
+		}
+
+		switch cloudv1alpha1.VolumeReclaimPolicy(v.ReclaimPolicy) {
+		case "", cloudv1alpha1.VolumeReclaimDelete, cloudv1alpha1.VolumeReclaimRetain:
+		default:
+			return fmt.Errorf("volume %s: unknown reclaim policy: %s", v.Name, v.ReclaimPolicy)
+		}
+	}
+
+	return nil
+}
+
+// volumeAccessMode returns the access mode of a volume claim, ReadWriteOnce by default
+func volumeAccessMode(accessMode string) corev1.PersistentVolumeAccessMode {
+	if accessMode == "" {
+		return corev1.ReadWriteOnce
+	}
+
+	return corev1.PersistentVolumeAccessMode(accessMode)
+}
+
+// validateExposedPorts checks the routing config of externally exposed ports
+func (svc *AppService) validateExposedPorts(hostLabel string, containers []requests.Container) error {
+	routes := map[string]bool{}
+
+	for _, c := range containers {
+		for _, p := range c.Ports {
+			if !p.ExposeExternally {
+				if p.Path != "" || p.HostSuffix != "" {
+					return fmt.Errorf("container %s: port %d: path and host suffix require exposeExternally", c.Name, p.Number)
+				}
+				continue
+			}
+
+			if p.Path != "" && !strings.HasPrefix(p.Path, "/") {
+				return fmt.Errorf("container %s: port %d: path must start with /", c.Name, p.Number)
+			}
+
+			if p.HostSuffix != "" {
+				if errs := validationutils.IsDNS1123Label(hostLabel + "-" + p.HostSuffix); len(errs) > 0 {
+					return fmt.Errorf("container %s: port %d: host suffix: %s", c.Name, p.Number, strings.Join(errs, "."))
+				}
+			}
+
+			path := p.Path
+			if path == "" {
+				path = "/"
+			}
+
+			route := p.HostSuffix + path
+			if routes[route] {
+				return fmt.Errorf("container %s: port %d: path %s is already routed to another port", c.Name, p.Number, path)
+			}
+			routes[route] = true
+		}
+	}
+
+	return nil
+}
+
+func (svc *AppService) validateContainers(containers []requests.Container, volumes []requests.Volume) error {
+	for _, c := range containers {
+		for _, m := range c.VolumeMounts {
+			err := svc.validateVolumeMount(m, volumes)
+			if err != nil {
+				return fmt.Errorf("container %s: %v", c.Name, err)
+			}
+		}
+
+		for _, e := range c.Env {
+			err := svc.validateEnvVar(e)
+			if err != nil {
+				return fmt.Errorf("container %s: %v", c.Name, err)
+			}
+		}
+
+		if c.Resources != nil {
+			err := svc.validateResources(c.Resources)
+			if err != nil {
+				return fmt.Errorf("container %s: %v", c.Name, err)
+			}
+		}
+
+		probes := map[string]*requests.Probe{"livenessProbe": c.LivenessProbe, "readinessProbe": c.ReadinessProbe, "startupProbe": c.StartupProbe}
+		for probeName, probe := range probes {
+			if probe == nil {
+				continue
+			}
+
+			err := svc.validateProbe(probe)
+			if err != nil {
+				return fmt.Errorf("container %s: %s: %v", c.Name, probeName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (svc *AppService) validateProbe(probe *requests.Probe) error {
+	handlers := 0
+
+	if probe.HTTPGet != nil {
+		handlers++
+
+		if errs := validationutils.IsValidPortNum(int(probe.HTTPGet.Port)); len(errs) > 0 {
+			return fmt.Errorf("httpGet port: %s", strings.Join(errs, "."))
+		}
+		if probe.HTTPGet.Path != "" && !strings.HasPrefix(probe.HTTPGet.Path, "/") {
+			return fmt.Errorf("httpGet path must start with /")
+		}
+	}
+
+	if probe.TCPSocket != nil {
+		handlers++
+
+		if errs := validationutils.IsValidPortNum(int(probe.TCPSocket.Port)); len(errs) > 0 {
+			return fmt.Errorf("tcpSocket port: %s", strings.Join(errs, "."))
+		}
+	}
+
+	if probe.Exec != nil {
+		handlers++
+
+		if len(probe.Exec.Command) == 0 {
+			return fmt.Errorf("exec command is required")
+		}
+	}
+
+	if handlers != 1 {
+		return fmt.Errorf("exactly one of httpGet, tcpSocket or exec must be set")
+	}
+
+	if probe.InitialDelaySeconds < 0 || probe.PeriodSeconds < 0 || probe.TimeoutSeconds < 0 || probe.FailureThreshold < 0 {
+		return fmt.Errorf("timings and thresholds must not be negative")
+	}
+
+	return nil
+}
+
+func (svc *AppService) validateResources(res *requests.Resources) error {
+	var requestsList, limitsList corev1.ResourceList
+	var err error
+
+	if res.Requests != nil {
+		requestsList, err = parseResourceList(res.Requests)
+		if err != nil {
+			return fmt.Errorf("resources requests: %v", err)
+		}
+	}
+	if res.Limits != nil {
+		limitsList, err = parseResourceList(res.Limits)
+		if err != nil {
+			return fmt.Errorf("resources limits: %v", err)
+		}
+	}
+
+	for name, req := range requestsList {
+		limit, ok := limitsList[name]
+		if ok && req.Cmp(limit) > 0 {
+			return fmt.Errorf("resources: %s request %s exceeds limit %s", name, req.String(), limit.String())
+		}
+	}
+
+	return nil
+}
+
+func parseResourceList(l *requests.ResourceList) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+
+	for name, value := range map[corev1.ResourceName]string{corev1.ResourceCPU: l.CPU, corev1.ResourceMemory: l.Memory} {
+		if value == "" {
+			continue
+		}
+
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %v", name, value, err)
+		}
+		if q.Sign() <= 0 {
+			return nil, fmt.Errorf("%s %q: must be positive", name, value)
+		}
+
+		list[name] = q
+	}
+
+	return list, nil
+}
+
+func (svc *AppService) validateVolumeMount(m requests.VolumeMount, volumes []requests.Volume) error {
+	if !strings.HasPrefix(m.MountPath, "/") {
+		return fmt.Errorf("volume mount %s: mount path must be absolute", m.Name)
+	}
+
+	for _, v := range volumes {
+		if v.Name == m.Name {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("volume mount %s: volume not found", m.Name)
+}
+
+func (svc *AppService) validateEnvVar(e requests.EnvVar) error {
+	if errs := validationutils.IsEnvVarName(e.Name); len(errs) > 0 {
+		return fmt.Errorf("env var name %q: %s", e.Name, strings.Join(errs, "."))
+	}
+
+	sources := 0
+	if e.Value != "" {
+		sources++
+	}
+	for _, ref := range []*requests.KeyRef{e.SecretKeyRef, e.ConfigMapKeyRef} {
+		if ref == nil {
+			continue
+		}
+		sources++
+
+		if errs := validationutils.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+			return fmt.Errorf("env var %s ref name: %s", e.Name, strings.Join(errs, "."))
+		}
+		if errs := validationutils.IsConfigMapKey(ref.Key); len(errs) > 0 {
+			return fmt.Errorf("env var %s ref key: %s", e.Name, strings.Join(errs, "."))
+		}
+	}
+
+	if sources > 1 {
+		return fmt.Errorf("env var %s: only one of value, secretKeyRef or configMapKeyRef can be set", e.Name)
+	}
+
 	return nil
 }
 
 func (svc *AppService) Create(ctx context.Context, projectName string, reqData *requests.CreateApp) error {
 	client := svc.k8sSvc.Client()
 
-	err := svc.validateCreateApp(reqData)
+	err := svc.validateCreateApp(projectName, reqData)
+	if err != nil {
+		return fmt.Errorf("app invalid: %v", err)
+	}
+
+	err = svc.validateHostLabels(ctx, projectName, reqData.Name, reqData.Containers)
 	if err != nil {
 		return fmt.Errorf("app invalid: %v", err)
 	}
@@ -64,27 +448,12 @@ func (svc *AppService) Create(ctx context.Context, projectName string, reqData *
 		},
 		Spec: cloudv1alpha1.AppSpec{
 			Replicas:   reqData.Replicas,
-			Containers: []cloudv1alpha1.Container{},
-		},
-	}
-
-	for _, c := range reqData.Containers {
-		container := cloudv1alpha1.Container{
-			Image:   c.Image,
-			Name:    c.Name,
-			Command: c.Command,
-			Ports:   []cloudv1alpha1.Port{},
-		}
+			Containers: buildContainers(reqData.Containers),
+			Volumes:    buildVolumes(reqData.Volumes),
 
-		for _, p := range c.Ports {
-			container.Ports = append(container.Ports, cloudv1alpha1.Port{
-				Number:           p.Number,
-				Protocol:         corev1.Protocol(p.Protocol),
-				ExposeExternally: p.ExposeExternally,
-			})
-		}
-
-		app.Spec.Containers = append(app.Spec.Containers, container)
+			Autoscaling: buildAutoscaling(reqData.Autoscaling),
+			TLS:         buildTLS(reqData.TLS),
+		},
 	}
 
 	err = client.Create(ctx, app)
@@ -103,10 +472,34 @@ func (svc *AppService) Update(ctx context.Context, projectName, appName string,
 		return fmt.Errorf("get app: %v", err)
 	}
 
+	err = svc.validateUpdateApp(reqData, app)
+	if err != nil {
+		return fmt.Errorf("app invalid: %v", err)
+	}
+
+	err = svc.validateHostLabels(ctx, projectName, appName, reqData.Containers)
+	if err != nil {
+		return fmt.Errorf("app invalid: %v", err)
+	}
+
 	app.Spec.Replicas = reqData.Replicas
-	app.Spec.Containers = []cloudv1alpha1.Container{}
+	app.Spec.Containers = buildContainers(reqData.Containers)
+	app.Spec.Volumes = buildVolumes(reqData.Volumes)
+	app.Spec.Autoscaling = buildAutoscaling(reqData.Autoscaling)
+	app.Spec.TLS = buildTLS(reqData.TLS)
+
+	err = client.Update(ctx, app)
+	if err != nil {
+		return fmt.Errorf("update app: %v", err)
+	}
+	return nil
+}
+
+// buildContainers converts request containers to app spec containers
+func buildContainers(reqContainers []requests.Container) []cloudv1alpha1.Container {
+	containers := []cloudv1alpha1.Container{}
 
-	for _, c := range reqData.Containers {
+	for _, c := range reqContainers {
 		container := cloudv1alpha1.Container{
 			Image:   c.Image,
 			Name:    c.Name,
@@ -119,15 +512,272 @@ func (svc *AppService) Update(ctx context.Context, projectName, appName string,
 				Number:           p.Number,
 				Protocol:         corev1.Protocol(p.Protocol),
 				ExposeExternally: p.ExposeExternally,
+				Path:             p.Path,
+				HostSuffix:       p.HostSuffix,
 			})
 		}
 
-		app.Spec.Containers = append(app.Spec.Containers, container)
+		for _, e := range c.Env {
+			envVar := cloudv1alpha1.EnvVar{
+				Name:  e.Name,
+				Value: e.Value,
+			}
+			if e.SecretKeyRef != nil {
+				envVar.SecretKeyRef = &cloudv1alpha1.KeyRef{Name: e.SecretKeyRef.Name, Key: e.SecretKeyRef.Key}
+			}
+			if e.ConfigMapKeyRef != nil {
+				envVar.ConfigMapKeyRef = &cloudv1alpha1.KeyRef{Name: e.ConfigMapKeyRef.Name, Key: e.ConfigMapKeyRef.Key}
+			}
+
+			container.Env = append(container.Env, envVar)
+		}
+
+		if c.Resources != nil {
+			container.Resources = &cloudv1alpha1.Resources{}
+			if c.Resources.Requests != nil {
+				container.Resources.Requests = &cloudv1alpha1.ResourceList{CPU: c.Resources.Requests.CPU, Memory: c.Resources.Requests.Memory}
+			}
+			if c.Resources.Limits != nil {
+				container.Resources.Limits = &cloudv1alpha1.ResourceList{CPU: c.Resources.Limits.CPU, Memory: c.Resources.Limits.Memory}
+			}
+		}
+
+		container.LivenessProbe = buildProbe(c.LivenessProbe)
+		container.ReadinessProbe = buildProbe(c.ReadinessProbe)
+		container.StartupProbe = buildProbe(c.StartupProbe)
+
+		for _, m := range c.VolumeMounts {
+			container.VolumeMounts = append(container.VolumeMounts, cloudv1alpha1.VolumeMount{
+				Name:      m.Name,
+				MountPath: m.MountPath,
+				ReadOnly:  m.ReadOnly,
+			})
+		}
+
+		containers = append(containers, container)
+	}
+
+	return containers
+}
+
+// buildVolumes converts request volumes to app spec volumes
+func buildVolumes(reqVolumes []requests.Volume) []cloudv1alpha1.Volume {
+	var volumes []cloudv1alpha1.Volume
+
+	for _, v := range reqVolumes {
+		volumes = append(volumes, cloudv1alpha1.Volume{
+			Name:             v.Name,
+			Size:             v.Size,
+			AccessMode:       corev1.PersistentVolumeAccessMode(v.AccessMode),
+			StorageClassName: v.StorageClassName,
+			ReclaimPolicy:    cloudv1alpha1.VolumeReclaimPolicy(v.ReclaimPolicy),
+		})
+	}
+
+	return volumes
+}
+
+// buildAutoscaling converts request autoscaling to app spec autoscaling
+func buildAutoscaling(reqAutoscaling *requests.Autoscaling) *cloudv1alpha1.Autoscaling {
+	if reqAutoscaling == nil {
+		return nil
+	}
+
+	return &cloudv1alpha1.Autoscaling{
+		MinReplicas:                    reqAutoscaling.MinReplicas,
+		MaxReplicas:                    reqAutoscaling.MaxReplicas,
+		TargetCPUUtilizationPercentage: reqAutoscaling.TargetCPUUtilizationPercentage,
+	}
+}
+
+// buildTLS converts request tls to app spec tls
+func buildTLS(reqTLS *requests.TLS) *cloudv1alpha1.TLS {
+	if reqTLS == nil {
+		return nil
+	}
+
+	return &cloudv1alpha1.TLS{
+		Issuer:        reqTLS.Issuer,
+		ClusterIssuer: reqTLS.ClusterIssuer,
+	}
+}
+
+// buildProbe converts a request probe to an app spec probe
+func buildProbe(reqProbe *requests.Probe) *cloudv1alpha1.Probe {
+	if reqProbe == nil {
+		return nil
+	}
+
+	probe := &cloudv1alpha1.Probe{
+		InitialDelaySeconds: reqProbe.InitialDelaySeconds,
+		PeriodSeconds:       reqProbe.PeriodSeconds,
+		TimeoutSeconds:      reqProbe.TimeoutSeconds,
+		FailureThreshold:    reqProbe.FailureThreshold,
+	}
+
+	if reqProbe.HTTPGet != nil {
+		probe.HTTPGet = &cloudv1alpha1.HTTPGetProbe{Path: reqProbe.HTTPGet.Path, Port: reqProbe.HTTPGet.Port}
+	}
+	if reqProbe.TCPSocket != nil {
+		probe.TCPSocket = &cloudv1alpha1.TCPSocketProbe{Port: reqProbe.TCPSocket.Port}
+	}
+	if reqProbe.Exec != nil {
+		probe.Exec = &cloudv1alpha1.ExecProbe{Command: reqProbe.Exec.Command}
+	}
+
+	return probe
+}
+
+func (svc *AppService) validateDomain(domain string) error {
+	if domain == "" {
+		return fmt.Errorf("domain is required")
+	}
+
+	if errs := validationutils.IsDNS1123Subdomain(domain); len(errs) > 0 {
+		return fmt.Errorf("domain: %s", strings.Join(errs, "."))
+	}
+
+	if !strings.Contains(domain, ".") {
+		return fmt.Errorf("domain: must be fully qualified")
+	}
+
+	// hosts under the root domain are reserved for default app hosts
+	rootDomain := controllers.AppRootDomain()
+	if domain == rootDomain || strings.HasSuffix(domain, "."+rootDomain) {
+		return fmt.Errorf("domain: hosts under %s are reserved", rootDomain)
+	}
+
+	return nil
+}
+
+// domainInUse checks if any app in the cluster already uses the domain,
+// like validateHostLabels it doesn't guard against concurrent requests for the same domain
+func (svc *AppService) domainInUse(ctx context.Context, domain string) (bool, error) {
+	cl := svc.k8sSvc.Client()
+
+	appList := &cloudv1alpha1.AppList{}
+	if err := cl.List(ctx, appList); err != nil {
+		return false, fmt.Errorf("failed to list apps: %v", err)
 	}
 
+	for _, app := range appList.Items {
+		for _, d := range app.Spec.Domains {
+			if d == domain {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// appHostLabels returns the labels of the hosts generated for the app under the root domain,
+// the default one followed by one per host suffix
+func appHostLabels(projectName, appName string, containers []cloudv1alpha1.Container) []string {
+	labels := []string{controllers.AppHostLabel(projectName, appName)}
+
+	for _, c := range containers {
+		for _, p := range c.Ports {
+			if p.ExposeExternally && p.HostSuffix != "" {
+				labels = append(labels, controllers.AppSuffixedHostLabel(projectName, appName, p.HostSuffix))
+			}
+		}
+	}
+
+	return labels
+}
+
+// validateHostLabels checks that no other app in the cluster already uses the hosts generated for the app:
+// app names, project names and host suffixes can all contain dashes, so different apps can end up with the same host label.
+// The apps are listed before the app is saved, so concurrent requests for colliding hosts can both pass the check
+func (svc *AppService) validateHostLabels(ctx context.Context, projectName, appName string, reqContainers []requests.Container) error {
+	cl := svc.k8sSvc.Client()
+
+	appList := &cloudv1alpha1.AppList{}
+	if err := cl.List(ctx, appList); err != nil {
+		return fmt.Errorf("failed to list apps: %v", err)
+	}
+
+	inUse := map[string]bool{}
+	for _, app := range appList.Items {
+		appProjectName := controllers.AppProjectName(&app)
+		if appProjectName == projectName && app.Name == appName {
+			continue
+		}
+
+		for _, label := range appHostLabels(appProjectName, app.Name, app.Spec.Containers) {
+			inUse[label] = true
+		}
+	}
+
+	for _, label := range appHostLabels(projectName, appName, buildContainers(reqContainers)) {
+		if inUse[label] {
+			return fmt.Errorf("host %s.%s is already in use", label, controllers.AppRootDomain())
+		}
+	}
+
+	return nil
+}
+
+func (svc *AppService) AttachDomain(ctx context.Context, projectName, appName string, reqData *requests.AttachDomain) error {
+	client := svc.k8sSvc.Client()
+
+	domain := strings.ToLower(reqData.Domain)
+
+	err := svc.validateDomain(domain)
+	if err != nil {
+		return fmt.Errorf("domain invalid: %v", err)
+	}
+
+	app := &cloudv1alpha1.App{}
+	err = client.Get(ctx, types.NamespacedName{Name: appName, Namespace: controllers.ProjectNamespaceName(projectName)}, app)
+	if err != nil {
+		return fmt.Errorf("get app: %v", err)
+	}
+
+	inUse, err := svc.domainInUse(ctx, domain)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return fmt.Errorf("domain already in use: %s", domain)
+	}
+
+	app.Spec.Domains = append(app.Spec.Domains, domain)
+
 	err = client.Update(ctx, app)
 	if err != nil {
-		return fmt.Errorf("update app: %v", err)
+		return fmt.Errorf("attach domain: %v", err)
+	}
+	return nil
+}
+
+func (svc *AppService) DetachDomain(ctx context.Context, projectName, appName, domain string) error {
+	client := svc.k8sSvc.Client()
+
+	app := &cloudv1alpha1.App{}
+	err := client.Get(ctx, types.NamespacedName{Name: appName, Namespace: controllers.ProjectNamespaceName(projectName)}, app)
+	if err != nil {
+		return fmt.Errorf("get app: %v", err)
+	}
+
+	domain = strings.ToLower(domain)
+
+	domains := []string{}
+	for _, d := range app.Spec.Domains {
+		if d != domain {
+			domains = append(domains, d)
+		}
+	}
+	if len(domains) == len(app.Spec.Domains) {
+		return fmt.Errorf("domain not found: %s", domain)
+	}
+
+	app.Spec.Domains = domains
+
+	err = client.Update(ctx, app)
+	if err != nil {
+		return fmt.Errorf("detach domain: %v", err)
 	}
 	return nil
 }
@@ -147,18 +797,49 @@ func (svc *AppService) List(ctx context.Context, projectName string) (*responses
 		Apps: []responses.ListAppEntry{},
 	}
 
-	for _, app := range appList.Items {
-		respData.Apps = append(respData.Apps, responses.ListAppEntry{
-			Name:                app.Name,
-			ExternalURL:         app.Status.ExternalURL,
-			AvailableReplicas:   app.Status.AvailableReplicas,
-			UnavailableReplicas: app.Status.UnavailableReplicas,
-		})
+	for i := range appList.Items {
+		respData.Apps = append(respData.Apps, listAppEntry(&appList.Items[i]))
 	}
 
 	return respData, nil
 }
 
+func listAppEntry(app *cloudv1alpha1.App) responses.ListAppEntry {
+	return responses.ListAppEntry{
+		Name:                app.Name,
+		ExternalURL:         app.Status.ExternalURL,
+		ExternalURLs:        app.Status.ExternalURLs,
+		AvailableReplicas:   app.Status.AvailableReplicas,
+		UnavailableReplicas: app.Status.UnavailableReplicas,
+		Autoscaling:         app.Spec.Autoscaling != nil,
+		CurrentReplicas:     app.Status.CurrentReplicas,
+		DesiredReplicas:     app.Status.DesiredReplicas,
+		Volumes:             listAppVolumes(app.Spec.Volumes),
+		Domains:             app.Spec.Domains,
+		ObservedGeneration:  app.Status.ObservedGeneration,
+		Conditions:          listConditions(app.Status.Conditions),
+	}
+}
+
+func listAppVolumes(volumes []cloudv1alpha1.Volume) []responses.AppVolume {
+	appVolumes := []responses.AppVolume{}
+
+	for _, v := range volumes {
+		reclaimPolicy := v.ReclaimPolicy
+		if reclaimPolicy == "" {
+			reclaimPolicy = cloudv1alpha1.VolumeReclaimDelete
+		}
+
+		appVolumes = append(appVolumes, responses.AppVolume{
+			Name:          v.Name,
+			Size:          v.Size,
+			ReclaimPolicy: string(reclaimPolicy),
+		})
+	}
+
+	return appVolumes
+}
+
 func (svc *AppService) Restart(ctx context.Context, projectName, appName string) error {
 	client := svc.k8sSvc.Client()
 
@@ -180,3 +861,20 @@ func (svc *AppService) Restart(ctx context.Context, projectName, appName string)
 	}
 	return nil
 }
+
+func (svc *AppService) Delete(ctx context.Context, projectName, appName string) error {
+	client := svc.k8sSvc.Client()
+
+	app := &cloudv1alpha1.App{}
+	err := client.Get(ctx, types.NamespacedName{Name: appName, Namespace: controllers.ProjectNamespaceName(projectName)}, app)
+	if err != nil {
+		return fmt.Errorf("get app: %v", err)
+	}
+
+	// owned resources are garbage collected by kubernetes
+	err = client.Delete(ctx, app)
+	if err != nil {
+		return fmt.Errorf("delete app: %v", err)
+	}
+	return nil
+}
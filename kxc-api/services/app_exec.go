@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/websocket"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/didil/kubexcloud/kxc-operator/controllers"
+)
+
+// ExecProtocol is the websocket subprotocol of exec sessions. Each message starts with the byte
+// of its stream, see the ExecStream constants, resize messages hold a json {"Width": w, "Height": h}
+const ExecProtocol = "v4.channel.k8s.io"
+
+// exec session streams
+const (
+	ExecStreamStdin  byte = 0
+	ExecStreamStdout byte = 1
+	ExecStreamStderr byte = 2
+	// the last message of a session, a json metav1.Status with the exit code
+	ExecStreamError  byte = 3
+	ExecStreamResize byte = 4
+)
+
+// ExecSession is a command running in an app container
+type ExecSession interface {
+	Receive() ([]byte, error)
+	Send(msg []byte) error
+	Close() error
+}
+
+// Exec starts a command in a replica of the app. The session is opened with the exec websocket
+// of the kubernetes api rather than remotecommand.NewSPDYExecutor: its v4.channel.k8s.io messages are the ones
+// of ExecProtocol, so they're relayed to the client as is, where the SPDY executor would need a pipe per stream
+// and a resize queue decoding the client messages
+func (svc *AppService) Exec(ctx context.Context, projectName, appName string, reqData *requests.AppExec) (ExecSession, error) {
+	cl := svc.k8sSvc.Client()
+	namespace := controllers.ProjectNamespaceName(projectName)
+
+	if len(reqData.Command) == 0 {
+		return nil, fmt.Errorf("command required")
+	}
+
+	app := &cloudv1alpha1.App{}
+	err := cl.Get(ctx, types.NamespacedName{Name: appName, Namespace: namespace}, app)
+	if err != nil {
+		return nil, fmt.Errorf("get app: %v", err)
+	}
+
+	containerName, err := appContainer(app, reqData.Container)
+	if err != nil {
+		return nil, err
+	}
+
+	podName, err := svc.runningPod(ctx, projectName, appName, reqData.Pod)
+	if err != nil {
+		return nil, err
+	}
+
+	execURL := svc.k8sSvc.Clientset().CoreV1().RESTClient().Post().
+		Namespace(namespace).
+		Resource("pods").
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: containerName,
+			Command:   reqData.Command,
+			Stdin:     reqData.Stdin,
+			Stdout:    true,
+			// stderr is merged into stdout by the container runtime with a tty
+			Stderr: !reqData.TTY,
+			TTY:    reqData.TTY,
+		}, scheme.ParameterCodec).
+		URL()
+
+	conn, err := dialK8sWebsocket(svc.k8sSvc.RestConfig(), execURL.String(), ExecProtocol)
+	if err != nil {
+		return nil, fmt.Errorf("exec in pod %s: %v", podName, err)
+	}
+
+	return &websocketSession{conn: conn}, nil
+}
+
+// runningPod returns podName if it's a running replica of the app, or the first running replica if empty
+func (svc *AppService) runningPod(ctx context.Context, projectName, appName, podName string) (string, error) {
+	cl := svc.k8sSvc.Client()
+
+	podList := &corev1.PodList{}
+	listOpts := []client.ListOption{
+		client.InNamespace(controllers.ProjectNamespaceName(projectName)),
+		client.MatchingLabels(controllers.LabelsForApp(projectName, appName)),
+	}
+	if err := cl.List(ctx, podList, listOpts...); err != nil {
+		return "", fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	running := []string{}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			running = append(running, pod.Name)
+		}
+	}
+	sort.Strings(running)
+
+	if podName == "" {
+		if len(running) == 0 {
+			return "", fmt.Errorf("no running pods found for app %s", appName)
+		}
+		return running[0], nil
+	}
+
+	for _, name := range running {
+		if name == podName {
+			return podName, nil
+		}
+	}
+
+	return "", fmt.Errorf("pod %s isn't a running replica of app %s, running: %s", podName, appName, strings.Join(running, ", "))
+}
+
+// dialK8sWebsocket opens a websocket to the kubernetes api with the credentials of config
+func dialK8sWebsocket(config *rest.Config, rawURL, protocol string) (*websocket.Conn, error) {
+	wsURL := strings.Replace(strings.Replace(rawURL, "https://", "wss://", 1), "http://", "ws://", 1)
+
+	wsConfig, err := websocket.NewConfig(wsURL, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("websocket config: %v", err)
+	}
+	wsConfig.Protocol = []string{protocol}
+
+	wsConfig.TlsConfig, err = rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("tls config: %v", err)
+	}
+
+	wsConfig.Header, err = k8sAuthHeaders(config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := websocket.DialConfig(wsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("websocket dial: %v", err)
+	}
+	conn.PayloadType = websocket.BinaryFrame
+
+	return conn, nil
+}
+
+// k8sAuthHeaders returns the headers the client-go transport adds to requests, such as bearer tokens
+func k8sAuthHeaders(config *rest.Config) (http.Header, error) {
+	capture := &headerCapture{}
+
+	rt, err := rest.HTTPWrappersForConfig(config, capture)
+	if err != nil {
+		return nil, fmt.Errorf("k8s auth: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, config.Host, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new req: %v", err)
+	}
+
+	_, err = rt.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("k8s auth: %v", err)
+	}
+
+	return capture.header, nil
+}
+
+// headerCapture is a round tripper recording the request headers instead of sending it
+type headerCapture struct {
+	header http.Header
+}
+
+func (c *headerCapture) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.header = req.Header.Clone()
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+type websocketSession struct {
+	conn *websocket.Conn
+}
+
+func (s *websocketSession) Receive() ([]byte, error) {
+	var msg []byte
+	err := websocket.Message.Receive(s.conn, &msg)
+	return msg, err
+}
+
+func (s *websocketSession) Send(msg []byte) error {
+	return websocket.Message.Send(s.conn, msg)
+}
+
+func (s *websocketSession) Close() error {
+	return s.conn.Close()
+}
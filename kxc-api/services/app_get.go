@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/didil/kubexcloud/kxc-operator/controllers"
+)
+
+// maxAppEvents is the number of recent events returned with an app
+const maxAppEvents = 20
+
+// Get returns the app spec and status with its pods, recent events and rollout progress
+func (svc *AppService) Get(ctx context.Context, projectName, appName string) (*responses.GetApp, error) {
+	cl := svc.k8sSvc.Client()
+	namespace := controllers.ProjectNamespaceName(projectName)
+
+	app := &cloudv1alpha1.App{}
+	err := cl.Get(ctx, types.NamespacedName{Name: appName, Namespace: namespace}, app)
+	if err != nil {
+		return nil, fmt.Errorf("get app: %v", err)
+	}
+
+	respData := &responses.GetApp{
+		Name:      app.Name,
+		CreatedAt: app.CreationTimestamp.Time,
+		Spec: responses.AppSpec{
+			Replicas:    app.Spec.Replicas,
+			Containers:  appSpecContainers(app.Spec.Containers),
+			Volumes:     appSpecVolumes(app.Spec.Volumes),
+			Autoscaling: appSpecAutoscaling(app.Spec.Autoscaling),
+			TLS:         appSpecTLS(app.Spec.TLS),
+			Domains:     app.Spec.Domains,
+		},
+		Status: responses.AppStatus{
+			ExternalURL:         app.Status.ExternalURL,
+			ExternalURLs:        app.Status.ExternalURLs,
+			AvailableReplicas:   app.Status.AvailableReplicas,
+			UnavailableReplicas: app.Status.UnavailableReplicas,
+			CurrentReplicas:     app.Status.CurrentReplicas,
+			DesiredReplicas:     app.Status.DesiredReplicas,
+			ObservedGeneration:  app.Status.ObservedGeneration,
+			Conditions:          listConditions(app.Status.Conditions),
+		},
+		Pods:   []responses.AppPod{},
+		Events: []responses.AppEvent{},
+	}
+
+	// the deployment is named after the app
+	dep := &appsv1.Deployment{}
+	err = cl.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: namespace}, dep)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("get deployment: %v", err)
+	}
+	if err == nil {
+		respData.Rollout = appRollout(dep)
+	}
+
+	listOpts := []client.ListOption{
+		client.InNamespace(namespace),
+		client.MatchingLabels(controllers.LabelsForApp(projectName, appName)),
+	}
+
+	podList := &corev1.PodList{}
+	if err := cl.List(ctx, podList, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	sort.Slice(podList.Items, func(i, j int) bool { return podList.Items[i].Name < podList.Items[j].Name })
+
+	for _, pod := range podList.Items {
+		respData.Pods = append(respData.Pods, appPod(&pod))
+	}
+
+	// replica sets carry the pod template labels
+	rsList := &appsv1.ReplicaSetList{}
+	if err := cl.List(ctx, rsList, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list replica sets: %v", err)
+	}
+
+	objects := map[string]bool{
+		"App/" + app.Name:        true,
+		"Deployment/" + app.Name: true,
+	}
+	for _, rs := range rsList.Items {
+		objects["ReplicaSet/"+rs.Name] = true
+	}
+	for _, pod := range podList.Items {
+		objects["Pod/"+pod.Name] = true
+	}
+
+	respData.Events, err = svc.appEvents(ctx, namespace, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return respData, nil
+}
+
+// appEvents returns the most recent events of objects, keyed by <kind>/<name>
+func (svc *AppService) appEvents(ctx context.Context, namespace string, objects map[string]bool) ([]responses.AppEvent, error) {
+	cl := svc.k8sSvc.Client()
+
+	eventList := &corev1.EventList{}
+	if err := cl.List(ctx, eventList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list events: %v", err)
+	}
+
+	events := []responses.AppEvent{}
+	for _, e := range eventList.Items {
+		object := e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name
+		if !objects[object] {
+			continue
+		}
+
+		count := e.Count
+		if e.Series != nil {
+			count = e.Series.Count
+		}
+		if count == 0 {
+			count = 1
+		}
+
+		events = append(events, responses.AppEvent{
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Object:   object,
+			Message:  e.Message,
+			Count:    count,
+			LastSeen: eventLastSeen(&e),
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].LastSeen.Before(events[j].LastSeen) })
+	if len(events) > maxAppEvents {
+		events = events[len(events)-maxAppEvents:]
+	}
+
+	return events, nil
+}
+
+// eventLastSeen returns the last occurrence of the event, depending on the api that recorded it
+func eventLastSeen(e *corev1.Event) time.Time {
+	switch {
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime.Time
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	case !e.FirstTimestamp.IsZero():
+		return e.FirstTimestamp.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+// appRollout returns the rollout progress of the app deployment
+func appRollout(dep *appsv1.Deployment) *responses.AppRollout {
+	var replicas int32 = 1
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+
+	_, _, state, message := controllers.AppRolloutState(dep)
+
+	return &responses.AppRollout{
+		// set by the deployment controller on each new replica set
+		Revision:            dep.Annotations["deployment.kubernetes.io/revision"],
+		Replicas:            replicas,
+		UpdatedReplicas:     dep.Status.UpdatedReplicas,
+		ReadyReplicas:       dep.Status.ReadyReplicas,
+		AvailableReplicas:   dep.Status.AvailableReplicas,
+		UnavailableReplicas: dep.Status.UnavailableReplicas,
+		State:               state,
+		Message:             message,
+	}
+}
+
+// appPod returns the pod phase, readiness and restarts
+func appPod(pod *corev1.Pod) responses.AppPod {
+	appPod := responses.AppPod{
+		Name:       pod.Name,
+		Phase:      string(pod.Status.Phase),
+		Reason:     pod.Status.Reason,
+		Containers: int32(len(pod.Spec.Containers)),
+		Node:       pod.Spec.NodeName,
+		CreatedAt:  pod.CreationTimestamp.Time,
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		appPod.Restarts += cs.RestartCount
+		if cs.Ready {
+			appPod.ReadyContainers++
+		}
+
+		// the first container that isn't running explains the pod state
+		if appPod.Reason != "" {
+			continue
+		}
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			appPod.Reason = cs.State.Waiting.Reason
+		} else if cs.State.Terminated != nil && cs.State.Terminated.Reason != "" {
+			appPod.Reason = cs.State.Terminated.Reason
+		}
+	}
+
+	if pod.DeletionTimestamp != nil {
+		appPod.Reason = "Terminating"
+	}
+
+	return appPod
+}
+
+// appSpecContainers converts app spec containers to request containers
+func appSpecContainers(containers []cloudv1alpha1.Container) []requests.Container {
+	reqContainers := []requests.Container{}
+
+	for _, c := range containers {
+		reqContainer := requests.Container{
+			Image:   c.Image,
+			Name:    c.Name,
+			Command: c.Command,
+		}
+
+		for _, p := range c.Ports {
+			reqContainer.Ports = append(reqContainer.Ports, requests.Port{
+				Number:           p.Number,
+				Protocol:         string(p.Protocol),
+				ExposeExternally: p.ExposeExternally,
+				Path:             p.Path,
+				HostSuffix:       p.HostSuffix,
+			})
+		}
+
+		for _, e := range c.Env {
+			envVar := requests.EnvVar{
+				Name:  e.Name,
+				Value: e.Value,
+			}
+			if e.SecretKeyRef != nil {
+				envVar.SecretKeyRef = &requests.KeyRef{Name: e.SecretKeyRef.Name, Key: e.SecretKeyRef.Key}
+			}
+			if e.ConfigMapKeyRef != nil {
+				envVar.ConfigMapKeyRef = &requests.KeyRef{Name: e.ConfigMapKeyRef.Name, Key: e.ConfigMapKeyRef.Key}
+			}
+
+			reqContainer.Env = append(reqContainer.Env, envVar)
+		}
+
+		if c.Resources != nil {
+			reqContainer.Resources = &requests.Resources{}
+			if c.Resources.Requests != nil {
+				reqContainer.Resources.Requests = &requests.ResourceList{CPU: c.Resources.Requests.CPU, Memory: c.Resources.Requests.Memory}
+			}
+			if c.Resources.Limits != nil {
+				reqContainer.Resources.Limits = &requests.ResourceList{CPU: c.Resources.Limits.CPU, Memory: c.Resources.Limits.Memory}
+			}
+		}
+
+		reqContainer.LivenessProbe = appSpecProbe(c.LivenessProbe)
+		reqContainer.ReadinessProbe = appSpecProbe(c.ReadinessProbe)
+		reqContainer.StartupProbe = appSpecProbe(c.StartupProbe)
+
+		for _, m := range c.VolumeMounts {
+			reqContainer.VolumeMounts = append(reqContainer.VolumeMounts, requests.VolumeMount{
+				Name:      m.Name,
+				MountPath: m.MountPath,
+				ReadOnly:  m.ReadOnly,
+			})
+		}
+
+		reqContainers = append(reqContainers, reqContainer)
+	}
+
+	return reqContainers
+}
+
+// appSpecVolumes converts app spec volumes to request volumes
+func appSpecVolumes(volumes []cloudv1alpha1.Volume) []requests.Volume {
+	var reqVolumes []requests.Volume
+
+	for _, v := range volumes {
+		reqVolumes = append(reqVolumes, requests.Volume{
+			Name:             v.Name,
+			Size:             v.Size,
+			AccessMode:       string(v.AccessMode),
+			StorageClassName: v.StorageClassName,
+			ReclaimPolicy:    string(v.ReclaimPolicy),
+		})
+	}
+
+	return reqVolumes
+}
+
+// appSpecAutoscaling converts app spec autoscaling to request autoscaling
+func appSpecAutoscaling(autoscaling *cloudv1alpha1.Autoscaling) *requests.Autoscaling {
+	if autoscaling == nil {
+		return nil
+	}
+
+	return &requests.Autoscaling{
+		MinReplicas:                    autoscaling.MinReplicas,
+		MaxReplicas:                    autoscaling.MaxReplicas,
+		TargetCPUUtilizationPercentage: autoscaling.TargetCPUUtilizationPercentage,
+	}
+}
+
+// appSpecTLS converts app spec tls to request tls
+func appSpecTLS(tls *cloudv1alpha1.TLS) *requests.TLS {
+	if tls == nil {
+		return nil
+	}
+
+	return &requests.TLS{
+		Issuer:        tls.Issuer,
+		ClusterIssuer: tls.ClusterIssuer,
+	}
+}
+
+// appSpecProbe converts an app spec probe to a request probe
+func appSpecProbe(probe *cloudv1alpha1.Probe) *requests.Probe {
+	if probe == nil {
+		return nil
+	}
+
+	reqProbe := &requests.Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+
+	if probe.HTTPGet != nil {
+		reqProbe.HTTPGet = &requests.HTTPGetProbe{Path: probe.HTTPGet.Path, Port: probe.HTTPGet.Port}
+	}
+	if probe.TCPSocket != nil {
+		reqProbe.TCPSocket = &requests.TCPSocketProbe{Port: probe.TCPSocket.Port}
+	}
+	if probe.Exec != nil {
+		reqProbe.Exec = &requests.ExecProbe{Command: probe.Exec.Command}
+	}
+
+	return reqProbe
+}
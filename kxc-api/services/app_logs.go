@@ -0,0 +1,155 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/didil/kubexcloud/kxc-operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Logs writes the logs of all the app pods to w, each line is prefixed with the pod name.
+// Errors are only returned before anything is written, later pod stream errors are written as log lines
+func (svc *AppService) Logs(ctx context.Context, projectName, appName string, reqData *requests.AppLogs, w io.Writer) error {
+	cl := svc.k8sSvc.Client()
+	namespace := controllers.ProjectNamespaceName(projectName)
+
+	app := &cloudv1alpha1.App{}
+	err := cl.Get(ctx, types.NamespacedName{Name: appName, Namespace: namespace}, app)
+	if err != nil {
+		return fmt.Errorf("get app: %v", err)
+	}
+
+	containerName, err := appContainer(app, reqData.Container)
+	if err != nil {
+		return err
+	}
+
+	podList := &corev1.PodList{}
+	listOpts := []client.ListOption{
+		client.InNamespace(namespace),
+		client.MatchingLabels(controllers.LabelsForApp(projectName, appName)),
+	}
+	if err := cl.List(ctx, podList, listOpts...); err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+	if len(podList.Items) == 0 {
+		return fmt.Errorf("no pods found for app %s", appName)
+	}
+
+	podNames := []string{}
+	for _, pod := range podList.Items {
+		podNames = append(podNames, pod.Name)
+	}
+	sort.Strings(podNames)
+
+	logOpts := &corev1.PodLogOptions{
+		Container:    containerName,
+		Follow:       reqData.Follow,
+		TailLines:    reqData.TailLines,
+		SinceSeconds: reqData.SinceSeconds,
+	}
+
+	streams := map[string]io.ReadCloser{}
+	defer func() {
+		for _, stream := range streams {
+			stream.Close()
+		}
+	}()
+
+	// pods that aren't running yet can't be streamed, only fail if no pod can
+	openErrs := map[string]error{}
+	for _, podName := range podNames {
+		stream, err := svc.k8sSvc.Clientset().CoreV1().Pods(namespace).GetLogs(podName, logOpts).Stream(ctx)
+		if err != nil {
+			openErrs[podName] = err
+			continue
+		}
+		streams[podName] = stream
+	}
+	if len(streams) == 0 {
+		return fmt.Errorf("stream logs of pod %s: %v", podNames[0], openErrs[podNames[0]])
+	}
+
+	// lines of the pods are merged as they come
+	lw := &lineWriter{w: w}
+
+	for _, podName := range podNames {
+		if err, ok := openErrs[podName]; ok {
+			lw.WriteLine("[" + podName + "] error: " + err.Error())
+		}
+	}
+
+	var wg sync.WaitGroup
+	for podName, stream := range streams {
+		wg.Add(1)
+		go func(podName string, stream io.Reader) {
+			defer wg.Done()
+
+			prefix := "[" + podName + "] "
+
+			r := bufio.NewReader(stream)
+			for {
+				line, err := r.ReadString('\n')
+				if line != "" {
+					if lw.WriteLine(prefix+strings.TrimSuffix(line, "\n")) != nil {
+						return
+					}
+				}
+				if err == io.EOF || ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					lw.WriteLine(prefix + "error: " + err.Error())
+					return
+				}
+			}
+		}(podName, stream)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// appContainer returns the app container called name, the only one if name is empty
+func appContainer(app *cloudv1alpha1.App, name string) (string, error) {
+	names := []string{}
+	for _, c := range app.Spec.Containers {
+		if c.Name == name {
+			return name, nil
+		}
+		names = append(names, c.Name)
+	}
+
+	if name != "" {
+		return "", fmt.Errorf("container not found: %s", name)
+	}
+	if len(names) != 1 {
+		return "", fmt.Errorf("container required, one of: %s", strings.Join(names, ", "))
+	}
+
+	return names[0], nil
+}
+
+// lineWriter serializes the lines written by concurrent streams
+type lineWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lineWriter) WriteLine(line string) error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	_, err := io.WriteString(lw.w, line+"\n")
+	return err
+}
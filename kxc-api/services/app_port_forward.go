@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/didil/kubexcloud/kxc-operator/controllers"
+)
+
+// PortForwardProtocol is the websocket subprotocol of port forward sessions. Each message starts with
+// the byte of its stream, see the PortForwardStream constants. A session tunnels a single connection
+const PortForwardProtocol = "v4.channel.k8s.io"
+
+// port forward session streams
+const (
+	PortForwardStreamData byte = 0
+	// error messages of the pod connection, as text
+	PortForwardStreamError byte = 1
+)
+
+// PortForwardSession is a connection to a port of an app replica
+type PortForwardSession interface {
+	Receive() ([]byte, error)
+	Send(msg []byte) error
+	Close() error
+}
+
+// PortForward opens a connection to a TCP port of a replica of the app. Exposed ports can also be
+// forwarded, as long as they are declared by a container of the app
+func (svc *AppService) PortForward(ctx context.Context, projectName, appName string, reqData *requests.AppPortForward) (PortForwardSession, error) {
+	cl := svc.k8sSvc.Client()
+	namespace := controllers.ProjectNamespaceName(projectName)
+
+	app := &cloudv1alpha1.App{}
+	err := cl.Get(ctx, types.NamespacedName{Name: appName, Namespace: namespace}, app)
+	if err != nil {
+		return nil, fmt.Errorf("get app: %v", err)
+	}
+
+	err = checkAppPort(app, reqData.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	podName, err := svc.runningPod(ctx, projectName, appName, reqData.Pod)
+	if err != nil {
+		return nil, err
+	}
+
+	portForwardURL := svc.k8sSvc.Clientset().CoreV1().RESTClient().Get().
+		Namespace(namespace).
+		Resource("pods").
+		Name(podName).
+		SubResource("portforward").
+		Param("ports", strconv.Itoa(int(reqData.Port))).
+		URL()
+
+	conn, err := dialK8sWebsocket(svc.k8sSvc.RestConfig(), portForwardURL.String(), PortForwardProtocol)
+	if err != nil {
+		return nil, fmt.Errorf("port forward to pod %s: %v", podName, err)
+	}
+
+	return &portForwardSession{websocketSession: &websocketSession{conn: conn}}, nil
+}
+
+// checkAppPort returns an error if port isn't a TCP port of the app containers
+func checkAppPort(app *cloudv1alpha1.App, port int32) error {
+	ports := []string{}
+	for _, c := range app.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Protocol != corev1.ProtocolTCP {
+				continue
+			}
+			if p.Number == port {
+				return nil
+			}
+			ports = append(ports, strconv.Itoa(int(p.Number)))
+		}
+	}
+
+	if len(ports) == 0 {
+		return fmt.Errorf("app %s has no TCP ports", app.Name)
+	}
+
+	return fmt.Errorf("port %d isn't a TCP port of app %s, ports: %s", port, app.Name, strings.Join(ports, ", "))
+}
+
+// portForwardSession strips the port number the kubernetes api sends at the start of each stream
+type portForwardSession struct {
+	*websocketSession
+	started [2]bool
+}
+
+func (s *portForwardSession) Receive() ([]byte, error) {
+	for {
+		msg, err := s.websocketSession.Receive()
+		if err != nil {
+			return nil, err
+		}
+		if len(msg) == 0 || int(msg[0]) >= len(s.started) {
+			continue
+		}
+
+		stream := msg[0]
+		if !s.started[stream] {
+			// the first message holds the 2 bytes little endian port number
+			s.started[stream] = true
+			if len(msg) < 3 {
+				continue
+			}
+			msg = append([]byte{stream}, msg[3:]...)
+			if len(msg) == 1 {
+				continue
+			}
+		}
+
+		return msg, nil
+	}
+}
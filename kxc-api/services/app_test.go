@@ -0,0 +1,107 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/didil/kubexcloud/kxc-operator/controllers"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type AppServiceTestSuite struct {
+	suite.Suite
+}
+
+func TestAppServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AppServiceTestSuite))
+}
+
+func newApp(projectName, appName string) *cloudv1alpha1.App {
+	return &cloudv1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: controllers.ProjectNamespaceName(projectName),
+			Labels:    controllers.LabelsForApp(projectName, appName),
+		},
+	}
+}
+
+func (suite *AppServiceTestSuite) Test_Update_VolumeImmutableFields() {
+	app := newApp("project-a", "db")
+	app.Spec.Volumes = []cloudv1alpha1.Volume{
+		cloudv1alpha1.Volume{Name: "data", Size: "1Gi"},
+	}
+
+	k8sSvc, _ := testsupport.FakeK8sSvc(app)
+	appSvc := services.NewAppService(k8sSvc)
+
+	update := func(v requests.Volume) error {
+		return appSvc.Update(context.Background(), "project-a", "db", &requests.UpdateApp{
+			Replicas: 1,
+			Containers: []requests.Container{
+				requests.Container{Name: "db", Image: "postgres"},
+			},
+			Volumes: []requests.Volume{v},
+		})
+	}
+
+	err := update(requests.Volume{Name: "data", Size: "1Gi", AccessMode: "ReadWriteMany"})
+	suite.EqualError(err, "app invalid: volume data: access mode can't be changed from ReadWriteOnce to ReadWriteMany")
+
+	err = update(requests.Volume{Name: "data", Size: "1Gi", StorageClassName: "fast"})
+	suite.EqualError(err, `app invalid: volume data: storage class can't be changed from "" to "fast"`)
+
+	// the default access mode can be made explicit, and the volume expanded
+	err = update(requests.Volume{Name: "data", Size: "2Gi", AccessMode: "ReadWriteOnce"})
+	suite.NoError(err)
+}
+
+func (suite *AppServiceTestSuite) Test_Create_HostCollision() {
+	// app a-b in project c and app a in project b-c share the host label a-b-c
+	k8sSvc, _ := testsupport.FakeK8sSvc(newApp("c", "a-b"))
+	appSvc := services.NewAppService(k8sSvc)
+
+	err := appSvc.Create(context.Background(), "b-c", &requests.CreateApp{Name: "a", Replicas: 1})
+	suite.EqualError(err, "app invalid: host a-b-c."+controllers.AppRootDomain()+" is already in use")
+
+	err = appSvc.Create(context.Background(), "b-c", &requests.CreateApp{Name: "b", Replicas: 1})
+	suite.NoError(err)
+}
+
+func (suite *AppServiceTestSuite) Test_CreateUpdate_HostSuffixCollision() {
+	// app web in project p-api has the host label web-p-api
+	k8sSvc, _ := testsupport.FakeK8sSvc(newApp("p-api", "web"))
+	appSvc := services.NewAppService(k8sSvc)
+
+	containers := func(suffix string) []requests.Container {
+		return []requests.Container{
+			requests.Container{
+				Name:  "web",
+				Image: "nginx",
+				Ports: []requests.Port{
+					requests.Port{Number: 80, Protocol: "TCP", ExposeExternally: true},
+					requests.Port{Number: 9000, Protocol: "TCP", ExposeExternally: true, HostSuffix: suffix},
+				},
+			},
+		}
+	}
+
+	// the api suffix of app web in project p is also web-p-api
+	err := appSvc.Create(context.Background(), "p", &requests.CreateApp{Name: "web", Replicas: 1, Containers: containers("api")})
+	suite.EqualError(err, "app invalid: host web-p-api."+controllers.AppRootDomain()+" is already in use")
+
+	err = appSvc.Create(context.Background(), "p", &requests.CreateApp{Name: "web", Replicas: 1, Containers: containers("admin")})
+	suite.NoError(err)
+
+	err = appSvc.Update(context.Background(), "p", "web", &requests.UpdateApp{Replicas: 1, Containers: containers("api")})
+	suite.EqualError(err, "app invalid: host web-p-api."+controllers.AppRootDomain()+" is already in use")
+
+	// the suffixed hosts of existing apps are taken too
+	err = appSvc.Create(context.Background(), "p-admin", &requests.CreateApp{Name: "web", Replicas: 1})
+	suite.EqualError(err, "app invalid: host web-p-admin."+controllers.AppRootDomain()+" is already in use")
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+)
+
+// Authenticator checks a user name and password against an identity provider
+type Authenticator interface {
+	// Provider returns the UserAccount provider handled by the authenticator
+	Provider() string
+	// Authenticate returns the user the credentials belong to, user is nil if it doesn't exist yet.
+	// Wrong user names and passwords are reported as ErrInvalidCredentials
+	Authenticate(ctx context.Context, user *cloudv1alpha1.UserAccount, userName, password string) (*cloudv1alpha1.UserAccount, error)
+}
+
+// localAuthenticator checks passwords against the bcrypt hashes stored in the user secrets
+type localAuthenticator struct {
+	svc *UserService
+}
+
+func (a *localAuthenticator) Provider() string {
+	return UserProviderLocal
+}
+
+func (a *localAuthenticator) Authenticate(ctx context.Context, user *cloudv1alpha1.UserAccount, userName, password string) (*cloudv1alpha1.UserAccount, error) {
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	passwordHash, secret, err := a.svc.passwordHash(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := comparePasswords(passwordHash, []byte(password))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	// upgrade hashes made with a different cost, unmigrated users are moved to a secret by the operator first
+	if secret != nil && len(passwordHash) > 0 && !hasBcryptCost(passwordHash, bcryptCost()) {
+		err = a.svc.rehashPassword(ctx, secret, []byte(password))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"github.com/didil/kubexcloud/kxc-api/responses"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+)
+
+// listConditions converts resource status conditions to response conditions
+func listConditions(conditions []cloudv1alpha1.Condition) []responses.Condition {
+	respConditions := []responses.Condition{}
+
+	for _, c := range conditions {
+		respConditions = append(respConditions, responses.Condition{
+			Type:               c.Type,
+			Status:             string(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime.Time,
+		})
+	}
+
+	return respConditions
+}
@@ -0,0 +1,12 @@
+package services
+
+import "time"
+
+// SetJWKSRefreshInterval lets tests refetch the jwks right away, the returned func restores the interval
+func SetJWKSRefreshInterval(interval time.Duration) func() {
+	previous := jwksRefreshInterval
+	jwksRefreshInterval = interval
+	return func() {
+		jwksRefreshInterval = previous
+	}
+}
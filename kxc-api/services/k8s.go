@@ -1,25 +1,43 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// cacheSyncTimeout bounds the initial sync of the informers cache
+const cacheSyncTimeout = 30 * time.Second
+
 type K8sSvc interface {
 	Client() client.Client
+	Clientset() kubernetes.Interface
+	RestConfig() *rest.Config
+	Cache() cache.Cache
+	Scheme() *runtime.Scheme
 }
 
 type K8sService struct {
 	client client.Client
+	// typed clientset for the subresources the controller-runtime client doesn't support, such as pod logs
+	clientset kubernetes.Interface
+	// config to open the streams the clientset doesn't support, such as exec websockets
+	restConfig *rest.Config
+	// informer cache shared by the watch streams, instead of a watch per client, run by StartCache
+	cache  cache.Cache
+	scheme *runtime.Scheme
 }
 
 func NewK8sService() (*K8sService, error) {
@@ -38,17 +56,67 @@ func NewK8sService() (*K8sService, error) {
 		return nil, fmt.Errorf("cloudv1alpha1: %v", err)
 	}
 
-	client, err := svc.initK8sClient(scheme)
+	config, err := svc.getConfig()
+	if err != nil {
+		return nil, fmt.Errorf("getConfig: %v", err)
+	}
+
+	client, err := client.New(config, client.Options{Scheme: scheme})
 	if err != nil {
 		return nil, fmt.Errorf("initK8sClient: %v", err)
 	}
 
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("initK8sClientset: %v", err)
+	}
+
+	informerCache, err := cache.New(config, cache.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("initK8sCache: %v", err)
+	}
+
 	svc.client = client
+	svc.clientset = clientset
+	svc.restConfig = config
+	svc.cache = informerCache
+	svc.scheme = scheme
 
 	return svc, nil
 }
 
-func (svc *K8sService) initK8sClient(scheme *runtime.Scheme) (client.Client, error) {
+// StartCache runs the informers cache until ctx is done and waits for its initial sync,
+// the informers must be created beforehand. Errors of the running cache are sent on the returned channel
+func (svc *K8sService) StartCache(ctx context.Context) (<-chan error, error) {
+	errs := make(chan error, 1)
+	go func() {
+		err := svc.cache.Start(ctx.Done())
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	syncCtx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+
+	synced := make(chan bool, 1)
+	go func() {
+		synced <- svc.cache.WaitForCacheSync(syncCtx.Done())
+	}()
+
+	select {
+	case err := <-errs:
+		return nil, fmt.Errorf("k8s cache: %v", err)
+	case ok := <-synced:
+		if !ok {
+			return nil, fmt.Errorf("k8s cache: sync timed out")
+		}
+	}
+
+	return errs, nil
+}
+
+func (svc *K8sService) getConfig() (*rest.Config, error) {
 	config, err := svc.getInClusterConfig()
 	if err != nil {
 		return nil, err
@@ -62,12 +130,7 @@ func (svc *K8sService) initK8sClient(scheme *runtime.Scheme) (client.Client, err
 
 	}
 
-	client, err := client.New(config, client.Options{Scheme: scheme})
-	if err != nil {
-		return nil, err
-	}
-
-	return client, nil
+	return config, nil
 }
 
 func (svc *K8sService) getInClusterConfig() (*rest.Config, error) {
@@ -106,3 +169,19 @@ func (svc *K8sService) getOutOfClusterConfig() (*rest.Config, error) {
 func (svc *K8sService) Client() client.Client {
 	return svc.client
 }
+
+func (svc *K8sService) Clientset() kubernetes.Interface {
+	return svc.clientset
+}
+
+func (svc *K8sService) RestConfig() *rest.Config {
+	return svc.restConfig
+}
+
+func (svc *K8sService) Cache() cache.Cache {
+	return svc.cache
+}
+
+func (svc *K8sService) Scheme() *runtime.Scheme {
+	return svc.scheme
+}
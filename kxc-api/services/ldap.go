@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapTimeout applies to ldap connections and requests without a context deadline
+const ldapTimeout = 10 * time.Second
+
+// LDAPConfig configures the LDAP authentication backend
+type LDAPConfig struct {
+	// ldap:// or ldaps:// server url, ldap is disabled if empty
+	URL string
+	// service account used to search users, searches are anonymous if empty
+	BindDN       string
+	BindPassword string
+	// users are searched under BaseDN with UserFilter, %s is replaced by the escaped user name
+	BaseDN     string
+	UserFilter string
+	// attribute listing the group DNs of the user
+	GroupAttribute string
+	// role of the members of each group DN, roles aren't synced if empty
+	GroupRoles map[string]string
+	// role of auto provisioned users not in a mapped group
+	DefaultRole string
+}
+
+// LDAPConfigFromEnv reads the ldap config from LDAP_* env variables.
+// LDAP_GROUP_ROLES is a ; separated list of <group dn>:<role>
+func LDAPConfigFromEnv() *LDAPConfig {
+	config := &LDAPConfig{
+		URL:            os.Getenv("LDAP_URL"),
+		BindDN:         os.Getenv("LDAP_BIND_DN"),
+		BindPassword:   os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:         os.Getenv("LDAP_BASE_DN"),
+		UserFilter:     os.Getenv("LDAP_USER_FILTER"),
+		GroupAttribute: os.Getenv("LDAP_GROUP_ATTRIBUTE"),
+		GroupRoles:     map[string]string{},
+		DefaultRole:    os.Getenv("LDAP_DEFAULT_ROLE"),
+	}
+
+	if config.UserFilter == "" {
+		config.UserFilter = "(uid=%s)"
+	}
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = "memberOf"
+	}
+	if config.DefaultRole == "" {
+		config.DefaultRole = UserRoleRegular
+	}
+	for _, mapping := range strings.Split(os.Getenv("LDAP_GROUP_ROLES"), ";") {
+		i := strings.LastIndex(mapping, ":")
+		if i <= 0 {
+			continue
+		}
+		config.GroupRoles[strings.ToLower(strings.TrimSpace(mapping[:i]))] = strings.TrimSpace(mapping[i+1:])
+	}
+
+	return config
+}
+
+// Enabled returns true if a server is configured
+func (config *LDAPConfig) Enabled() bool {
+	return config.URL != ""
+}
+
+// ldapAuthenticator looks up users with a search then checks their password with a bind
+type ldapAuthenticator struct {
+	config *LDAPConfig
+	svc    *UserService
+}
+
+func (a *ldapAuthenticator) Provider() string {
+	return UserProviderLDAP
+}
+
+func (a *ldapAuthenticator) Authenticate(ctx context.Context, user *cloudv1alpha1.UserAccount, userName, password string) (*cloudv1alpha1.UserAccount, error) {
+	// empty passwords would be unauthenticated binds, which servers accept for any dn
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	timeout := ldapTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	conn, err := ldap.DialURL(a.config.URL, ldap.DialWithDialer(&net.Dialer{Timeout: timeout}))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: %v", err)
+	}
+	defer conn.Close()
+	conn.SetTimeout(timeout)
+
+	if a.config.BindDN != "" {
+		err = conn.Bind(a.config.BindDN, a.config.BindPassword)
+		if err != nil {
+			return nil, fmt.Errorf("ldap service bind: %v", err)
+		}
+	}
+
+	filter := strings.Replace(a.config.UserFilter, "%s", ldap.EscapeFilter(userName), -1)
+	// a size limit of 2 is enough to detect ambiguous filters
+	searchReq := ldap.NewSearchRequest(a.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(timeout.Seconds()), false,
+		filter, []string{a.config.GroupAttribute}, nil)
+	result, err := conn.Search(searchReq)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("ldap user filter matches several entries: %s", userName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ldap search: %v", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("ldap user filter matches several entries: %s", userName)
+	}
+	entry := result.Entries[0]
+
+	err = conn.Bind(entry.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ldap bind: %v", err)
+	}
+
+	// admin wins over other mapped roles
+	role, mapped := a.config.DefaultRole, false
+	for _, group := range entry.GetEqualFoldAttributeValues(a.config.GroupAttribute) {
+		groupRole, ok := a.config.GroupRoles[strings.ToLower(group)]
+		if ok && (!mapped || groupRole == UserRoleAdmin) {
+			role, mapped = groupRole, true
+		}
+	}
+
+	return a.svc.provisionUser(ctx, userName, "", UserProviderLDAP, role, len(a.config.GroupRoles) > 0)
+}
@@ -0,0 +1,206 @@
+package services_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/stretchr/testify/suite"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ldap protocol ops and result codes used by the fake server
+const (
+	ldapOpBindRequest       = 0
+	ldapOpBindResponse      = 1
+	ldapOpUnbindRequest     = 2
+	ldapOpSearchRequest     = 3
+	ldapOpSearchResultEntry = 4
+	ldapOpSearchResultDone  = 5
+
+	ldapResultSuccess            = 0
+	ldapResultInvalidCredentials = 49
+)
+
+type ldapEntry struct {
+	dn       string
+	password string
+	groups   []string
+}
+
+// fakeLDAPServer answers simple binds and returns all its entries to any search
+func fakeLDAPServer(t *testing.T, entries ...ldapEntry) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeLDAPConn(conn, entries)
+		}
+	}()
+
+	return "ldap://" + listener.Addr().String()
+}
+
+func serveFakeLDAPConn(conn net.Conn, entries []ldapEntry) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value
+		op := packet.Children[1]
+
+		reply := func(op *ber.Packet) {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+			envelope.AppendChild(op)
+			conn.Write(envelope.Bytes())
+		}
+		result := func(tag ber.Tag, code int64) *ber.Packet {
+			res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+			res.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "ResultCode"))
+			res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "MatchedDN"))
+			res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "DiagnosticMessage"))
+			return res
+		}
+
+		switch op.Tag {
+		case ldapOpBindRequest:
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			code := int64(ldapResultInvalidCredentials)
+			for _, entry := range entries {
+				if entry.dn == dn && entry.password == password {
+					code = ldapResultSuccess
+				}
+			}
+			reply(result(ldapOpBindResponse, code))
+		case ldapOpSearchRequest:
+			for _, entry := range entries {
+				res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapOpSearchResultEntry, nil, "Entry")
+				res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "DN"))
+				attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+				attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+				attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "memberOf", "Type"))
+				vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+				for _, group := range entry.groups {
+					vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, group, "Value"))
+				}
+				attr.AppendChild(vals)
+				attrs.AppendChild(attr)
+				res.AppendChild(attrs)
+				reply(res)
+			}
+			reply(result(ldapOpSearchResultDone, ldapResultSuccess))
+		case ldapOpUnbindRequest:
+			return
+		}
+	}
+}
+
+type LDAPTestSuite struct {
+	suite.Suite
+}
+
+func TestLDAPTestSuite(t *testing.T) {
+	suite.Run(t, new(LDAPTestSuite))
+}
+
+func (suite *LDAPTestSuite) SetupTest() {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Setenv("LDAP_BASE_DN", "dc=example,dc=org")
+	os.Setenv("LDAP_GROUP_ROLES", "cn=admins,ou=groups,dc=example,dc=org:admin")
+}
+
+func (suite *LDAPTestSuite) TearDownTest() {
+	for _, key := range []string{"JWT_SECRET", "LDAP_URL", "LDAP_BASE_DN", "LDAP_GROUP_ROLES"} {
+		os.Unsetenv(key)
+	}
+}
+
+func (suite *LDAPTestSuite) Test_Login_Provision() {
+	ctx := context.Background()
+
+	os.Setenv("LDAP_URL", fakeLDAPServer(suite.T(), ldapEntry{
+		dn:       "uid=jdoe,ou=people,dc=example,dc=org",
+		password: "secret",
+		groups:   []string{"cn=dev,ou=groups,dc=example,dc=org", "CN=Admins,OU=Groups,DC=example,DC=org"},
+	}))
+
+	k8sSvc, cl := testsupport.FakeK8sSvc()
+	userSvc := services.NewUserService(k8sSvc)
+
+	_, err := userSvc.Login(ctx, "jdoe", "wrong", "10.0.0.1")
+	suite.Equal(services.ErrInvalidCredentials, err)
+
+	_, err = userSvc.Login(ctx, "jdoe", "", "10.0.0.1")
+	suite.Equal(services.ErrInvalidCredentials, err)
+
+	respData, err := userSvc.Login(ctx, "jdoe", "secret", "10.0.0.1")
+	suite.NoError(err)
+	suite.NotEmpty(respData.Token)
+
+	// first logins create the user, with the role of its groups
+	user := &cloudv1alpha1.UserAccount{}
+	suite.NoError(cl.Get(ctx, types.NamespacedName{Name: "jdoe"}, user))
+	suite.Equal(services.UserProviderLDAP, user.Spec.Provider)
+	suite.Equal(services.UserRoleAdmin, user.Spec.Role)
+}
+
+func (suite *LDAPTestSuite) Test_Login_AmbiguousFilter() {
+	os.Setenv("LDAP_URL", fakeLDAPServer(suite.T(),
+		ldapEntry{dn: "uid=jdoe,ou=people,dc=example,dc=org", password: "secret"},
+		ldapEntry{dn: "uid=jdoe,ou=contractors,dc=example,dc=org", password: "secret"},
+	))
+
+	k8sSvc, _ := testsupport.FakeK8sSvc()
+	userSvc := services.NewUserService(k8sSvc)
+
+	_, err := userSvc.Login(context.Background(), "jdoe", "secret", "10.0.0.1")
+	suite.EqualError(err, "ldap user filter matches several entries: jdoe")
+}
+
+func (suite *LDAPTestSuite) Test_Login_MixedCaseLockout() {
+	ctx := context.Background()
+
+	os.Setenv("LDAP_URL", fakeLDAPServer(suite.T(), ldapEntry{
+		dn:       "uid=alice,ou=people,dc=example,dc=org",
+		password: "secret",
+	}))
+	os.Setenv("LOGIN_MAX_FAILED_ATTEMPTS", "2")
+	defer os.Unsetenv("LOGIN_MAX_FAILED_ATTEMPTS")
+
+	alice := newUserAccount("alice", "uid-1")
+	alice.Spec.Provider = services.UserProviderLDAP
+	k8sSvc, cl := testsupport.FakeK8sSvc(alice)
+	userSvc := services.NewUserService(k8sSvc)
+
+	// failed attempts count against the existing user whatever the case of the name
+	for _, userName := range []string{"Alice", "ALICE"} {
+		_, err := userSvc.Login(ctx, userName, "wrong", "10.0.0.1")
+		suite.Equal(services.ErrInvalidCredentials, err)
+	}
+
+	user := &cloudv1alpha1.UserAccount{}
+	suite.NoError(cl.Get(ctx, types.NamespacedName{Name: "alice"}, user))
+	suite.Equal(int32(2), user.Status.FailedLoginAttempts)
+	suite.NotNil(user.Status.LockedUntil)
+
+	// locked out, even with the right password
+	_, err := userSvc.Login(ctx, "Alice", "secret", "10.0.0.1")
+	suite.Equal(services.ErrInvalidCredentials, err)
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidCredentials is returned for all failed password logins, so that user names can't be enumerated
+	ErrInvalidCredentials = errors.New("invalid user name or password")
+	// ErrLoginRateLimited is returned when a user name or client ip made too many login attempts
+	ErrLoginRateLimited = errors.New("too many login attempts, try again later")
+)
+
+// login attempts are rate limited over loginRateWindow
+const loginRateWindow = time.Minute
+
+// accounts are locked for loginLockoutDuration after LOGIN_MAX_FAILED_ATTEMPTS consecutive failures,
+// the duration doubles on each further lockout up to maxLoginLockoutDuration
+const maxLoginLockoutDuration = 24 * time.Hour
+
+func envInt(key string, defaultValue int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return defaultValue
+	}
+	return n
+}
+
+// LoginRateLimitUser returns the login attempts allowed per user name and minute, set by LOGIN_RATE_LIMIT_USER
+func LoginRateLimitUser() int {
+	return envInt("LOGIN_RATE_LIMIT_USER", 10)
+}
+
+// LoginRateLimitIP returns the login attempts allowed per client ip and minute, set by LOGIN_RATE_LIMIT_IP
+func LoginRateLimitIP() int {
+	return envInt("LOGIN_RATE_LIMIT_IP", 30)
+}
+
+// LoginMaxFailedAttempts returns the consecutive failures locking an account, set by LOGIN_MAX_FAILED_ATTEMPTS
+func LoginMaxFailedAttempts() int32 {
+	return int32(envInt("LOGIN_MAX_FAILED_ATTEMPTS", 5))
+}
+
+// LoginLockoutDuration returns the duration of the first lockout, set by LOGIN_LOCKOUT_DURATION
+func LoginLockoutDuration() time.Duration {
+	return envDuration("LOGIN_LOCKOUT_DURATION", time.Minute)
+}
+
+// loginLockoutDuration returns how long the account is locked after failedAttempts, 0 if it isn't
+func loginLockoutDuration(failedAttempts int32) time.Duration {
+	maxAttempts := LoginMaxFailedAttempts()
+	if failedAttempts < maxAttempts || failedAttempts%maxAttempts != 0 {
+		return 0
+	}
+
+	duration := LoginLockoutDuration()
+	for i := int32(1); i < failedAttempts/maxAttempts; i++ {
+		duration *= 2
+		if duration >= maxLoginLockoutDuration {
+			return maxLoginLockoutDuration
+		}
+	}
+
+	return duration
+}
+
+// loginLimiter rate limits login attempts per user name and per client ip
+type loginLimiter struct {
+	users *rateLimiter
+	ips   *rateLimiter
+}
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{
+		users: newRateLimiter(LoginRateLimitUser(), loginRateWindow),
+		ips:   newRateLimiter(LoginRateLimitIP(), loginRateWindow),
+	}
+}
+
+// Allow records an attempt and returns false if the user name or client ip is over its limit
+func (l *loginLimiter) Allow(userName, clientIP string) bool {
+	// both limits are always counted
+	userOk := l.users.Allow(userName)
+	ipOk := clientIP == "" || l.ips.Allow(clientIP)
+	return userOk && ipOk
+}
+
+// Reset clears the attempts of the user name
+func (l *loginLimiter) Reset(userName string) {
+	l.users.Reset(userName)
+}
+
+// rateLimiter counts attempts per key over fixed windows
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu       sync.Mutex
+	windows  map[string]*rateWindow
+	prunedAt time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:    limit,
+		window:   window,
+		windows:  map[string]*rateWindow{},
+		prunedAt: time.Now(),
+	}
+}
+
+// Allow records an attempt for key and returns false if the limit is exceeded
+func (l *rateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	// drop the expired windows so that the map doesn't grow with every key ever seen
+	if now.Sub(l.prunedAt) > l.window {
+		for k, w := range l.windows {
+			if now.Sub(w.start) > l.window {
+				delete(l.windows, k)
+			}
+		}
+		l.prunedAt = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) > l.window {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+	w.count++
+
+	return w.count <= l.limit
+}
+
+// Reset clears the attempts of key
+func (l *rateLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.windows, key)
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// OIDCConfig configures the OpenID Connect authentication mode
+type OIDCConfig struct {
+	// issuer url, oidc is disabled if empty
+	Issuer   string
+	ClientID string
+	// claim used as the kxc user name, sub by default. The email claim is only accepted if email_verified is set
+	UsernameClaim string
+	// claim listing the groups of the user
+	GroupsClaim string
+	// members of these groups get the admin role, roles aren't synced if empty
+	AdminGroups []string
+	// role of auto provisioned users not in an admin group
+	DefaultRole string
+}
+
+// OIDCConfigFromEnv reads the oidc config from OIDC_* env variables
+func OIDCConfigFromEnv() *OIDCConfig {
+	config := &OIDCConfig{
+		Issuer:        strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+		ClientID:      os.Getenv("OIDC_CLIENT_ID"),
+		UsernameClaim: os.Getenv("OIDC_USERNAME_CLAIM"),
+		GroupsClaim:   os.Getenv("OIDC_GROUPS_CLAIM"),
+		DefaultRole:   os.Getenv("OIDC_DEFAULT_ROLE"),
+	}
+
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "sub"
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	if config.DefaultRole == "" {
+		config.DefaultRole = UserRoleRegular
+	}
+	if adminGroups := os.Getenv("OIDC_ADMIN_GROUPS"); adminGroups != "" {
+		config.AdminGroups = strings.Split(adminGroups, ",")
+	}
+
+	return config
+}
+
+// Enabled returns true if an issuer is configured
+func (config *OIDCConfig) Enabled() bool {
+	return config.Issuer != ""
+}
+
+// OIDCIdentity is the user identity of a verified id token
+type OIDCIdentity struct {
+	// sub claim, stable for a user of the issuer
+	Subject  string
+	UserName string
+	Groups   []string
+}
+
+// jwks are refetched after jwksCacheTTL, or when a token is signed by an unknown key
+// at most once every jwksRefreshInterval
+var (
+	jwksCacheTTL        = time.Hour
+	jwksRefreshInterval = time.Minute
+)
+
+// oidcVerifier verifies id tokens against the issuer keys
+type oidcVerifier struct {
+	config     *OIDCConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func newOIDCVerifier(config *OIDCConfig) *oidcVerifier {
+	return &oidcVerifier{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Verify checks the id token signature, issuer, audience and expiry and returns the identity it holds
+func (v *oidcVerifier) Verify(ctx context.Context, rawIDToken string) (*OIDCIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("id token invalid: %v", err)
+	}
+
+	if iss, _ := claims["iss"].(string); iss != v.config.Issuer {
+		return nil, fmt.Errorf("id token issuer invalid: %s", iss)
+	}
+	if !claimContains(claims["aud"], v.config.ClientID) {
+		return nil, fmt.Errorf("id token audience invalid")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("id token expiry missing")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("id token claim missing: sub")
+	}
+
+	userName, _ := claims[v.config.UsernameClaim].(string)
+	if userName == "" {
+		return nil, fmt.Errorf("id token claim missing: %s", v.config.UsernameClaim)
+	}
+	// unverified emails can be set to anything by the user at some issuers
+	if emailVerified, _ := claims["email_verified"].(bool); v.config.UsernameClaim == "email" && !emailVerified {
+		return nil, fmt.Errorf("id token email not verified")
+	}
+
+	identity := &OIDCIdentity{
+		Subject:  subject,
+		UserName: strings.ToLower(userName),
+		Groups:   claimStrings(claims[v.config.GroupsClaim]),
+	}
+
+	return identity, nil
+}
+
+// key returns the issuer public key kid, fetching the jwks when needed
+func (v *oidcVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	expired := time.Since(v.fetchedAt) > jwksCacheTTL
+	if ok && !expired {
+		return key, nil
+	}
+
+	if expired || time.Since(v.fetchedAt) > jwksRefreshInterval {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+		v.fetchedAt = time.Now()
+	}
+
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+
+	return key, nil
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKeySet struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func (v *oidcVerifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	discovery := &oidcDiscovery{}
+	err := v.getJSON(ctx, v.config.Issuer+"/.well-known/openid-configuration", discovery)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %v", err)
+	}
+	if discovery.Issuer != v.config.Issuer {
+		return nil, fmt.Errorf("oidc discovery issuer mismatch: %s", discovery.Issuer)
+	}
+
+	jwks := &jsonWebKeySet{}
+	err = v.getJSON(ctx, discovery.JWKSURI, jwks)
+	if err != nil {
+		return nil, fmt.Errorf("oidc jwks: %v", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("oidc jwks key %s modulus: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("oidc jwks key %s exponent: %v", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+func (v *oidcVerifier) getJSON(ctx context.Context, url string, data interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	resp, err := v.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http: %v", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(data)
+	if err != nil {
+		return fmt.Errorf("decode: %v", err)
+	}
+
+	return nil
+}
+
+// claimContains returns true if the string or string list claim contains value
+func claimContains(claim interface{}, value string) bool {
+	for _, s := range claimStrings(claim) {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// claimStrings returns a string or string list claim as a list
+func claimStrings(claim interface{}) []string {
+	switch c := claim.(type) {
+	case string:
+		return []string{c}
+	case []interface{}:
+		values := []string{}
+		for _, v := range c {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return []string{}
+}
@@ -0,0 +1,243 @@
+package services_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fakeIssuer serves the oidc discovery document and the jwks of its keys
+type fakeIssuer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PrivateKey
+	jwksCalls int
+	t         *testing.T
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	issuer := &fakeIssuer{
+		keys: map[string]*rsa.PrivateKey{},
+		t:    t,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer.URL,
+			"jwks_uri": issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+		issuer.jwksCalls++
+
+		keys := []map[string]string{}
+		for kid, key := range issuer.keys {
+			keys = append(keys, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+
+	return issuer
+}
+
+// addKey generates a signing key published in the jwks
+func (issuer *fakeIssuer) addKey(kid string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		issuer.t.Fatal(err)
+	}
+
+	issuer.mu.Lock()
+	defer issuer.mu.Unlock()
+	issuer.keys[kid] = key
+
+	return key
+}
+
+func (issuer *fakeIssuer) calls() int {
+	issuer.mu.Lock()
+	defer issuer.mu.Unlock()
+	return issuer.jwksCalls
+}
+
+// claims returns valid id token claims of subject
+func (issuer *fakeIssuer) claims(subject string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": issuer.URL,
+		"aud": "kxc",
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func (issuer *fakeIssuer) sign(kid string, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	tokenStr, err := token.SignedString(key)
+	if err != nil {
+		issuer.t.Fatal(err)
+	}
+
+	return tokenStr
+}
+
+type OIDCTestSuite struct {
+	suite.Suite
+	issuer *fakeIssuer
+	key    *rsa.PrivateKey
+}
+
+func TestOIDCTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCTestSuite))
+}
+
+func (suite *OIDCTestSuite) SetupTest() {
+	suite.issuer = newFakeIssuer(suite.T())
+	suite.key = suite.issuer.addKey("key-1")
+
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Setenv("OIDC_ISSUER", suite.issuer.URL)
+	os.Setenv("OIDC_CLIENT_ID", "kxc")
+}
+
+func (suite *OIDCTestSuite) TearDownTest() {
+	for _, key := range []string{"JWT_SECRET", "OIDC_ISSUER", "OIDC_CLIENT_ID", "OIDC_USERNAME_CLAIM", "OIDC_ADMIN_GROUPS"} {
+		os.Unsetenv(key)
+	}
+}
+
+func (suite *OIDCTestSuite) Test_LoginOIDC_Invalid() {
+	ctx := context.Background()
+
+	k8sSvc, _ := testsupport.FakeK8sSvc()
+	userSvc := services.NewUserService(k8sSvc)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	suite.NoError(err)
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", otherKey, suite.issuer.claims("jdoe")))
+	suite.EqualError(err, "id token invalid: crypto/rsa: verification error")
+
+	claims := suite.issuer.claims("jdoe")
+	claims["iss"] = "https://issuer.example.com"
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, claims))
+	suite.EqualError(err, "id token issuer invalid: https://issuer.example.com")
+
+	claims = suite.issuer.claims("jdoe")
+	claims["aud"] = []string{"other-client"}
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, claims))
+	suite.EqualError(err, "id token audience invalid")
+
+	claims = suite.issuer.claims("jdoe")
+	claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, claims))
+	suite.EqualError(err, "id token invalid: Token is expired")
+
+	os.Setenv("OIDC_USERNAME_CLAIM", "email")
+	userSvc = services.NewUserService(k8sSvc)
+
+	claims = suite.issuer.claims("jdoe")
+	claims["email"] = "jdoe@example.com"
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, claims))
+	suite.EqualError(err, "id token email not verified")
+}
+
+func (suite *OIDCTestSuite) Test_LoginOIDC_UnknownKey() {
+	ctx := context.Background()
+
+	k8sSvc, _ := testsupport.FakeK8sSvc()
+	userSvc := services.NewUserService(k8sSvc)
+
+	_, err := userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, suite.issuer.claims("jdoe")))
+	suite.NoError(err)
+	suite.Equal(1, suite.issuer.calls())
+
+	// the jwks aren't refetched for each unknown key
+	key := suite.issuer.addKey("key-2")
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-2", key, suite.issuer.claims("jdoe")))
+	suite.EqualError(err, "id token invalid: unknown signing key: key-2")
+	suite.Equal(1, suite.issuer.calls())
+
+	// but are once the refresh interval elapsed, picking up rotated keys
+	defer services.SetJWKSRefreshInterval(0)()
+
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-2", key, suite.issuer.claims("jdoe")))
+	suite.NoError(err)
+	suite.Equal(2, suite.issuer.calls())
+}
+
+func (suite *OIDCTestSuite) Test_LoginOIDC_AdminGroups() {
+	ctx := context.Background()
+	os.Setenv("OIDC_ADMIN_GROUPS", "kxc-admins")
+
+	k8sSvc, cl := testsupport.FakeK8sSvc()
+	userSvc := services.NewUserService(k8sSvc)
+
+	claims := suite.issuer.claims("jdoe")
+	claims["groups"] = []string{"dev", "kxc-admins"}
+	respData, err := userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, claims))
+	suite.NoError(err)
+	suite.NotEmpty(respData.Token)
+
+	user := &cloudv1alpha1.UserAccount{}
+	suite.NoError(cl.Get(ctx, types.NamespacedName{Name: "jdoe"}, user))
+	suite.Equal(services.UserProviderOIDC, user.Spec.Provider)
+	suite.Equal("jdoe", user.Spec.Subject)
+	suite.Equal(services.UserRoleAdmin, user.Spec.Role)
+
+	// roles follow the groups on later logins
+	claims = suite.issuer.claims("jdoe")
+	claims["groups"] = []string{"dev"}
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, claims))
+	suite.NoError(err)
+
+	suite.NoError(cl.Get(ctx, types.NamespacedName{Name: "jdoe"}, user))
+	suite.Equal(services.UserRoleRegular, user.Spec.Role)
+}
+
+func (suite *OIDCTestSuite) Test_LoginOIDC_SubjectChanged() {
+	ctx := context.Background()
+	os.Setenv("OIDC_USERNAME_CLAIM", "preferred_username")
+
+	k8sSvc, _ := testsupport.FakeK8sSvc()
+	userSvc := services.NewUserService(k8sSvc)
+
+	claims := suite.issuer.claims("subject-1")
+	claims["preferred_username"] = "jdoe"
+	_, err := userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, claims))
+	suite.NoError(err)
+
+	// another identity taking the same user name doesn't get the account
+	claims = suite.issuer.claims("subject-2")
+	claims["preferred_username"] = "JDoe"
+	_, err = userSvc.LoginOIDC(ctx, suite.issuer.sign("key-1", suite.key, claims))
+	suite.EqualError(err, "user jdoe logs in with another oidc identity")
+}
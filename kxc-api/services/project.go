@@ -7,11 +7,11 @@ import (
 
 	"github.com/didil/kubexcloud/kxc-api/requests"
 	"github.com/didil/kubexcloud/kxc-api/responses"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
 	"github.com/didil/kubexcloud/kxc-operator/controllers"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	validationutils "k8s.io/apimachinery/pkg/util/validation"
@@ -21,6 +21,23 @@ type ProjectSvc interface {
 	Create(ctx context.Context, userName string, reqData *requests.CreateProject) error
 	Get(ctx context.Context, userName, projectName string) (*responses.Project, error)
 	List(ctx context.Context, userName string) (*responses.ListProject, error)
+	Delete(ctx context.Context, userName, projectName string) error
+	SetQuota(ctx context.Context, projectName string, reqData *requests.SetProjectQuota) error
+	AddMember(ctx context.Context, projectName string, reqData *requests.AddProjectMember) error
+	UpdateMember(ctx context.Context, projectName, memberName string, reqData *requests.UpdateProjectMember) error
+	RemoveMember(ctx context.Context, projectName, memberName string) error
+}
+
+// project roles, from most to least privileged
+const (
+	ProjectRoleOwner     string = string(cloudv1alpha1.ProjectRoleOwner)
+	ProjectRoleDeveloper string = string(cloudv1alpha1.ProjectRoleDeveloper)
+	ProjectRoleViewer    string = string(cloudv1alpha1.ProjectRoleViewer)
+)
+
+// ProjectRoleAllows returns true if role grants at least the permissions of minRole
+func ProjectRoleAllows(role, minRole string) bool {
+	return controllers.ProjectRoleAllows(cloudv1alpha1.ProjectRole(role), cloudv1alpha1.ProjectRole(minRole))
 }
 
 type ProjectService struct {
@@ -58,6 +75,11 @@ func (svc *ProjectService) Create(ctx context.Context, userName string, reqData
 			Name:   reqData.Name,
 			Labels: controllers.LabelsForProject(userName),
 		},
+		Spec: cloudv1alpha1.ProjectSpec{
+			Members: []cloudv1alpha1.ProjectMember{
+				cloudv1alpha1.ProjectMember{UserName: userName, Role: cloudv1alpha1.ProjectRoleOwner},
+			},
+		},
 	}
 
 	err = client.Get(ctx, types.NamespacedName{Name: proj.Name}, &cloudv1alpha1.Project{})
@@ -83,18 +105,165 @@ func (svc *ProjectService) Get(ctx context.Context, userName, projectName string
 		return nil, nil
 	}
 
-	if controllers.ProjectUserName(proj) != userName {
+	role := controllers.ProjectMemberRole(proj, userName)
+	if role == "" {
 		// project doesn't exist for this user
 		return nil, nil
 	}
 
 	respData := &responses.Project{
-		Name: proj.Name,
+		Name:               proj.Name,
+		Role:               string(role),
+		Members:            listProjectMembers(proj),
+		Quota:              projectQuotaResponse(proj.Spec.Quota),
+		Usage:              projectUsageResponse(proj.Status.Usage),
+		ObservedGeneration: proj.Status.ObservedGeneration,
+		Conditions:         listConditions(proj.Status.Conditions),
 	}
 
 	return respData, nil
 }
 
+func (svc *ProjectService) Delete(ctx context.Context, userName, projectName string) error {
+	client := svc.k8sSvc.Client()
+
+	proj, err := svc.find(ctx, projectName)
+	if err != nil {
+		return err
+	}
+	role := controllers.ProjectMemberRole(proj, userName)
+	if role == "" {
+		return fmt.Errorf("project not found: %s", projectName)
+	}
+	if role != cloudv1alpha1.ProjectRoleOwner {
+		return fmt.Errorf("only project owners can delete a project")
+	}
+
+	// the operator deletes the project apps and namespace before releasing the project
+	err = client.Delete(ctx, proj)
+	if err != nil {
+		return fmt.Errorf("delete project: %v", err)
+	}
+	return nil
+}
+
+func (svc *ProjectService) validateQuota(reqData *requests.SetProjectQuota) error {
+	quantities := map[string]string{"cpu": reqData.CPU, "memory": reqData.Memory, "storage": reqData.Storage}
+	for name, value := range quantities {
+		if value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if reqData.Pods < 0 {
+		return fmt.Errorf("pods: can't be negative")
+	}
+	if reqData.Apps < 0 {
+		return fmt.Errorf("apps: can't be negative")
+	}
+
+	if reqData.DefaultRequests != nil {
+		if _, err := parseResourceList(reqData.DefaultRequests); err != nil {
+			return fmt.Errorf("default requests: %v", err)
+		}
+	}
+	if reqData.DefaultLimits != nil {
+		if _, err := parseResourceList(reqData.DefaultLimits); err != nil {
+			return fmt.Errorf("default limits: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (svc *ProjectService) SetQuota(ctx context.Context, projectName string, reqData *requests.SetProjectQuota) error {
+	client := svc.k8sSvc.Client()
+
+	err := svc.validateQuota(reqData)
+	if err != nil {
+		return fmt.Errorf("quota invalid: %v", err)
+	}
+
+	proj, err := svc.find(ctx, projectName)
+	if err != nil {
+		return err
+	}
+	if proj == nil {
+		return fmt.Errorf("project not found: %s", projectName)
+	}
+
+	proj.Spec.Quota = buildProjectQuota(reqData)
+
+	err = client.Update(ctx, proj)
+	if err != nil {
+		return fmt.Errorf("set project quota: %v", err)
+	}
+	return nil
+}
+
+// buildProjectQuota converts a quota request to a project quota, nil when neither limits nor defaults are set
+func buildProjectQuota(reqData *requests.SetProjectQuota) *cloudv1alpha1.ProjectQuota {
+	if reqData.CPU == "" && reqData.Memory == "" && reqData.Storage == "" && reqData.Pods == 0 && reqData.Apps == 0 &&
+		reqData.DefaultRequests == nil && reqData.DefaultLimits == nil {
+		return nil
+	}
+
+	quota := &cloudv1alpha1.ProjectQuota{
+		CPU:     reqData.CPU,
+		Memory:  reqData.Memory,
+		Pods:    reqData.Pods,
+		Storage: reqData.Storage,
+		Apps:    reqData.Apps,
+	}
+	if reqData.DefaultRequests != nil {
+		quota.DefaultRequests = &cloudv1alpha1.ResourceList{CPU: reqData.DefaultRequests.CPU, Memory: reqData.DefaultRequests.Memory}
+	}
+	if reqData.DefaultLimits != nil {
+		quota.DefaultLimits = &cloudv1alpha1.ResourceList{CPU: reqData.DefaultLimits.CPU, Memory: reqData.DefaultLimits.Memory}
+	}
+
+	return quota
+}
+
+func projectQuotaResponse(quota *cloudv1alpha1.ProjectQuota) *responses.ProjectQuota {
+	if quota == nil {
+		return nil
+	}
+
+	quotaResp := &responses.ProjectQuota{
+		CPU:     quota.CPU,
+		Memory:  quota.Memory,
+		Pods:    quota.Pods,
+		Storage: quota.Storage,
+		Apps:    quota.Apps,
+	}
+	if quota.DefaultRequests != nil {
+		quotaResp.DefaultRequests = &requests.ResourceList{CPU: quota.DefaultRequests.CPU, Memory: quota.DefaultRequests.Memory}
+	}
+	if quota.DefaultLimits != nil {
+		quotaResp.DefaultLimits = &requests.ResourceList{CPU: quota.DefaultLimits.CPU, Memory: quota.DefaultLimits.Memory}
+	}
+
+	return quotaResp
+}
+
+func projectUsageResponse(usage *cloudv1alpha1.ProjectUsage) *responses.ProjectUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &responses.ProjectUsage{
+		CPU:     usage.CPU,
+		Memory:  usage.Memory,
+		Pods:    usage.Pods,
+		Storage: usage.Storage,
+		Apps:    usage.Apps,
+	}
+}
+
 func (svc *ProjectService) find(ctx context.Context, projectName string) (*cloudv1alpha1.Project, error) {
 	client := svc.k8sSvc.Client()
 
@@ -113,11 +282,9 @@ func (svc *ProjectService) find(ctx context.Context, projectName string) (*cloud
 func (svc *ProjectService) List(ctx context.Context, userName string) (*responses.ListProject, error) {
 	cl := svc.k8sSvc.Client()
 
+	// membership isn't indexed, filter all projects
 	projectList := &cloudv1alpha1.ProjectList{}
-	listOpts := []client.ListOption{
-		client.MatchingLabels(controllers.LabelsForProject(userName)),
-	}
-	if err := cl.List(ctx, projectList, listOpts...); err != nil {
+	if err := cl.List(ctx, projectList); err != nil {
 		return nil, fmt.Errorf("failed to list projects: %v", err)
 	}
 
@@ -125,11 +292,190 @@ func (svc *ProjectService) List(ctx context.Context, userName string) (*response
 		Projects: []responses.ListProjectEntry{},
 	}
 
-	for _, proj := range projectList.Items {
-		respData.Projects = append(respData.Projects, responses.ListProjectEntry{
-			Name: proj.Name,
-		})
+	for i := range projectList.Items {
+		proj := &projectList.Items[i]
+
+		role := controllers.ProjectMemberRole(proj, userName)
+		if role == "" {
+			continue
+		}
+
+		respData.Projects = append(respData.Projects, listProjectEntry(proj, role))
 	}
 
 	return respData, nil
 }
+
+func listProjectEntry(proj *cloudv1alpha1.Project, role cloudv1alpha1.ProjectRole) responses.ListProjectEntry {
+	return responses.ListProjectEntry{
+		Name:               proj.Name,
+		Role:               string(role),
+		Members:            listProjectMembers(proj),
+		Quota:              projectQuotaResponse(proj.Spec.Quota),
+		Usage:              projectUsageResponse(proj.Status.Usage),
+		ObservedGeneration: proj.Status.ObservedGeneration,
+		Conditions:         listConditions(proj.Status.Conditions),
+	}
+}
+
+// listProjectMembers returns the project members, including the implicit owner of projects without members
+func listProjectMembers(proj *cloudv1alpha1.Project) []responses.ProjectMember {
+	members := []responses.ProjectMember{}
+
+	if len(proj.Spec.Members) == 0 {
+		if userName := controllers.ProjectUserName(proj); userName != "" {
+			members = append(members, responses.ProjectMember{UserName: userName, Role: ProjectRoleOwner})
+		}
+		return members
+	}
+
+	for _, m := range proj.Spec.Members {
+		members = append(members, responses.ProjectMember{UserName: m.UserName, Role: string(m.Role)})
+	}
+
+	return members
+}
+
+func (svc *ProjectService) validateMemberRole(role string) error {
+	if role != ProjectRoleOwner && role != ProjectRoleDeveloper && role != ProjectRoleViewer {
+		return fmt.Errorf("unknown role: %s", role)
+	}
+
+	return nil
+}
+
+// findWithMembers returns the project with the implicit owner turned into an explicit member
+func (svc *ProjectService) findWithMembers(ctx context.Context, projectName string) (*cloudv1alpha1.Project, error) {
+	proj, err := svc.find(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	if proj == nil {
+		return nil, fmt.Errorf("project not found: %s", projectName)
+	}
+
+	if len(proj.Spec.Members) == 0 {
+		if userName := controllers.ProjectUserName(proj); userName != "" {
+			proj.Spec.Members = []cloudv1alpha1.ProjectMember{
+				cloudv1alpha1.ProjectMember{UserName: userName, Role: cloudv1alpha1.ProjectRoleOwner},
+			}
+		}
+	}
+
+	return proj, nil
+}
+
+// countOwners returns the number of owners of the project
+func countOwners(members []cloudv1alpha1.ProjectMember) int {
+	owners := 0
+	for _, m := range members {
+		if m.Role == cloudv1alpha1.ProjectRoleOwner {
+			owners++
+		}
+	}
+	return owners
+}
+
+func (svc *ProjectService) AddMember(ctx context.Context, projectName string, reqData *requests.AddProjectMember) error {
+	client := svc.k8sSvc.Client()
+
+	err := svc.validateMemberRole(reqData.Role)
+	if err != nil {
+		return fmt.Errorf("member invalid: %v", err)
+	}
+
+	// check if the user exists
+	err = client.Get(ctx, types.NamespacedName{Name: reqData.UserName}, &cloudv1alpha1.UserAccount{})
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("user not found: %s", reqData.UserName)
+	}
+	if err != nil {
+		return fmt.Errorf("get user: %v", err)
+	}
+
+	proj, err := svc.findWithMembers(ctx, projectName)
+	if err != nil {
+		return err
+	}
+
+	if controllers.ProjectMemberRole(proj, reqData.UserName) != "" {
+		return fmt.Errorf("user is already a member: %s", reqData.UserName)
+	}
+
+	proj.Spec.Members = append(proj.Spec.Members, cloudv1alpha1.ProjectMember{
+		UserName: reqData.UserName,
+		Role:     cloudv1alpha1.ProjectRole(reqData.Role),
+	})
+
+	err = client.Update(ctx, proj)
+	if err != nil {
+		return fmt.Errorf("add project member: %v", err)
+	}
+	return nil
+}
+
+func (svc *ProjectService) UpdateMember(ctx context.Context, projectName, memberName string, reqData *requests.UpdateProjectMember) error {
+	client := svc.k8sSvc.Client()
+
+	err := svc.validateMemberRole(reqData.Role)
+	if err != nil {
+		return fmt.Errorf("member invalid: %v", err)
+	}
+
+	proj, err := svc.findWithMembers(ctx, projectName)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, m := range proj.Spec.Members {
+		if m.UserName == memberName {
+			proj.Spec.Members[i].Role = cloudv1alpha1.ProjectRole(reqData.Role)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("member not found: %s", memberName)
+	}
+
+	if countOwners(proj.Spec.Members) == 0 {
+		return fmt.Errorf("a project needs at least one owner")
+	}
+
+	err = client.Update(ctx, proj)
+	if err != nil {
+		return fmt.Errorf("update project member: %v", err)
+	}
+	return nil
+}
+
+func (svc *ProjectService) RemoveMember(ctx context.Context, projectName, memberName string) error {
+	client := svc.k8sSvc.Client()
+
+	proj, err := svc.findWithMembers(ctx, projectName)
+	if err != nil {
+		return err
+	}
+
+	members := []cloudv1alpha1.ProjectMember{}
+	for _, m := range proj.Spec.Members {
+		if m.UserName != memberName {
+			members = append(members, m)
+		}
+	}
+	if len(members) == len(proj.Spec.Members) {
+		return fmt.Errorf("member not found: %s", memberName)
+	}
+
+	if countOwners(members) == 0 {
+		return fmt.Errorf("a project needs at least one owner")
+	}
+
+	proj.Spec.Members = members
+
+	err = client.Update(ctx, proj)
+	if err != nil {
+		return fmt.Errorf("remove project member: %v", err)
+	}
+	return nil
+}
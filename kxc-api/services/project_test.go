@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ProjectServiceTestSuite struct {
+	suite.Suite
+}
+
+func TestProjectServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ProjectServiceTestSuite))
+}
+
+func newProject(projectName string, members ...cloudv1alpha1.ProjectMember) *cloudv1alpha1.Project {
+	return &cloudv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectName,
+		},
+		Spec: cloudv1alpha1.ProjectSpec{
+			Members: members,
+		},
+	}
+}
+
+func (suite *ProjectServiceTestSuite) Test_SetQuota_DefaultsOnly() {
+	proj := newProject("project-a", cloudv1alpha1.ProjectMember{UserName: "user-a", Role: cloudv1alpha1.ProjectRoleOwner})
+
+	k8sSvc, _ := testsupport.FakeK8sSvc(proj)
+	projectSvc := services.NewProjectService(k8sSvc)
+
+	reqData := &requests.SetProjectQuota{
+		DefaultRequests: &requests.ResourceList{CPU: "50m", Memory: "64Mi"},
+		DefaultLimits:   &requests.ResourceList{CPU: "200m", Memory: "256Mi"},
+	}
+	err := projectSvc.SetQuota(context.Background(), "project-a", reqData)
+	suite.NoError(err)
+
+	respData, err := projectSvc.Get(context.Background(), "user-a", "project-a")
+	suite.NoError(err)
+	suite.Require().NotNil(respData.Quota)
+	suite.Equal(reqData.DefaultRequests, respData.Quota.DefaultRequests)
+	suite.Equal(reqData.DefaultLimits, respData.Quota.DefaultLimits)
+
+	// an empty quota still removes everything
+	err = projectSvc.SetQuota(context.Background(), "project-a", &requests.SetProjectQuota{})
+	suite.NoError(err)
+
+	respData, err = projectSvc.Get(context.Background(), "user-a", "project-a")
+	suite.NoError(err)
+	suite.Nil(respData.Quota)
+}
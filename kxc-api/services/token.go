@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/didil/kubexcloud/kxc-operator/controllers"
+)
+
+// TokenSvc interface
+type TokenSvc interface {
+	Create(ctx context.Context, userName string, reqData *requests.CreateToken) (*responses.CreateToken, error)
+	List(ctx context.Context, userName string) (*responses.ListToken, error)
+	Revoke(ctx context.Context, userName, tokenID string) error
+	Authenticate(ctx context.Context, token string) (*AccessToken, error)
+}
+
+type TokenService struct {
+	k8sSvc K8sSvc
+}
+
+// NewTokenService builds a new token service
+func NewTokenService(k8sSvc K8sSvc) *TokenService {
+	return &TokenService{
+		k8sSvc: k8sSvc,
+	}
+}
+
+// AccessToken is an authenticated personal access token
+type AccessToken struct {
+	ID       string
+	UserName string
+	// read only tokens can only be used for GET requests
+	ReadOnly bool
+	// projects the token is limited to, all the user projects if empty
+	Projects []string
+}
+
+// AllowsProject returns true if the token can be used to access the project
+func (t *AccessToken) AllowsProject(projectName string) bool {
+	if len(t.Projects) == 0 {
+		return true
+	}
+
+	for _, p := range t.Projects {
+		if p == projectName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// personal access tokens look like kxc_<id>_<secret>, only a hash of the secret is stored
+const accessTokenPrefix = "kxc_"
+
+// IsAccessToken returns true if token looks like a personal access token rather than a jwt
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, accessTokenPrefix)
+}
+
+const (
+	tokenIDBytes     = 6
+	tokenSecretBytes = 24
+
+	tokenLabelKey = "kxc_token"
+	// user name of the token owner
+	tokenOwnerLabelKey = "kxc_token_owner"
+
+	tokenHashSecretKey      = "tokenHash"
+	tokenNameSecretKey      = "name"
+	tokenReadOnlySecretKey  = "readOnly"
+	tokenProjectsSecretKey  = "projects"
+	tokenExpiresAtSecretKey = "expiresAt"
+)
+
+func tokenSecretName(tokenID string) string {
+	return "kxc-token-" + tokenID
+}
+
+func labelsForToken(userName string) map[string]string {
+	return map[string]string{"app": "kxc", tokenLabelKey: "true", tokenOwnerLabelKey: userName}
+}
+
+// tokenOwner returns the user name of the token owner
+func tokenOwner(secret *corev1.Secret) string {
+	return secret.Labels[tokenOwnerLabelKey]
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("read random: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (svc *TokenService) Create(ctx context.Context, userName string, reqData *requests.CreateToken) (*responses.CreateToken, error) {
+	cl := svc.k8sSvc.Client()
+
+	if reqData.Name == "" {
+		return nil, fmt.Errorf("token name required")
+	}
+	if reqData.ExpiresInDays < 0 {
+		return nil, fmt.Errorf("expiry should be a positive number of days")
+	}
+
+	user := &cloudv1alpha1.UserAccount{}
+	err := cl.Get(ctx, types.NamespacedName{Name: userName}, user)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %v", err)
+	}
+
+	tokenID, err := randomHex(tokenIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	tokenSecret, err := randomHex(tokenSecretBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string][]byte{
+		tokenHashSecretKey: []byte(hashToken(tokenSecret)),
+		tokenNameSecretKey: []byte(reqData.Name),
+	}
+	if reqData.ReadOnly {
+		data[tokenReadOnlySecretKey] = []byte("true")
+	}
+	if len(reqData.Projects) > 0 {
+		data[tokenProjectsSecretKey] = []byte(strings.Join(reqData.Projects, ","))
+	}
+	var expiresAt *time.Time
+	if reqData.ExpiresInDays > 0 {
+		t := time.Now().Add(time.Duration(reqData.ExpiresInDays) * 24 * time.Hour).UTC()
+		expiresAt = &t
+		data[tokenExpiresAtSecretKey] = []byte(t.Format(time.RFC3339))
+	}
+
+	err = controllers.EnsureSystemNamespace(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tokenSecretName(tokenID),
+			Namespace: controllers.SystemNamespaceName(),
+			Labels:    labelsForToken(userName),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	// tokens are deleted with their user
+	err = ctrl.SetControllerReference(user, secret, svc.k8sSvc.Scheme())
+	if err != nil {
+		return nil, fmt.Errorf("set token owner: %v", err)
+	}
+
+	err = cl.Create(ctx, secret)
+	if err != nil {
+		return nil, fmt.Errorf("create token: %v", err)
+	}
+
+	respData := &responses.CreateToken{
+		ID:        tokenID,
+		Name:      reqData.Name,
+		Token:     accessTokenPrefix + tokenID + "_" + tokenSecret,
+		ExpiresAt: expiresAt,
+	}
+
+	return respData, nil
+}
+
+func (svc *TokenService) List(ctx context.Context, userName string) (*responses.ListToken, error) {
+	cl := svc.k8sSvc.Client()
+
+	secretList := &corev1.SecretList{}
+	listOpts := []client.ListOption{
+		client.InNamespace(controllers.SystemNamespaceName()),
+		client.MatchingLabels(labelsForToken(userName)),
+	}
+	if err := cl.List(ctx, secretList, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %v", err)
+	}
+
+	respData := &responses.ListToken{
+		Tokens: []responses.ListTokenEntry{},
+	}
+
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+
+		token := accessTokenFromSecret(secret)
+		respData.Tokens = append(respData.Tokens, responses.ListTokenEntry{
+			ID:        token.ID,
+			Name:      string(secret.Data[tokenNameSecretKey]),
+			ReadOnly:  token.ReadOnly,
+			Projects:  token.Projects,
+			CreatedAt: secret.CreationTimestamp.Time,
+			ExpiresAt: tokenExpiresAt(secret),
+		})
+	}
+
+	sort.Slice(respData.Tokens, func(i, j int) bool {
+		return respData.Tokens[i].CreatedAt.Before(respData.Tokens[j].CreatedAt)
+	})
+
+	return respData, nil
+}
+
+func (svc *TokenService) Revoke(ctx context.Context, userName, tokenID string) error {
+	cl := svc.k8sSvc.Client()
+
+	secret, err := svc.find(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if secret == nil || tokenOwner(secret) != userName {
+		return fmt.Errorf("token not found: %s", tokenID)
+	}
+
+	err = cl.Delete(ctx, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("revoke token: %v", err)
+	}
+
+	return nil
+}
+
+func (svc *TokenService) Authenticate(ctx context.Context, token string) (*AccessToken, error) {
+	fields := strings.Split(strings.TrimPrefix(token, accessTokenPrefix), "_")
+	if !IsAccessToken(token) || len(fields) != 2 {
+		return nil, fmt.Errorf("token invalid")
+	}
+	tokenID, tokenSecret := fields[0], fields[1]
+
+	secret, err := svc.find(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("token invalid")
+	}
+
+	if subtle.ConstantTimeCompare(secret.Data[tokenHashSecretKey], []byte(hashToken(tokenSecret))) != 1 {
+		return nil, fmt.Errorf("token invalid")
+	}
+
+	expiresAt := tokenExpiresAt(secret)
+	if expiresAt != nil && time.Now().After(*expiresAt) {
+		return nil, fmt.Errorf("token expired")
+	}
+
+	// the tokens of deleted users are only garbage collected eventually, so the owner is checked here.
+	// Logouts don't revoke tokens, they are revoked one by one or expire.
+	// Login lockouts don't apply either, so that failed password logins by anyone can't take down the user's automations
+	cl := svc.k8sSvc.Client()
+	user := &cloudv1alpha1.UserAccount{}
+	err = cl.Get(ctx, types.NamespacedName{Name: tokenOwner(secret)}, user)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("token invalid")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user: %v", err)
+	}
+
+	// a user deleted then created again doesn't get the tokens of the previous one
+	owner := metav1.GetControllerOf(secret)
+	if owner == nil || owner.UID != user.UID {
+		return nil, fmt.Errorf("token invalid")
+	}
+
+	return accessTokenFromSecret(secret), nil
+}
+
+func (svc *TokenService) find(ctx context.Context, tokenID string) (*corev1.Secret, error) {
+	cl := svc.k8sSvc.Client()
+
+	secret := &corev1.Secret{}
+	err := cl.Get(ctx, types.NamespacedName{Namespace: controllers.SystemNamespaceName(), Name: tokenSecretName(tokenID)}, secret)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find token: %v", err)
+	}
+
+	if secret.Labels[tokenLabelKey] != "true" {
+		return nil, nil
+	}
+
+	return secret, nil
+}
+
+func accessTokenFromSecret(secret *corev1.Secret) *AccessToken {
+	token := &AccessToken{
+		ID:       strings.TrimPrefix(secret.Name, tokenSecretName("")),
+		UserName: tokenOwner(secret),
+		ReadOnly: string(secret.Data[tokenReadOnlySecretKey]) == "true",
+		Projects: []string{},
+	}
+
+	if projects := string(secret.Data[tokenProjectsSecretKey]); projects != "" {
+		token.Projects = strings.Split(projects, ",")
+	}
+
+	return token
+}
+
+func tokenExpiresAt(secret *corev1.Secret) *time.Time {
+	expiresAt, err := time.Parse(time.RFC3339, string(secret.Data[tokenExpiresAtSecretKey]))
+	if err != nil {
+		return nil
+	}
+	return &expiresAt
+}
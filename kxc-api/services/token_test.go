@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type TokenServiceTestSuite struct {
+	suite.Suite
+}
+
+func TestTokenServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenServiceTestSuite))
+}
+
+func newUserAccount(userName string, uid types.UID) *cloudv1alpha1.UserAccount {
+	return &cloudv1alpha1.UserAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: userName,
+			UID:  uid,
+		},
+		Spec: cloudv1alpha1.UserAccountSpec{
+			Role: services.UserRoleRegular,
+		},
+	}
+}
+
+func (suite *TokenServiceTestSuite) Test_Authenticate_Owner() {
+	ctx := context.Background()
+	user := newUserAccount("user-a", "uid-1")
+
+	k8sSvc, cl := testsupport.FakeK8sSvc(user)
+	tokenSvc := services.NewTokenService(k8sSvc)
+
+	created, err := tokenSvc.Create(ctx, "user-a", &requests.CreateToken{Name: "ci"})
+	suite.NoError(err)
+
+	accessToken, err := tokenSvc.Authenticate(ctx, created.Token)
+	suite.NoError(err)
+	suite.Equal("user-a", accessToken.UserName)
+
+	// login lockouts only block password logins
+	lockedUntil := metav1.NewTime(time.Now().Add(time.Hour))
+	user.Status.LockedUntil = &lockedUntil
+	suite.NoError(cl.Update(ctx, user))
+
+	accessToken, err = tokenSvc.Authenticate(ctx, created.Token)
+	suite.NoError(err)
+	suite.Equal("user-a", accessToken.UserName)
+
+	// deleted users can't either, even before their tokens are garbage collected
+	suite.NoError(cl.Delete(ctx, user))
+
+	_, err = tokenSvc.Authenticate(ctx, created.Token)
+	suite.EqualError(err, "token invalid")
+
+	// nor users created again with the same name
+	suite.NoError(cl.Create(ctx, newUserAccount("user-a", "uid-2")))
+
+	_, err = tokenSvc.Authenticate(ctx, created.Token)
+	suite.EqualError(err, "token invalid")
+}
+
+func (suite *TokenServiceTestSuite) Test_ListRevoke_Owner() {
+	ctx := context.Background()
+
+	k8sSvc, _ := testsupport.FakeK8sSvc(newUserAccount("user-a", "uid-1"), newUserAccount("user-b", "uid-2"))
+	tokenSvc := services.NewTokenService(k8sSvc)
+
+	created, err := tokenSvc.Create(ctx, "user-a", &requests.CreateToken{Name: "ci"})
+	suite.NoError(err)
+	_, err = tokenSvc.Create(ctx, "user-b", &requests.CreateToken{Name: "ci"})
+	suite.NoError(err)
+
+	list, err := tokenSvc.List(ctx, "user-a")
+	suite.NoError(err)
+	suite.Len(list.Tokens, 1)
+	suite.Equal(created.ID, list.Tokens[0].ID)
+
+	// users can only revoke their own tokens
+	err = tokenSvc.Revoke(ctx, "user-b", created.ID)
+	suite.EqualError(err, "token not found: "+created.ID)
+
+	suite.NoError(tokenSvc.Revoke(ctx, "user-a", created.ID))
+
+	list, err = tokenSvc.List(ctx, "user-a")
+	suite.NoError(err)
+	suite.Len(list.Tokens, 0)
+}
@@ -3,65 +3,301 @@ package services
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/didil/kubexcloud/kxc-api/requests"
 	"github.com/didil/kubexcloud/kxc-api/responses"
 	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/didil/kubexcloud/kxc-operator/controllers"
 )
 
 // UserSvc interface
 type UserSvc interface {
-	Login(ctx context.Context, userName, password string) (string, error)
+	Login(ctx context.Context, userName, password, clientIP string) (*responses.LoginUser, error)
+	Refresh(ctx context.Context, refreshToken string) (*responses.LoginUser, error)
+	Logout(ctx context.Context, userName string) error
+	ValidateSession(ctx context.Context, userName, userUID string, tokenVersion int64) error
+	OIDCConfig(ctx context.Context) (*responses.OIDCConfig, error)
+	LoginOIDC(ctx context.Context, idToken string) (*responses.LoginUser, error)
 	Create(ctx context.Context, reqData *requests.CreateUser) error
 	HasRole(ctx context.Context, userName, role string) (bool, error)
 	List(ctx context.Context) (*responses.ListUser, error)
+	Delete(ctx context.Context, userName string) error
+	Unlock(ctx context.Context, userName string) error
 }
 
 type UserService struct {
 	k8sSvc K8sSvc
+
+	oidcConfig   *OIDCConfig
+	oidcVerifier *oidcVerifier
+
+	// password authenticators by provider
+	authenticators map[string]Authenticator
+	// provider of the users logging in for the first time, local users are created by admins
+	provisioningProvider string
+
+	loginLimiter *loginLimiter
+
+	dummyHashOnce sync.Once
+	dummyHash     []byte
 }
 
 // NewUserService builds a new user service
 func NewUserService(k8sSvc K8sSvc) *UserService {
-	return &UserService{
-		k8sSvc: k8sSvc,
+	svc := &UserService{
+		k8sSvc:       k8sSvc,
+		oidcConfig:   OIDCConfigFromEnv(),
+		loginLimiter: newLoginLimiter(),
+	}
+
+	if svc.oidcConfig.Enabled() {
+		svc.oidcVerifier = newOIDCVerifier(svc.oidcConfig)
+	}
+
+	svc.RegisterAuthenticator(&localAuthenticator{svc: svc})
+
+	if ldapConfig := LDAPConfigFromEnv(); ldapConfig.Enabled() {
+		svc.RegisterAuthenticator(&ldapAuthenticator{config: ldapConfig, svc: svc})
+		svc.provisioningProvider = UserProviderLDAP
 	}
+
+	return svc
+}
+
+// RegisterAuthenticator sets the password authenticator of its provider
+func (svc *UserService) RegisterAuthenticator(authenticator Authenticator) {
+	if svc.authenticators == nil {
+		svc.authenticators = map[string]Authenticator{}
+	}
+	svc.authenticators[authenticator.Provider()] = authenticator
 }
 
-func (svc *UserService) Login(ctx context.Context, userName, password string) (string, error) {
+// Login checks the user password, failed attempts return ErrInvalidCredentials whatever the cause
+func (svc *UserService) Login(ctx context.Context, userName, password, clientIP string) (*responses.LoginUser, error) {
+	// user names are lowercase, normalized once so that the lookup, limits and lockout all see the same user
+	userName = strings.ToLower(userName)
+
+	if !svc.loginLimiter.Allow(userName, clientIP) {
+		return nil, ErrLoginRateLimited
+	}
+
 	// check if the user exists
 	user, err := svc.find(ctx, userName)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	provider := svc.provisioningProvider
+	if user != nil {
+		provider = userProvider(user)
+	}
+
+	authenticator, ok := svc.authenticators[provider]
+	if !ok || isLocked(user) {
+		// spend the time of a password check so that responses can't be told apart
+		comparePasswords(svc.dummyPasswordHash(), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+
+	authUser, err := authenticator.Authenticate(ctx, user, userName, password)
+	if err == ErrInvalidCredentials && user != nil {
+		// the failure is still reported as invalid credentials, an error would tell that the user exists
+		recordErr := svc.recordFailedLogin(ctx, user.Name)
+		if recordErr != nil {
+			log.Printf("record failed login of %s: %v\n", user.Name, recordErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	user = authUser
+
+	if user.Status.FailedLoginAttempts > 0 || user.Status.LockedUntil != nil {
+		err = svc.resetFailedLogins(ctx, user.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newSession(user.Name, string(user.UID), user.Spec.TokenVersion)
+}
 
+// isLocked returns true if password logins are refused for the user
+func isLocked(user *cloudv1alpha1.UserAccount) bool {
+	return user != nil && user.Status.LockedUntil != nil && time.Now().Before(user.Status.LockedUntil.Time)
+}
+
+// dummyPasswordHash returns a hash compared against on logins that can't succeed
+func (svc *UserService) dummyPasswordHash() []byte {
+	svc.dummyHashOnce.Do(func() {
+		hash, err := hashAndSalt([]byte("kxc-dummy-password"))
+		if err == nil {
+			svc.dummyHash = []byte(hash)
+		}
+	})
+	return svc.dummyHash
+}
+
+// recordFailedLogin counts a failed password login and locks the user after too many
+func (svc *UserService) recordFailedLogin(ctx context.Context, userName string) error {
+	client := svc.k8sSvc.Client()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		user := &cloudv1alpha1.UserAccount{}
+		err := client.Get(ctx, types.NamespacedName{Name: userName}, user)
+		if err != nil {
+			return err
+		}
+
+		user.Status.FailedLoginAttempts++
+		if lockout := loginLockoutDuration(user.Status.FailedLoginAttempts); lockout > 0 {
+			lockedUntil := metav1.NewTime(time.Now().Add(lockout))
+			user.Status.LockedUntil = &lockedUntil
+		}
+
+		return client.Status().Update(ctx, user)
+	})
+	if err != nil {
+		return fmt.Errorf("record failed login: %v", err)
+	}
+
+	return nil
+}
+
+// resetFailedLogins clears the failed logins count and the lockout of the user
+func (svc *UserService) resetFailedLogins(ctx context.Context, userName string) error {
+	client := svc.k8sSvc.Client()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		user := &cloudv1alpha1.UserAccount{}
+		err := client.Get(ctx, types.NamespacedName{Name: userName}, user)
+		if err != nil {
+			return err
+		}
+
+		user.Status.FailedLoginAttempts = 0
+		user.Status.LockedUntil = nil
+
+		return client.Status().Update(ctx, user)
+	})
+	if err != nil {
+		return fmt.Errorf("reset failed logins: %v", err)
+	}
+
+	return nil
+}
+
+// Unlock lets a locked out user log in again
+func (svc *UserService) Unlock(ctx context.Context, userName string) error {
+	user, err := svc.find(ctx, userName)
+	if err != nil {
+		return err
 	}
 	if user == nil {
-		return "", fmt.Errorf("user not found: %s", userName)
+		return fmt.Errorf("user not found: %s", userName)
 	}
 
-	ok, err := comparePasswords([]byte(user.Spec.Password), []byte(password))
+	err = svc.resetFailedLogins(ctx, userName)
 	if err != nil {
-		return "", err
+		return err
 	}
-	if !ok {
-		return "", fmt.Errorf("password invalid")
+
+	svc.loginLimiter.Reset(userName)
+
+	return nil
+}
+
+func (svc *UserService) Refresh(ctx context.Context, refreshToken string) (*responses.LoginUser, error) {
+	userName, userUID, tokenVersion, err := ParseJWT(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, fmt.Errorf("refresh token invalid: %v", err)
 	}
 
-	token, err := SignJWT(userName)
+	err = svc.ValidateSession(ctx, userName, userUID, tokenVersion)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	return newSession(userName, userUID, tokenVersion)
+}
+
+// Logout revokes all the sessions of the user, personal access tokens stay valid until revoked or expired
+func (svc *UserService) Logout(ctx context.Context, userName string) error {
+	cl := svc.k8sSvc.Client()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		user, err := svc.find(ctx, userName)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user not found: %s", userName)
+		}
+
+		user.Spec.TokenVersion++
+
+		return cl.Update(ctx, user)
+	})
+	if err != nil {
+		return fmt.Errorf("revoke user sessions: %v", err)
+	}
+
+	return nil
+}
+
+// ValidateSession checks that the user still exists and the session wasn't revoked,
+// sessions of a deleted user aren't valid for a user created again with the same name
+func (svc *UserService) ValidateSession(ctx context.Context, userName, userUID string, tokenVersion int64) error {
+	user, err := svc.find(ctx, userName)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user not found: %s", userName)
+	}
+
+	if string(user.UID) != userUID || user.Spec.TokenVersion != tokenVersion {
+		return fmt.Errorf("session revoked")
 	}
 
-	return token, nil
+	return nil
+}
+
+// newSession signs a new access and refresh tokens pair
+func newSession(userName, userUID string, tokenVersion int64) (*responses.LoginUser, error) {
+	accessToken, expiresAt, err := SignJWT(userName, userUID, tokenVersion, TokenTypeAccess, AccessTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, _, err := SignJWT(userName, userUID, tokenVersion, TokenTypeRefresh, RefreshTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	respData := &responses.LoginUser{
+		Token:        accessToken,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}
+
+	return respData, nil
 }
 
 const minPasswordLength = 6
@@ -71,6 +307,125 @@ const (
 	UserRoleAdmin   string = "admin"
 )
 
+// identity providers users authenticate with
+const (
+	UserProviderLocal string = "local"
+	UserProviderOIDC  string = "oidc"
+	UserProviderLDAP  string = "ldap"
+)
+
+// userProvider returns the identity provider of the user, local if not set
+func userProvider(user *cloudv1alpha1.UserAccount) string {
+	if user.Spec.Provider == "" {
+		return UserProviderLocal
+	}
+	return user.Spec.Provider
+}
+
+// provisionUser returns the user authenticated by an external provider, creating it on first login.
+// If subject is set, it must match the subject of the first login. The role is only updated on later logins if syncRole is set
+func (svc *UserService) provisionUser(ctx context.Context, userName, subject, provider, role string, syncRole bool) (*cloudv1alpha1.UserAccount, error) {
+	client := svc.k8sSvc.Client()
+
+	if errs := validation.IsDNS1123Subdomain(userName); len(errs) > 0 {
+		return nil, fmt.Errorf("user name invalid: %s: %s", userName, strings.Join(errs, ", "))
+	}
+
+	user, err := svc.find(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		user = &cloudv1alpha1.UserAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: userName,
+			},
+			Spec: cloudv1alpha1.UserAccountSpec{
+				Provider: provider,
+				Subject:  subject,
+				Role:     role,
+			},
+		}
+
+		err = client.Create(ctx, user)
+		if err != nil {
+			return nil, fmt.Errorf("create user: %v", err)
+		}
+		return user, nil
+	}
+
+	// don't let an external identity take over an account from another provider
+	if userProvider(user) != provider {
+		return nil, fmt.Errorf("user %s logs in with %s", userName, userProvider(user))
+	}
+
+	// nor another identity of the provider with the same user name.
+	// Users provisioned before subjects were stored get the subject of their next login
+	if subject != "" && user.Spec.Subject != "" && user.Spec.Subject != subject {
+		return nil, fmt.Errorf("user %s logs in with another %s identity", userName, provider)
+	}
+
+	if (subject != "" && user.Spec.Subject == "") || (syncRole && user.Spec.Role != role) {
+		user.Spec.Subject = subject
+		if syncRole {
+			user.Spec.Role = role
+		}
+
+		err = client.Update(ctx, user)
+		if err != nil {
+			return nil, fmt.Errorf("update user: %v", err)
+		}
+	}
+
+	return user, nil
+}
+
+// oidcUser verifies the id token and returns the matching user
+func (svc *UserService) oidcUser(ctx context.Context, idToken string) (*cloudv1alpha1.UserAccount, error) {
+	if svc.oidcVerifier == nil {
+		return nil, fmt.Errorf("oidc not enabled")
+	}
+
+	identity, err := svc.oidcVerifier.Verify(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	role := svc.oidcConfig.DefaultRole
+	for _, group := range identity.Groups {
+		for _, adminGroup := range svc.oidcConfig.AdminGroups {
+			if group == adminGroup {
+				role = UserRoleAdmin
+			}
+		}
+	}
+
+	return svc.provisionUser(ctx, identity.UserName, identity.Subject, UserProviderOIDC, role, len(svc.oidcConfig.AdminGroups) > 0)
+}
+
+func (svc *UserService) OIDCConfig(ctx context.Context) (*responses.OIDCConfig, error) {
+	if !svc.oidcConfig.Enabled() {
+		return nil, fmt.Errorf("oidc not enabled")
+	}
+
+	respData := &responses.OIDCConfig{
+		Issuer:   svc.oidcConfig.Issuer,
+		ClientID: svc.oidcConfig.ClientID,
+	}
+
+	return respData, nil
+}
+
+func (svc *UserService) LoginOIDC(ctx context.Context, idToken string) (*responses.LoginUser, error) {
+	user, err := svc.oidcUser(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSession(user.Name, string(user.UID), user.Spec.TokenVersion)
+}
+
 func (svc *UserService) Create(ctx context.Context, reqData *requests.CreateUser) error {
 	client := svc.k8sSvc.Client()
 
@@ -97,13 +452,21 @@ func (svc *UserService) Create(ctx context.Context, reqData *requests.CreateUser
 		return err
 	}
 
+	err = controllers.EnsureSystemNamespace(ctx, client)
+	if err != nil {
+		return err
+	}
+
 	user = &cloudv1alpha1.UserAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: reqData.Name,
 		},
 		Spec: cloudv1alpha1.UserAccountSpec{
-			Password: passwordHash,
-			Role:     reqData.Role,
+			PasswordSecretRef: &corev1.SecretReference{
+				Namespace: controllers.SystemNamespaceName(),
+				Name:      controllers.UserAccountSecretName(reqData.Name),
+			},
+			Role: reqData.Role,
 		},
 	}
 
@@ -112,6 +475,124 @@ func (svc *UserService) Create(ctx context.Context, reqData *requests.CreateUser
 		return fmt.Errorf("create user: %v", err)
 	}
 
+	// the secret is owned by the user account so it's created once the user account has a uid
+	secret, err := controllers.UserAccountSecret(user, []byte(passwordHash), svc.k8sSvc.Scheme())
+	if err != nil {
+		return fmt.Errorf("build user secret: %v", err)
+	}
+
+	err = client.Create(ctx, secret)
+	if err != nil {
+		// don't leave a user without credentials behind
+		if delErr := client.Delete(ctx, user); delErr != nil {
+			return fmt.Errorf("create user secret: %v, delete user: %v", err, delErr)
+		}
+		return fmt.Errorf("create user secret: %v", err)
+	}
+
+	return nil
+}
+
+// passwordHash returns the password hash of the user and the secret holding it,
+// the secret is nil for users whose hash is still in the spec
+func (svc *UserService) passwordHash(ctx context.Context, user *cloudv1alpha1.UserAccount) ([]byte, *corev1.Secret, error) {
+	if user.Spec.PasswordSecretRef == nil {
+		return []byte(user.Spec.Password), nil, nil
+	}
+
+	client := svc.k8sSvc.Client()
+
+	secret := &corev1.Secret{}
+	err := client.Get(ctx, types.NamespacedName{Namespace: user.Spec.PasswordSecretRef.Namespace, Name: user.Spec.PasswordSecretRef.Name}, secret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get user secret: %v", err)
+	}
+
+	return secret.Data[controllers.PasswordHashSecretKey], secret, nil
+}
+
+// rehashPassword stores a new hash of the password made with the configured cost
+func (svc *UserService) rehashPassword(ctx context.Context, secret *corev1.Secret, password []byte) error {
+	client := svc.k8sSvc.Client()
+
+	passwordHash, err := hashAndSalt(password)
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[controllers.PasswordHashSecretKey] = []byte(passwordHash)
+
+	err = client.Update(ctx, secret)
+	if err != nil {
+		return fmt.Errorf("update user secret: %v", err)
+	}
+
+	return nil
+}
+
+func (svc *UserService) Delete(ctx context.Context, userName string) error {
+	cl := svc.k8sSvc.Client()
+
+	user, err := svc.find(ctx, userName)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user not found: %s", userName)
+	}
+
+	projectList := &cloudv1alpha1.ProjectList{}
+	err = cl.List(ctx, projectList)
+	if err != nil {
+		return fmt.Errorf("failed to list projects: %v", err)
+	}
+
+	memberOf := []*cloudv1alpha1.Project{}
+	owned := 0
+	for i := range projectList.Items {
+		proj := &projectList.Items[i]
+
+		switch controllers.ProjectMemberRole(proj, userName) {
+		case "":
+		case cloudv1alpha1.ProjectRoleOwner:
+			if countOwners(proj.Spec.Members) > 1 {
+				memberOf = append(memberOf, proj)
+				continue
+			}
+			owned++
+		default:
+			memberOf = append(memberOf, proj)
+		}
+	}
+
+	// projects would be left without an owner
+	if owned > 0 {
+		return fmt.Errorf("user %s still owns %d project(s), delete them first", userName, owned)
+	}
+
+	for _, proj := range memberOf {
+		members := []cloudv1alpha1.ProjectMember{}
+		for _, m := range proj.Spec.Members {
+			if m.UserName != userName {
+				members = append(members, m)
+			}
+		}
+		proj.Spec.Members = members
+
+		err = cl.Update(ctx, proj)
+		if err != nil {
+			return fmt.Errorf("remove user from project %s: %v", proj.Name, err)
+		}
+	}
+
+	err = cl.Delete(ctx, user)
+	if err != nil {
+		return fmt.Errorf("delete user: %v", err)
+	}
+
 	return nil
 }
 
@@ -155,11 +636,20 @@ func (svc *UserService) List(ctx context.Context) (*responses.ListUser, error) {
 		Users: []responses.ListUserEntry{},
 	}
 
-	for _, user := range userList.Items {
-		respData.Users = append(respData.Users, responses.ListUserEntry{
-			Name: user.Name,
-			Role: user.Spec.Role,
-		})
+	for i := range userList.Items {
+		user := &userList.Items[i]
+
+		entry := responses.ListUserEntry{
+			Name:               user.Name,
+			Role:               user.Spec.Role,
+			ObservedGeneration: user.Status.ObservedGeneration,
+			Conditions:         listConditions(user.Status.Conditions),
+		}
+		if isLocked(user) {
+			entry.LockedUntil = &user.Status.LockedUntil.Time
+		}
+
+		respData.Users = append(respData.Users, entry)
 	}
 
 	return respData, nil
@@ -167,9 +657,29 @@ func (svc *UserService) List(ctx context.Context) (*responses.ListUser, error) {
 
 // auth helpers
 
+// bcryptCost returns the cost used to hash passwords, set by BCRYPT_COST
+func bcryptCost() int {
+	cost, err := strconv.Atoi(os.Getenv("BCRYPT_COST"))
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+
+	return cost
+}
+
+// hasBcryptCost returns true if the password hash was made with cost
+func hasBcryptCost(passwordHash []byte, cost int) bool {
+	hashCost, err := bcrypt.Cost(passwordHash)
+	if err != nil {
+		return false
+	}
+
+	return hashCost == cost
+}
+
 // hashAndSalt hashes and salts a password
 func hashAndSalt(pwd []byte) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword(pwd, bcrypt.MinCost)
+	hash, err := bcrypt.GenerateFromPassword(pwd, bcryptCost())
 	if err != nil {
 		return "", err
 	}
@@ -197,9 +707,47 @@ func comparePasswords(passwordHash []byte, plainPwd []byte) (bool, error) {
 	return true, nil
 }
 
+// session token types
+const (
+	TokenTypeAccess  string = "access"
+	TokenTypeRefresh string = "refresh"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// envDuration returns the duration set by the env variable key, or defaultTTL
+func envDuration(key string, defaultTTL time.Duration) time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || ttl <= 0 {
+		return defaultTTL
+	}
+
+	return ttl
+}
+
+// AccessTokenTTL returns the lifetime of access tokens, set by ACCESS_TOKEN_TTL
+func AccessTokenTTL() time.Duration {
+	return envDuration("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL returns the lifetime of refresh tokens, set by REFRESH_TOKEN_TTL
+func RefreshTokenTTL() time.Duration {
+	return envDuration("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+const jwtIssuer = "kxc-api"
+
 // jwt custom claims
 type customClaims struct {
 	UserName string `json:"user"`
+	// uid of the user account, so that sessions don't carry over to a user created again with the same name
+	UserUID string `json:"uid"`
+	// sessions signed with an older version than the user token version are revoked
+	TokenVersion int64  `json:"ver"`
+	TokenType    string `json:"typ"`
 	jwt.StandardClaims
 }
 
@@ -212,35 +760,40 @@ func getJWTSecret() ([]byte, error) {
 	return secret, nil
 }
 
-// SignJWT returns a jwt signed token
-func SignJWT(userName string) (string, error) {
+// SignJWT returns a jwt signed token of tokenType and its expiry
+func SignJWT(userName, userUID string, tokenVersion int64, tokenType string, ttl time.Duration) (string, time.Time, error) {
 	secret, err := getJWTSecret()
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
+	expiresAt := time.Now().Add(ttl)
+
 	claims := customClaims{
-		UserName: userName,
+		UserName:     userName,
+		UserUID:      userUID,
+		TokenVersion: tokenVersion,
+		TokenType:    tokenType,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(60 * 24 * time.Hour).Unix(),
-			Issuer:    "kxc-api",
+			ExpiresAt: expiresAt.Unix(),
+			Issuer:    jwtIssuer,
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenStr, err := token.SignedString(secret)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	return tokenStr, nil
+	return tokenStr, expiresAt, nil
 }
 
-// ParseJWT parses a jwt token
-func ParseJWT(tokenStr string) (string, error) {
+// ParseJWT parses a jwt token of tokenType, returning the user name, user uid and token version
+func ParseJWT(tokenStr, tokenType string) (string, string, int64, error) {
 	secret, err := getJWTSecret()
 	if err != nil {
-		return "", err
+		return "", "", 0, err
 	}
 
 	token, err := jwt.ParseWithClaims(tokenStr, &customClaims{}, func(token *jwt.Token) (interface{}, error) {
@@ -252,13 +805,17 @@ func ParseJWT(tokenStr string) (string, error) {
 	})
 
 	if err != nil {
-		return "", err
+		return "", "", 0, err
 	}
 
 	claims, ok := token.Claims.(*customClaims)
 	if !ok || !token.Valid {
-		return "", fmt.Errorf("jwt token invalid")
+		return "", "", 0, fmt.Errorf("jwt token invalid")
+	}
+
+	if claims.TokenType != tokenType {
+		return "", "", 0, fmt.Errorf("jwt token type invalid")
 	}
 
-	return claims.UserName, nil
+	return claims.UserName, claims.UserUID, claims.TokenVersion, nil
 }
@@ -0,0 +1,59 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserServiceTestSuite struct {
+	suite.Suite
+}
+
+func TestUserServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(UserServiceTestSuite))
+}
+
+func (suite *UserServiceTestSuite) SetupTest() {
+	os.Setenv("JWT_SECRET", "test-secret")
+}
+
+func (suite *UserServiceTestSuite) TearDownTest() {
+	os.Unsetenv("JWT_SECRET")
+}
+
+func (suite *UserServiceTestSuite) Test_Session_UserRecreated() {
+	ctx := context.Background()
+	user := newUserAccount("user-a", "uid-1")
+
+	k8sSvc, cl := testsupport.FakeK8sSvc(user)
+	userSvc := services.NewUserService(k8sSvc)
+
+	refreshToken, _, err := services.SignJWT("user-a", "uid-1", 0, services.TokenTypeRefresh, services.RefreshTokenTTL())
+	suite.NoError(err)
+
+	respData, err := userSvc.Refresh(ctx, refreshToken)
+	suite.NoError(err)
+
+	userName, userUID, tokenVersion, err := services.ParseJWT(respData.Token, services.TokenTypeAccess)
+	suite.NoError(err)
+	suite.Equal("user-a", userName)
+	suite.Equal("uid-1", userUID)
+	suite.NoError(userSvc.ValidateSession(ctx, userName, userUID, tokenVersion))
+
+	// users created again with the same name start over at the same token version
+	suite.NoError(cl.Delete(ctx, user))
+	suite.NoError(cl.Create(ctx, newUserAccount("user-a", "uid-2")))
+
+	suite.EqualError(userSvc.ValidateSession(ctx, userName, userUID, tokenVersion), "session revoked")
+
+	_, err = userSvc.Refresh(ctx, refreshToken)
+	suite.EqualError(err, "session revoked")
+
+	_, err = userSvc.Refresh(ctx, respData.RefreshToken)
+	suite.EqualError(err, "session revoked")
+}
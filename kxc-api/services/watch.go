@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/didil/kubexcloud/kxc-operator/controllers"
+)
+
+// watch event types
+const (
+	WatchEventAdded    = "ADDED"
+	WatchEventModified = "MODIFIED"
+	WatchEventDeleted  = "DELETED"
+)
+
+// watched kinds
+const (
+	WatchKindApp     = "App"
+	WatchKindProject = "Project"
+)
+
+// watchBufferSize is the number of events queued per subscriber, subscribers falling further behind are disconnected
+const watchBufferSize = 100
+
+// WatchSvc interface
+type WatchSvc interface {
+	Watch(ctx context.Context, userName string, reqData *requests.Watch) (<-chan *responses.WatchEvent, error)
+}
+
+// WatchService broadcasts the app and project changes seen by the shared informers to the subscribers
+type WatchService struct {
+	k8sSvc K8sSvc
+
+	mu          sync.Mutex
+	started     bool
+	subscribers map[*watchSubscriber]bool
+}
+
+// NewWatchService builds a new watch service
+func NewWatchService(k8sSvc K8sSvc) *WatchService {
+	return &WatchService{
+		k8sSvc:      k8sSvc,
+		subscribers: map[*watchSubscriber]bool{},
+	}
+}
+
+type watchSubscriber struct {
+	userName string
+	reqData  *requests.Watch
+	events   chan *responses.WatchEvent
+	closed   bool
+	// projects the user is a member of, only their apps are sent
+	projects map[string]bool
+	// last event sent per <kind>/<namespace>/<name>, to skip informer resyncs and replays
+	// and to delete the objects the user can't see anymore
+	sent map[string]*watchSentEvent
+}
+
+type watchSentEvent struct {
+	resourceVersion string
+	event           *responses.WatchEvent
+}
+
+// Watch streams the changes of the apps and projects userName is a member of, starting with their current
+// state as ADDED events. The channel is closed once ctx is done or if the subscriber falls behind
+func (svc *WatchService) Watch(ctx context.Context, userName string, reqData *requests.Watch) (<-chan *responses.WatchEvent, error) {
+	if reqData.App != "" && reqData.Project == "" {
+		return nil, fmt.Errorf("project required to watch an app")
+	}
+
+	sub := &watchSubscriber{
+		userName: userName,
+		reqData:  reqData,
+		projects: map[string]bool{},
+		sent:     map[string]*watchSentEvent{},
+	}
+
+	// the snapshot is queued under the lock so that it precedes the changes broadcast after it
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if !svc.started {
+		return nil, fmt.Errorf("watch not started")
+	}
+
+	snapshot, err := svc.snapshot(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.events = make(chan *responses.WatchEvent, len(snapshot)+watchBufferSize)
+	for _, obj := range snapshot {
+		svc.notify(sub, WatchEventAdded, obj)
+	}
+	svc.subscribers[sub] = true
+
+	go func() {
+		<-ctx.Done()
+		svc.unsubscribe(sub)
+	}()
+
+	return sub.events, nil
+}
+
+// Start creates the informers and registers the broadcast handlers, before the k8s cache is started
+func (svc *WatchService) Start(ctx context.Context) error {
+	for _, obj := range []runtime.Object{&cloudv1alpha1.Project{}, &cloudv1alpha1.App{}} {
+		informer, err := svc.k8sSvc.Cache().GetInformer(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("watch informer: %v", err)
+		}
+
+		informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				svc.broadcast(WatchEventAdded, obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				svc.broadcast(WatchEventModified, newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				svc.broadcast(WatchEventDeleted, obj)
+			},
+		})
+	}
+
+	svc.mu.Lock()
+	svc.started = true
+	svc.mu.Unlock()
+
+	return nil
+}
+
+// snapshot returns the projects and apps currently visible to the subscriber, from the informers cache
+func (svc *WatchService) snapshot(ctx context.Context, sub *watchSubscriber) ([]runtime.Object, error) {
+	cl := svc.k8sSvc.Cache()
+
+	objects := []runtime.Object{}
+
+	projectList := &cloudv1alpha1.ProjectList{}
+	if err := cl.List(ctx, projectList); err != nil {
+		return nil, fmt.Errorf("failed to list projects: %v", err)
+	}
+	for i := range projectList.Items {
+		objects = append(objects, &projectList.Items[i])
+	}
+
+	listOpts := []client.ListOption{}
+	if sub.reqData.Project != "" {
+		listOpts = append(listOpts, client.InNamespace(controllers.ProjectNamespaceName(sub.reqData.Project)))
+	}
+
+	appList := &cloudv1alpha1.AppList{}
+	if err := cl.List(ctx, appList, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list apps: %v", err)
+	}
+	for i := range appList.Items {
+		objects = append(objects, &appList.Items[i])
+	}
+
+	return objects, nil
+}
+
+func (svc *WatchService) broadcast(eventType string, obj interface{}) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for sub := range svc.subscribers {
+		svc.notify(sub, eventType, obj)
+	}
+}
+
+// notify queues the event if the subscriber can see the object, svc.mu must be held
+func (svc *WatchService) notify(sub *watchSubscriber, eventType string, obj interface{}) {
+	switch o := obj.(type) {
+	case *cloudv1alpha1.Project:
+		svc.notifyProject(sub, eventType, o)
+	case *cloudv1alpha1.App:
+		svc.notifyApp(sub, eventType, o)
+	}
+}
+
+// notifyProject tracks the projects visible to the subscriber, the objects of projects the user joins are sent
+// as ADDED events and those of projects the user leaves as DELETED events
+func (svc *WatchService) notifyProject(sub *watchSubscriber, eventType string, proj *cloudv1alpha1.Project) {
+	if sub.reqData.Project != "" && sub.reqData.Project != proj.Name {
+		return
+	}
+
+	var role cloudv1alpha1.ProjectRole
+	if eventType != WatchEventDeleted {
+		role = controllers.ProjectMemberRole(proj, sub.userName)
+	}
+
+	if role == "" {
+		if sub.projects[proj.Name] {
+			svc.revokeProject(sub, proj.Name)
+		}
+		return
+	}
+
+	joined := !sub.projects[proj.Name]
+	sub.projects[proj.Name] = true
+	if joined {
+		eventType = WatchEventAdded
+	}
+
+	// app watches only get app events
+	if sub.reqData.App == "" {
+		entry := listProjectEntry(proj, role)
+		event := &responses.WatchEvent{Type: eventType, Kind: WatchKindProject, Name: proj.Name, Project: proj.Name, ProjectState: &entry}
+		svc.send(sub, WatchKindProject+"/"+proj.Name, proj.ResourceVersion, event)
+	}
+
+	// the snapshot already holds the apps of the projects the user is a member of when subscribing
+	if joined && svc.subscribers[sub] {
+		for _, app := range svc.cachedApps(proj.Name) {
+			svc.notifyApp(sub, WatchEventAdded, app)
+		}
+	}
+}
+
+func (svc *WatchService) notifyApp(sub *watchSubscriber, eventType string, app *cloudv1alpha1.App) {
+	projectName := controllers.AppProjectName(app)
+	if sub.reqData.Project != "" && sub.reqData.Project != projectName {
+		return
+	}
+	if sub.reqData.App != "" && sub.reqData.App != app.Name {
+		return
+	}
+
+	if !sub.projects[projectName] {
+		return
+	}
+
+	entry := listAppEntry(app)
+	event := &responses.WatchEvent{Type: eventType, Kind: WatchKindApp, Name: app.Name, Project: projectName, App: &entry}
+	svc.send(sub, WatchKindApp+"/"+app.Namespace+"/"+app.Name, app.ResourceVersion, event)
+}
+
+// revokeProject sends DELETED events for the project and the apps sent to the subscriber, once the user can't see them anymore
+func (svc *WatchService) revokeProject(sub *watchSubscriber, projectName string) {
+	delete(sub.projects, projectName)
+
+	// apps first, then their project
+	keys := []string{}
+	projectKey := ""
+	for key, sent := range sub.sent {
+		if sent.event.Project != projectName {
+			continue
+		}
+		if sent.event.Kind == WatchKindProject {
+			projectKey = key
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	if projectKey != "" {
+		keys = append(keys, projectKey)
+	}
+
+	for _, key := range keys {
+		event := *sub.sent[key].event
+		event.Type = WatchEventDeleted
+		svc.send(sub, key, "", &event)
+	}
+}
+
+// send queues the event unless the subscriber already has this version of the object, svc.mu must be held
+func (svc *WatchService) send(sub *watchSubscriber, key, resourceVersion string, event *responses.WatchEvent) {
+	if sub.closed {
+		return
+	}
+
+	if event.Type == WatchEventDeleted {
+		// the subscriber never got the object
+		if sub.sent[key] == nil {
+			return
+		}
+		delete(sub.sent, key)
+	} else {
+		if sent := sub.sent[key]; sent != nil && sent.resourceVersion == resourceVersion {
+			return
+		}
+		sub.sent[key] = &watchSentEvent{resourceVersion: resourceVersion, event: event}
+	}
+
+	select {
+	case sub.events <- event:
+	default:
+		// the client can't keep up, it has to reconnect
+		sub.closed = true
+		delete(svc.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// cachedApps returns the apps of the project from the informers cache
+func (svc *WatchService) cachedApps(projectName string) []*cloudv1alpha1.App {
+	appList := &cloudv1alpha1.AppList{}
+	err := svc.k8sSvc.Cache().List(context.Background(), appList, client.InNamespace(controllers.ProjectNamespaceName(projectName)))
+	if err != nil {
+		return nil
+	}
+
+	apps := []*cloudv1alpha1.App{}
+	for i := range appList.Items {
+		apps = append(apps, &appList.Items[i])
+	}
+
+	return apps
+}
+
+func (svc *WatchService) unsubscribe(sub *watchSubscriber) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if svc.subscribers[sub] {
+		delete(svc.subscribers, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,155 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
+	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport"
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeCache reads from a fake client, its informers only record their handlers so that tests can trigger events
+type fakeCache struct {
+	client.Reader
+	informer *fakeInformer
+}
+
+func (c *fakeCache) GetInformer(ctx context.Context, obj runtime.Object) (cache.Informer, error) {
+	return c.informer, nil
+}
+
+func (c *fakeCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	return c.informer, nil
+}
+
+func (c *fakeCache) Start(stop <-chan struct{}) error {
+	<-stop
+	return nil
+}
+
+func (c *fakeCache) WaitForCacheSync(stop <-chan struct{}) bool {
+	return true
+}
+
+func (c *fakeCache) IndexField(ctx context.Context, obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	return nil
+}
+
+type fakeInformer struct {
+	handlers []toolscache.ResourceEventHandler
+}
+
+func (i *fakeInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.handlers = append(i.handlers, handler)
+}
+
+func (i *fakeInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.AddEventHandler(handler)
+}
+
+func (i *fakeInformer) AddIndexers(indexers toolscache.Indexers) error {
+	return nil
+}
+
+func (i *fakeInformer) HasSynced() bool {
+	return true
+}
+
+// update runs the handlers of the first informer only, both informers get the same ones
+func (i *fakeInformer) update(oldObj, newObj interface{}) {
+	i.handlers[0].OnUpdate(oldObj, newObj)
+}
+
+type WatchServiceTestSuite struct {
+	suite.Suite
+}
+
+func TestWatchServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(WatchServiceTestSuite))
+}
+
+func (suite *WatchServiceTestSuite) receive(events <-chan *responses.WatchEvent) *responses.WatchEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		suite.FailNow("no event")
+		return nil
+	}
+}
+
+func (suite *WatchServiceTestSuite) Test_Watch_MemberRemoved() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	proj := newProject("project-a",
+		cloudv1alpha1.ProjectMember{UserName: "user-a", Role: cloudv1alpha1.ProjectRoleOwner},
+		cloudv1alpha1.ProjectMember{UserName: "user-b", Role: cloudv1alpha1.ProjectRoleViewer},
+	)
+	proj.ResourceVersion = "1"
+	app := newApp("project-a", "web")
+	app.ResourceVersion = "1"
+
+	k8sSvc, cl := testsupport.FakeK8sSvc(proj, app)
+	informer := &fakeInformer{}
+	k8sSvc.On("Cache").Return(&fakeCache{Reader: cl, informer: informer})
+
+	watchSvc := services.NewWatchService(k8sSvc)
+
+	_, err := watchSvc.Watch(ctx, "user-b", &requests.Watch{})
+	suite.EqualError(err, "watch not started")
+
+	suite.NoError(watchSvc.Start(ctx))
+
+	events, err := watchSvc.Watch(ctx, "user-b", &requests.Watch{})
+	suite.NoError(err)
+
+	event := suite.receive(events)
+	suite.Equal(services.WatchEventAdded, event.Type)
+	suite.Equal(services.WatchKindProject, event.Kind)
+	event = suite.receive(events)
+	suite.Equal(services.WatchEventAdded, event.Type)
+	suite.Equal(services.WatchKindApp, event.Kind)
+
+	// removing the member deletes the project and its apps from the stream
+	updated := proj.DeepCopy()
+	updated.Spec.Members = updated.Spec.Members[:1]
+	updated.ResourceVersion = "2"
+	informer.update(proj, updated)
+
+	event = suite.receive(events)
+	suite.Equal(services.WatchEventDeleted, event.Type)
+	suite.Equal(services.WatchKindApp, event.Kind)
+	suite.Equal("web", event.Name)
+	event = suite.receive(events)
+	suite.Equal(services.WatchEventDeleted, event.Type)
+	suite.Equal(services.WatchKindProject, event.Kind)
+	suite.Equal("project-a", event.Name)
+
+	// the changes of the project aren't sent anymore
+	updatedApp := app.DeepCopy()
+	updatedApp.ResourceVersion = "2"
+	informer.update(app, updatedApp)
+
+	// adding the member back sends the project and its apps again
+	informer.update(updated, proj)
+
+	event = suite.receive(events)
+	suite.Equal(services.WatchEventAdded, event.Type)
+	suite.Equal(services.WatchKindProject, event.Kind)
+	event = suite.receive(events)
+	suite.Equal(services.WatchEventAdded, event.Type)
+	suite.Equal(services.WatchKindApp, event.Kind)
+
+	suite.Len(events, 0)
+}
@@ -2,8 +2,11 @@ package auth
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/didil/kubexcloud/kxc-api/services"
+	"github.com/didil/kubexcloud/kxc-api/testsupport/mocks"
+	"github.com/stretchr/testify/mock"
 )
 
 // Login is a support function to fake login
@@ -12,10 +15,15 @@ func Login(username string) (string, error) {
 		return "", fmt.Errorf("username empty")
 	}
 
-	token, err := services.SignJWT(username)
+	token, _, err := services.SignJWT(username, "", 0, services.TokenTypeAccess, time.Hour)
 	if err != nil {
 		return "", err
 	}
 
 	return token, nil
 }
+
+// MockSession makes the sessions returned by Login valid for the user service mock
+func MockSession(userSvc *mocks.UserSvc, username string) {
+	userSvc.On("ValidateSession", mock.AnythingOfType("*context.valueCtx"), username, "", int64(0)).Return(nil)
+}
@@ -0,0 +1,32 @@
+package testsupport
+
+import (
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/didil/kubexcloud/kxc-api/testsupport/mocks"
+)
+
+// FakeK8sSvc returns a k8s service mock backed by a fake client holding objs
+func FakeK8sSvc(objs ...runtime.Object) (*mocks.K8sSvc, client.Client) {
+	scheme := runtime.NewScheme()
+	err := clientgoscheme.AddToScheme(scheme)
+	if err != nil {
+		panic(err)
+	}
+	err = cloudv1alpha1.AddToScheme(scheme)
+	if err != nil {
+		panic(err)
+	}
+
+	cl := fake.NewFakeClientWithScheme(scheme, objs...)
+
+	k8sSvc := new(mocks.K8sSvc)
+	k8sSvc.On("Client").Return(cl)
+	k8sSvc.On("Scheme").Return(scheme)
+
+	return k8sSvc, cl
+}
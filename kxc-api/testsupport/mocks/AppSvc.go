@@ -5,10 +5,14 @@ package mocks
 import (
 	context "context"
 
+	io "io"
+
 	requests "github.com/didil/kubexcloud/kxc-api/requests"
 	mock "github.com/stretchr/testify/mock"
 
 	responses "github.com/didil/kubexcloud/kxc-api/responses"
+
+	services "github.com/didil/kubexcloud/kxc-api/services"
 )
 
 // AppSvc is an autogenerated mock type for the AppSvc type
@@ -16,6 +20,20 @@ type AppSvc struct {
 	mock.Mock
 }
 
+// AttachDomain provides a mock function with given fields: ctx, projectName, appName, reqData
+func (_m *AppSvc) AttachDomain(ctx context.Context, projectName string, appName string, reqData *requests.AttachDomain) error {
+	ret := _m.Called(ctx, projectName, appName, reqData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *requests.AttachDomain) error); ok {
+		r0 = rf(ctx, projectName, appName, reqData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: ctx, projectName, reqData
 func (_m *AppSvc) Create(ctx context.Context, projectName string, reqData *requests.CreateApp) error {
 	ret := _m.Called(ctx, projectName, reqData)
@@ -30,6 +48,80 @@ func (_m *AppSvc) Create(ctx context.Context, projectName string, reqData *reque
 	return r0
 }
 
+// Delete provides a mock function with given fields: ctx, projectName, appName
+func (_m *AppSvc) Delete(ctx context.Context, projectName string, appName string) error {
+	ret := _m.Called(ctx, projectName, appName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, projectName, appName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DetachDomain provides a mock function with given fields: ctx, projectName, appName, domain
+func (_m *AppSvc) DetachDomain(ctx context.Context, projectName string, appName string, domain string) error {
+	ret := _m.Called(ctx, projectName, appName, domain)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, projectName, appName, domain)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Exec provides a mock function with given fields: ctx, projectName, appName, reqData
+func (_m *AppSvc) Exec(ctx context.Context, projectName string, appName string, reqData *requests.AppExec) (services.ExecSession, error) {
+	ret := _m.Called(ctx, projectName, appName, reqData)
+
+	var r0 services.ExecSession
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *requests.AppExec) services.ExecSession); ok {
+		r0 = rf(ctx, projectName, appName, reqData)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(services.ExecSession)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *requests.AppExec) error); ok {
+		r1 = rf(ctx, projectName, appName, reqData)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: ctx, projectName, appName
+func (_m *AppSvc) Get(ctx context.Context, projectName string, appName string) (*responses.GetApp, error) {
+	ret := _m.Called(ctx, projectName, appName)
+
+	var r0 *responses.GetApp
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *responses.GetApp); ok {
+		r0 = rf(ctx, projectName, appName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*responses.GetApp)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, projectName, appName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // List provides a mock function with given fields: ctx, projectName
 func (_m *AppSvc) List(ctx context.Context, projectName string) (*responses.ListApp, error) {
 	ret := _m.Called(ctx, projectName)
@@ -53,6 +145,43 @@ func (_m *AppSvc) List(ctx context.Context, projectName string) (*responses.List
 	return r0, r1
 }
 
+// Logs provides a mock function with given fields: ctx, projectName, appName, reqData, w
+func (_m *AppSvc) Logs(ctx context.Context, projectName string, appName string, reqData *requests.AppLogs, w io.Writer) error {
+	ret := _m.Called(ctx, projectName, appName, reqData, w)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *requests.AppLogs, io.Writer) error); ok {
+		r0 = rf(ctx, projectName, appName, reqData, w)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortForward provides a mock function with given fields: ctx, projectName, appName, reqData
+func (_m *AppSvc) PortForward(ctx context.Context, projectName string, appName string, reqData *requests.AppPortForward) (services.PortForwardSession, error) {
+	ret := _m.Called(ctx, projectName, appName, reqData)
+
+	var r0 services.PortForwardSession
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *requests.AppPortForward) services.PortForwardSession); ok {
+		r0 = rf(ctx, projectName, appName, reqData)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(services.PortForwardSession)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *requests.AppPortForward) error); ok {
+		r1 = rf(ctx, projectName, appName, reqData)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Restart provides a mock function with given fields: ctx, projectName, appName
 func (_m *AppSvc) Restart(ctx context.Context, projectName string, appName string) error {
 	ret := _m.Called(ctx, projectName, appName)
@@ -3,8 +3,16 @@
 package mocks
 
 import (
+	cache "sigs.k8s.io/controller-runtime/pkg/cache"
+
 	mock "github.com/stretchr/testify/mock"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubernetes "k8s.io/client-go/kubernetes"
+
+	rest "k8s.io/client-go/rest"
+
+	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // K8sSvc is an autogenerated mock type for the K8sSvc type
@@ -12,6 +20,22 @@ type K8sSvc struct {
 	mock.Mock
 }
 
+// Cache provides a mock function with given fields:
+func (_m *K8sSvc) Cache() cache.Cache {
+	ret := _m.Called()
+
+	var r0 cache.Cache
+	if rf, ok := ret.Get(0).(func() cache.Cache); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(cache.Cache)
+		}
+	}
+
+	return r0
+}
+
 // Client provides a mock function with given fields:
 func (_m *K8sSvc) Client() client.Client {
 	ret := _m.Called()
@@ -27,3 +51,51 @@ func (_m *K8sSvc) Client() client.Client {
 
 	return r0
 }
+
+// Clientset provides a mock function with given fields:
+func (_m *K8sSvc) Clientset() kubernetes.Interface {
+	ret := _m.Called()
+
+	var r0 kubernetes.Interface
+	if rf, ok := ret.Get(0).(func() kubernetes.Interface); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(kubernetes.Interface)
+		}
+	}
+
+	return r0
+}
+
+// RestConfig provides a mock function with given fields:
+func (_m *K8sSvc) RestConfig() *rest.Config {
+	ret := _m.Called()
+
+	var r0 *rest.Config
+	if rf, ok := ret.Get(0).(func() *rest.Config); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rest.Config)
+		}
+	}
+
+	return r0
+}
+
+// Scheme provides a mock function with given fields:
+func (_m *K8sSvc) Scheme() *runtime.Scheme {
+	ret := _m.Called()
+
+	var r0 *runtime.Scheme
+	if rf, ok := ret.Get(0).(func() *runtime.Scheme); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*runtime.Scheme)
+		}
+	}
+
+	return r0
+}
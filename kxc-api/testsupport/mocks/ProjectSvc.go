@@ -16,6 +16,20 @@ type ProjectSvc struct {
 	mock.Mock
 }
 
+// AddMember provides a mock function with given fields: ctx, projectName, reqData
+func (_m *ProjectSvc) AddMember(ctx context.Context, projectName string, reqData *requests.AddProjectMember) error {
+	ret := _m.Called(ctx, projectName, reqData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *requests.AddProjectMember) error); ok {
+		r0 = rf(ctx, projectName, reqData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: ctx, userName, reqData
 func (_m *ProjectSvc) Create(ctx context.Context, userName string, reqData *requests.CreateProject) error {
 	ret := _m.Called(ctx, userName, reqData)
@@ -30,6 +44,20 @@ func (_m *ProjectSvc) Create(ctx context.Context, userName string, reqData *requ
 	return r0
 }
 
+// Delete provides a mock function with given fields: ctx, userName, projectName
+func (_m *ProjectSvc) Delete(ctx context.Context, userName string, projectName string) error {
+	ret := _m.Called(ctx, userName, projectName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userName, projectName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields: ctx, userName, projectName
 func (_m *ProjectSvc) Get(ctx context.Context, userName string, projectName string) (*responses.Project, error) {
 	ret := _m.Called(ctx, userName, projectName)
@@ -75,3 +103,45 @@ func (_m *ProjectSvc) List(ctx context.Context, userName string) (*responses.Lis
 
 	return r0, r1
 }
+
+// RemoveMember provides a mock function with given fields: ctx, projectName, memberName
+func (_m *ProjectSvc) RemoveMember(ctx context.Context, projectName string, memberName string) error {
+	ret := _m.Called(ctx, projectName, memberName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, projectName, memberName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetQuota provides a mock function with given fields: ctx, projectName, reqData
+func (_m *ProjectSvc) SetQuota(ctx context.Context, projectName string, reqData *requests.SetProjectQuota) error {
+	ret := _m.Called(ctx, projectName, reqData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *requests.SetProjectQuota) error); ok {
+		r0 = rf(ctx, projectName, reqData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateMember provides a mock function with given fields: ctx, projectName, memberName, reqData
+func (_m *ProjectSvc) UpdateMember(ctx context.Context, projectName string, memberName string, reqData *requests.UpdateProjectMember) error {
+	ret := _m.Called(ctx, projectName, memberName, reqData)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *requests.UpdateProjectMember) error); ok {
+		r0 = rf(ctx, projectName, memberName, reqData)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
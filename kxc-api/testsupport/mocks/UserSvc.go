@@ -30,6 +30,20 @@ func (_m *UserSvc) Create(ctx context.Context, reqData *requests.CreateUser) err
 	return r0
 }
 
+// Delete provides a mock function with given fields: ctx, userName
+func (_m *UserSvc) Delete(ctx context.Context, userName string) error {
+	ret := _m.Called(ctx, userName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // HasRole provides a mock function with given fields: ctx, userName, role
 func (_m *UserSvc) HasRole(ctx context.Context, userName string, role string) (bool, error) {
 	ret := _m.Called(ctx, userName, role)
@@ -74,23 +88,136 @@ func (_m *UserSvc) List(ctx context.Context) (*responses.ListUser, error) {
 	return r0, r1
 }
 
-// Login provides a mock function with given fields: ctx, userName, password
-func (_m *UserSvc) Login(ctx context.Context, userName string, password string) (string, error) {
-	ret := _m.Called(ctx, userName, password)
+// Login provides a mock function with given fields: ctx, userName, password, clientIP
+func (_m *UserSvc) Login(ctx context.Context, userName string, password string, clientIP string) (*responses.LoginUser, error) {
+	ret := _m.Called(ctx, userName, password, clientIP)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, userName, password)
+	var r0 *responses.LoginUser
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *responses.LoginUser); ok {
+		r0 = rf(ctx, userName, password, clientIP)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*responses.LoginUser)
+		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, userName, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, userName, password, clientIP)
 	} else {
 		r1 = ret.Error(1)
 	}
 
 	return r0, r1
 }
+
+// LoginOIDC provides a mock function with given fields: ctx, idToken
+func (_m *UserSvc) LoginOIDC(ctx context.Context, idToken string) (*responses.LoginUser, error) {
+	ret := _m.Called(ctx, idToken)
+
+	var r0 *responses.LoginUser
+	if rf, ok := ret.Get(0).(func(context.Context, string) *responses.LoginUser); ok {
+		r0 = rf(ctx, idToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*responses.LoginUser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, idToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: ctx, userName
+func (_m *UserSvc) Logout(ctx context.Context, userName string) error {
+	ret := _m.Called(ctx, userName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OIDCConfig provides a mock function with given fields: ctx
+func (_m *UserSvc) OIDCConfig(ctx context.Context) (*responses.OIDCConfig, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *responses.OIDCConfig
+	if rf, ok := ret.Get(0).(func(context.Context) *responses.OIDCConfig); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*responses.OIDCConfig)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *UserSvc) Refresh(ctx context.Context, refreshToken string) (*responses.LoginUser, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	var r0 *responses.LoginUser
+	if rf, ok := ret.Get(0).(func(context.Context, string) *responses.LoginUser); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*responses.LoginUser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unlock provides a mock function with given fields: ctx, userName
+func (_m *UserSvc) Unlock(ctx context.Context, userName string) error {
+	ret := _m.Called(ctx, userName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateSession provides a mock function with given fields: ctx, userName, userUID, tokenVersion
+func (_m *UserSvc) ValidateSession(ctx context.Context, userName string, userUID string, tokenVersion int64) error {
+	ret := _m.Called(ctx, userName, userUID, tokenVersion)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) error); ok {
+		r0 = rf(ctx, userName, userUID, tokenVersion)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
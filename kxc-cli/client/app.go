@@ -1,14 +1,19 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 
+	"github.com/didil/kubexcloud/kxc-api/requests"
 	"github.com/didil/kubexcloud/kxc-api/responses"
+	"golang.org/x/net/websocket"
 )
 
 func (cl *Client) ListApps(projectName string) (*responses.ListApp, error) {
@@ -26,7 +31,7 @@ func (cl *Client) ListApps(projectName string) (*responses.ListApp, error) {
 
 	req.Header.Set("Authorization", "Bearer "+cl.authToken)
 
-	resp, err := cl.httpClient.Do(req)
+	resp, err := cl.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("req do: %v", err)
 	}
@@ -52,6 +57,47 @@ func (cl *Client) ListApps(projectName string) (*responses.ListApp, error) {
 	return respData, nil
 }
 
+func (cl *Client) GetApp(projectName, appName string) (*responses.GetApp, error) {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/apps/%s", projectName, appName))
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("http read: %v", err)
+		}
+
+		return nil, fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	respData := &responses.GetApp{}
+
+	err = json.NewDecoder(resp.Body).Decode(respData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+
+	return respData, nil
+}
+
 func (cl *Client) RestartApp(projectName, appName string) error {
 	u, err := url.Parse(cl.apiURL)
 	if err != nil {
@@ -67,7 +113,7 @@ func (cl *Client) RestartApp(projectName, appName string) error {
 
 	req.Header.Set("Authorization", "Bearer "+cl.authToken)
 
-	resp, err := cl.httpClient.Do(req)
+	resp, err := cl.do(req)
 	if err != nil {
 		return fmt.Errorf("req do: %v", err)
 	}
@@ -92,3 +138,416 @@ func (cl *Client) RestartApp(projectName, appName string) error {
 
 	return nil
 }
+
+func (cl *Client) AttachAppDomain(projectName, appName, domain string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/apps/%s/domains", projectName, appName))
+
+	reqData := &requests.AttachDomain{
+		Domain: domain,
+	}
+
+	var b bytes.Buffer
+	err = json.NewEncoder(&b).Encode(reqData)
+	if err != nil {
+		return fmt.Errorf("encode req data: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), &b)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
+
+func (cl *Client) DetachAppDomain(projectName, appName, domain string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/apps/%s/domains/%s", projectName, appName, domain))
+
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
+
+func (cl *Client) DeleteApp(projectName, appName string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/apps/%s", projectName, appName))
+
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
+
+// AppLogs writes the logs of the app pods to w until the api closes the stream
+func (cl *Client) AppLogs(projectName, appName string, reqData *requests.AppLogs, w io.Writer) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/apps/%s/logs", projectName, appName))
+
+	q := u.Query()
+	if reqData.Container != "" {
+		q.Set("container", reqData.Container)
+	}
+	if reqData.Follow {
+		q.Set("follow", "true")
+	}
+	if reqData.TailLines != nil {
+		q.Set("tail", strconv.FormatInt(*reqData.TailLines, 10))
+	}
+	if reqData.SinceSeconds != nil {
+		q.Set("since", fmt.Sprintf("%ds", *reqData.SinceSeconds))
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.doStream(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return fmt.Errorf("read logs: %v", err)
+	}
+
+	return nil
+}
+
+// exec session streams, see the api ExecProtocol
+const (
+	execProtocol     = "v4.channel.k8s.io"
+	execStreamStdin  = 0
+	execStreamStdout = 1
+	execStreamStderr = 2
+	execStreamError  = 3
+	execStreamResize = 4
+)
+
+// TerminalSize is sent to resize the tty of exec sessions
+type TerminalSize struct {
+	Width  uint16
+	Height uint16
+}
+
+// execStatus is the kubernetes status ending exec sessions
+type execStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Details *struct {
+		Causes []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"causes"`
+	} `json:"details"`
+}
+
+// ExecApp runs a command in an app container, stdin is only read if reqData.Stdin is set.
+// It returns the exit code of the command
+func (cl *Client) ExecApp(projectName, appName string, reqData *requests.AppExec, stdin io.Reader, stdout, stderr io.Writer, resizes <-chan TerminalSize) (int, error) {
+	q := url.Values{}
+	q["command"] = reqData.Command
+	if reqData.Pod != "" {
+		q.Set("pod", reqData.Pod)
+	}
+	if reqData.Container != "" {
+		q.Set("container", reqData.Container)
+	}
+	q.Set("stdin", strconv.FormatBool(reqData.Stdin))
+	q.Set("tty", strconv.FormatBool(reqData.TTY))
+
+	ws, err := cl.dialWebsocket(projectName, fmt.Sprintf("v1/projects/%s/apps/%s/exec", projectName, appName), q, execProtocol)
+	if err != nil {
+		return 0, err
+	}
+	defer ws.Close()
+
+	if reqData.Stdin {
+		go func() {
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := stdin.Read(buf)
+				if n > 0 {
+					msg := append([]byte{execStreamStdin}, buf[:n]...)
+					if websocket.Message.Send(ws, msg) != nil {
+						return
+					}
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	if resizes != nil {
+		go func() {
+			for size := range resizes {
+				b, err := json.Marshal(size)
+				if err != nil {
+					continue
+				}
+				if websocket.Message.Send(ws, append([]byte{execStreamResize}, b...)) != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		var msg []byte
+		err := websocket.Message.Receive(ws, &msg)
+		if err == io.EOF {
+			return 0, fmt.Errorf("exec session closed without status")
+		}
+		if err != nil {
+			return 0, fmt.Errorf("websocket receive: %v", err)
+		}
+		if len(msg) == 0 {
+			continue
+		}
+
+		switch msg[0] {
+		case execStreamStdout:
+			stdout.Write(msg[1:])
+		case execStreamStderr:
+			stderr.Write(msg[1:])
+		case execStreamError:
+			return execExitCode(msg[1:])
+		}
+	}
+}
+
+// execExitCode returns the exit code held by an exec status
+func execExitCode(b []byte) (int, error) {
+	status := &execStatus{}
+	err := json.Unmarshal(b, status)
+	if err != nil {
+		return 0, fmt.Errorf("decode exec status: %v", err)
+	}
+
+	if status.Status == "Success" {
+		return 0, nil
+	}
+
+	if status.Reason == "NonZeroExitCode" && status.Details != nil {
+		for _, cause := range status.Details.Causes {
+			if cause.Reason == "ExitCode" {
+				code, err := strconv.Atoi(cause.Message)
+				if err != nil {
+					return 0, fmt.Errorf("invalid exit code: %s", cause.Message)
+				}
+				return code, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("exec: %s", status.Message)
+}
+
+// port forward session streams, see the api PortForwardProtocol
+const (
+	portForwardProtocol    = "v4.channel.k8s.io"
+	portForwardStreamData  = 0
+	portForwardStreamError = 1
+)
+
+// PortForward is a connection to an app port opened by OpenAppPortForward
+type PortForward struct {
+	ws *websocket.Conn
+}
+
+// OpenAppPortForward opens a connection to a port of an app replica
+func (cl *Client) OpenAppPortForward(projectName, appName string, reqData *requests.AppPortForward) (*PortForward, error) {
+	q := url.Values{}
+	q.Set("port", strconv.Itoa(int(reqData.Port)))
+	if reqData.Pod != "" {
+		q.Set("pod", reqData.Pod)
+	}
+
+	ws, err := cl.dialWebsocket(projectName, fmt.Sprintf("v1/projects/%s/apps/%s/port-forward", projectName, appName), q, portForwardProtocol)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PortForward{ws: ws}, nil
+}
+
+// Forward copies data between conn and the app port until either side closes, both are closed on return
+func (pf *PortForward) Forward(conn io.ReadWriteCloser) error {
+	defer pf.ws.Close()
+	defer conn.Close()
+
+	go func() {
+		// unblocks the websocket receive once conn is closed
+		defer pf.ws.Close()
+
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				msg := append([]byte{portForwardStreamData}, buf[:n]...)
+				if websocket.Message.Send(pf.ws, msg) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var msg []byte
+		err := websocket.Message.Receive(pf.ws, &msg)
+		if err != nil {
+			// closed by either side
+			return nil
+		}
+		if len(msg) == 0 {
+			continue
+		}
+
+		switch msg[0] {
+		case portForwardStreamData:
+			_, err = conn.Write(msg[1:])
+			if err != nil {
+				return nil
+			}
+		case portForwardStreamError:
+			return fmt.Errorf("port forward: %s", msg[1:])
+		}
+	}
+}
+
+// dialWebsocket opens a websocket to the api
+func (cl *Client) dialWebsocket(projectName, apiPath string, q url.Values, protocol string) (*websocket.Conn, error) {
+	// checks the project access first, refreshing the session if needed, as websocket dials can't report api errors
+	_, err := cl.GetProject(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+	origin := u.String()
+
+	u.Path = path.Join(u.Path, apiPath)
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.RawQuery = q.Encode()
+
+	config, err := websocket.NewConfig(u.String(), origin)
+	if err != nil {
+		return nil, fmt.Errorf("websocket config: %v", err)
+	}
+	config.Protocol = []string{protocol}
+	config.Header = http.Header{"Authorization": []string{"Bearer " + cl.authToken}}
+
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("websocket dial: %v", err)
+	}
+	ws.PayloadType = websocket.BinaryFrame
+
+	return ws, nil
+}
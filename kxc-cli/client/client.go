@@ -1,6 +1,7 @@
 package client
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
@@ -8,9 +9,12 @@ import (
 )
 
 type Client struct {
-	apiURL     string
-	authToken  string
-	httpClient *http.Client
+	apiURL       string
+	authToken    string
+	refreshToken string
+	httpClient   *http.Client
+	// streamHTTPClient has no timeout, for long lived responses such as followed logs
+	streamHTTPClient *http.Client
 }
 
 func NewClient() *Client {
@@ -19,7 +23,8 @@ func NewClient() *Client {
 	}
 
 	cl := &Client{
-		httpClient: httpClient,
+		httpClient:       httpClient,
+		streamHTTPClient: &http.Client{},
 	}
 
 	if apiURL := config.GetApiUrl(); apiURL != "" {
@@ -30,5 +35,64 @@ func NewClient() *Client {
 		cl.authToken = authToken
 	}
 
+	if refreshToken := config.GetRefreshToken(); refreshToken != "" {
+		cl.refreshToken = refreshToken
+	}
+
 	return cl
 }
+
+// do sends the request, refreshing the session and retrying once when the access token expired
+func (cl *Client) do(req *http.Request) (*http.Response, error) {
+	return cl.doWith(cl.httpClient, req)
+}
+
+// doStream sends the request like do, without a timeout
+func (cl *Client) doStream(req *http.Request) (*http.Response, error) {
+	return cl.doWith(cl.streamHTTPClient, req)
+}
+
+func (cl *Client) doWith(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || cl.refreshToken == "" {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	err = cl.refreshSession()
+	if err != nil {
+		return nil, fmt.Errorf("refresh session: %v", err)
+	}
+
+	retryReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retryReq.Body, err = req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("get body: %v", err)
+		}
+	}
+	retryReq.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	return httpClient.Do(retryReq)
+}
+
+// refreshSession gets a new access token and saves it to the config
+func (cl *Client) refreshSession() error {
+	respData, err := cl.RefreshUser(cl.refreshToken)
+	if err != nil {
+		return err
+	}
+
+	cl.authToken = respData.Token
+	cl.refreshToken = respData.RefreshToken
+
+	config.SetAuthToken(respData.Token)
+	config.SetRefreshToken(respData.RefreshToken)
+
+	err = config.WriteConfig()
+	if err != nil {
+		return fmt.Errorf("writeconfig: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type oidcDiscovery struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+type oidcTokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+// OIDCLogin runs the oidc authorization code flow with pkce against the issuer
+// using a local callback server, onAuthURL is called with the url to open in a browser.
+// It returns the id token issued to clientID
+func (cl *Client) OIDCLogin(ctx context.Context, issuer, clientID string, onAuthURL func(authURL string)) (string, error) {
+	discovery := &oidcDiscovery{}
+	err := cl.getJSON(strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", discovery)
+	if err != nil {
+		return "", fmt.Errorf("oidc discovery: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr().String())
+
+	state, err := randomURLString(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomURLString(32)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %v", err)
+	}
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", clientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", "openid profile email groups")
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	authURL.RawQuery = q.Encode()
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/callback" {
+				http.NotFound(w, r)
+				return
+			}
+
+			var result callbackResult
+			switch {
+			case r.URL.Query().Get("state") != state:
+				result.err = fmt.Errorf("oidc callback state mismatch")
+			case r.URL.Query().Get("error") != "":
+				result.err = fmt.Errorf("oidc: %s %s", r.URL.Query().Get("error"), r.URL.Query().Get("error_description"))
+			default:
+				result.code = r.URL.Query().Get("code")
+			}
+
+			if result.err != nil {
+				http.Error(w, "Authentication failed, you can close this window.", http.StatusBadRequest)
+			} else {
+				fmt.Fprintf(w, "Authenticated, you can close this window.")
+			}
+
+			select {
+			case results <- result:
+			default:
+			}
+		}),
+	}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	onAuthURL(authURL.String())
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return "", fmt.Errorf("oidc callback: %v", ctx.Err())
+	}
+	if result.err != nil {
+		return "", result.err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", result.code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", clientID)
+	form.Set("code_verifier", verifier)
+
+	resp, err := cl.httpClient.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return "", fmt.Errorf("oidc token req: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("http read: %v", err)
+		}
+
+		return "", fmt.Errorf("oidc token http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	tokenResp := &oidcTokenResponse{}
+	err = json.NewDecoder(resp.Body).Decode(tokenResp)
+	if err != nil {
+		return "", fmt.Errorf("decode: %v", err)
+	}
+	if tokenResp.IDToken == "" {
+		return "", fmt.Errorf("oidc token response has no id token")
+	}
+
+	return tokenResp.IDToken, nil
+}
+
+func (cl *Client) getJSON(u string, data interface{}) error {
+	resp, err := cl.httpClient.Get(u)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("http: %v", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(data)
+	if err != nil {
+		return fmt.Errorf("decode: %v", err)
+	}
+
+	return nil
+}
+
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("read random: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"net/url"
 	"path"
 
+	"github.com/didil/kubexcloud/kxc-api/requests"
 	"github.com/didil/kubexcloud/kxc-api/responses"
 )
 
@@ -26,7 +28,7 @@ func (cl *Client) ListProjects() (*responses.ListProject, error) {
 
 	req.Header.Set("Authorization", "Bearer "+cl.authToken)
 
-	resp, err := cl.httpClient.Do(req)
+	resp, err := cl.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("req do: %v", err)
 	}
@@ -51,3 +53,241 @@ func (cl *Client) ListProjects() (*responses.ListProject, error) {
 
 	return respData, nil
 }
+
+func (cl *Client) DeleteProject(projectName string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s", projectName))
+
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
+
+func (cl *Client) SetProjectQuota(projectName string, reqData *requests.SetProjectQuota) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/quota", projectName))
+
+	var b bytes.Buffer
+	err = json.NewEncoder(&b).Encode(reqData)
+	if err != nil {
+		return fmt.Errorf("encode req data: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, u.String(), &b)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
+
+func (cl *Client) GetProject(projectName string) (*responses.Project, error) {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s", projectName))
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("http read: %v", err)
+		}
+
+		return nil, fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	respData := &responses.Project{}
+
+	err = json.NewDecoder(resp.Body).Decode(respData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+
+	return respData, nil
+}
+
+func (cl *Client) AddProjectMember(projectName, userName, role string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/members", projectName))
+
+	reqData := &requests.AddProjectMember{
+		UserName: userName,
+		Role:     role,
+	}
+
+	var b bytes.Buffer
+	err = json.NewEncoder(&b).Encode(reqData)
+	if err != nil {
+		return fmt.Errorf("encode req data: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), &b)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
+
+func (cl *Client) UpdateProjectMember(projectName, userName, role string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/members/%s", projectName, userName))
+
+	reqData := &requests.UpdateProjectMember{
+		Role: role,
+	}
+
+	var b bytes.Buffer
+	err = json.NewEncoder(&b).Encode(reqData)
+	if err != nil {
+		return fmt.Errorf("encode req data: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, u.String(), &b)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
+
+func (cl *Client) RemoveProjectMember(projectName, userName string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/projects/%s/members/%s", projectName, userName))
+
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
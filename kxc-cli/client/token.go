@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
+)
+
+func (cl *Client) CreateToken(reqData *requests.CreateToken) (*responses.CreateToken, error) {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, "v1/users/me/tokens")
+
+	var b bytes.Buffer
+	err = json.NewEncoder(&b).Encode(reqData)
+	if err != nil {
+		return nil, fmt.Errorf("encode req data: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), &b)
+	if err != nil {
+		return nil, fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("http read: %v", err)
+		}
+
+		return nil, fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	respData := &responses.CreateToken{}
+
+	err = json.NewDecoder(resp.Body).Decode(respData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+
+	return respData, nil
+}
+
+func (cl *Client) ListTokens() (*responses.ListToken, error) {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, "v1/users/me/tokens")
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("http read: %v", err)
+		}
+
+		return nil, fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	respData := &responses.ListToken{}
+
+	err = json.NewDecoder(resp.Body).Decode(respData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+
+	return respData, nil
+}
+
+func (cl *Client) RevokeToken(tokenID string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/users/me/tokens/%s", tokenID))
+
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
@@ -13,10 +13,10 @@ import (
 	"github.com/didil/kubexcloud/kxc-api/responses"
 )
 
-func (cl *Client) LoginUser(apiURL, userName, password string) (string, error) {
+func (cl *Client) LoginUser(apiURL, userName, password string) (*responses.LoginUser, error) {
 	u, err := url.Parse(apiURL)
 	if err != nil {
-		return "", fmt.Errorf("invalid api url %v : %v", apiURL, err)
+		return nil, fmt.Errorf("invalid api url %v : %v", apiURL, err)
 	}
 
 	u.Path = path.Join(u.Path, "v1/users/login")
@@ -29,17 +29,156 @@ func (cl *Client) LoginUser(apiURL, userName, password string) (string, error) {
 	var b bytes.Buffer
 	err = json.NewEncoder(&b).Encode(reqData)
 	if err != nil {
-		return "", fmt.Errorf("encode req data: %v", err)
+		return nil, fmt.Errorf("encode req data: %v", err)
 	}
 
 	req, err := http.NewRequest(http.MethodPost, u.String(), &b)
 	if err != nil {
-		return "", fmt.Errorf("new req: %v", err)
+		return nil, fmt.Errorf("new req: %v", err)
+	}
+
+	resp, err := cl.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("http read: %v", err)
+		}
+
+		return nil, fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	respData := &responses.LoginUser{}
+
+	err = json.NewDecoder(resp.Body).Decode(respData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+
+	return respData, nil
+}
+
+// GetOIDCConfig returns the oidc provider configured on the api
+func (cl *Client) GetOIDCConfig(apiURL string) (*responses.OIDCConfig, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %v : %v", apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, "v1/users/login/oidc")
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new req: %v", err)
+	}
+
+	resp, err := cl.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("http read: %v", err)
+		}
+
+		return nil, fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	respData := &responses.OIDCConfig{}
+
+	err = json.NewDecoder(resp.Body).Decode(respData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+
+	return respData, nil
+}
+
+// LoginUserOIDC exchanges an oidc id token for a kxc session
+func (cl *Client) LoginUserOIDC(apiURL, idToken string) (*responses.LoginUser, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %v : %v", apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, "v1/users/login/oidc")
+
+	reqData := &requests.LoginUserOIDC{
+		IDToken: idToken,
+	}
+
+	var b bytes.Buffer
+	err = json.NewEncoder(&b).Encode(reqData)
+	if err != nil {
+		return nil, fmt.Errorf("encode req data: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), &b)
+	if err != nil {
+		return nil, fmt.Errorf("new req: %v", err)
+	}
+
+	resp, err := cl.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("http read: %v", err)
+		}
+
+		return nil, fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	respData := &responses.LoginUser{}
+
+	err = json.NewDecoder(resp.Body).Decode(respData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+
+	return respData, nil
+}
+
+func (cl *Client) RefreshUser(refreshToken string) (*responses.LoginUser, error) {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, "v1/users/refresh")
+
+	reqData := &requests.RefreshUser{
+		RefreshToken: refreshToken,
+	}
+
+	var b bytes.Buffer
+	err = json.NewEncoder(&b).Encode(reqData)
+	if err != nil {
+		return nil, fmt.Errorf("encode req data: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), &b)
+	if err != nil {
+		return nil, fmt.Errorf("new req: %v", err)
 	}
 
 	resp, err := cl.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("req do: %v", err)
+		return nil, fmt.Errorf("req do: %v", err)
 	}
 
 	defer resp.Body.Close()
@@ -47,20 +186,54 @@ func (cl *Client) LoginUser(apiURL, userName, password string) (string, error) {
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
 		errData, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return "", fmt.Errorf("http read: %v", err)
+			return nil, fmt.Errorf("http read: %v", err)
 		}
 
-		return "", fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+		return nil, fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
 	}
 
 	respData := &responses.LoginUser{}
 
 	err = json.NewDecoder(resp.Body).Decode(respData)
 	if err != nil {
-		return "", fmt.Errorf("decode: %v", err)
+		return nil, fmt.Errorf("decode: %v", err)
 	}
 
-	return respData.Token, nil
+	return respData, nil
+}
+
+func (cl *Client) LogoutUser() error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, "v1/users/me/logout")
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
 }
 
 func (cl *Client) CreateUser(userName, password, role string) error {
@@ -90,7 +263,7 @@ func (cl *Client) CreateUser(userName, password, role string) error {
 
 	req.Header.Set("Authorization", "Bearer "+cl.authToken)
 
-	resp, err := cl.httpClient.Do(req)
+	resp, err := cl.do(req)
 	if err != nil {
 		return fmt.Errorf("req do: %v", err)
 	}
@@ -124,7 +297,7 @@ func (cl *Client) ListUsers() (*responses.ListUser, error) {
 
 	req.Header.Set("Authorization", "Bearer "+cl.authToken)
 
-	resp, err := cl.httpClient.Do(req)
+	resp, err := cl.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("req do: %v", err)
 	}
@@ -149,3 +322,71 @@ func (cl *Client) ListUsers() (*responses.ListUser, error) {
 
 	return respData, nil
 }
+
+func (cl *Client) DeleteUser(userName string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/users/%s", userName))
+
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
+
+func (cl *Client) UnlockUser(userName string) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, fmt.Sprintf("v1/users/%s/unlock", userName))
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+
+	resp, err := cl.do(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	return nil
+}
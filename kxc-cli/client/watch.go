@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
+)
+
+// Watch calls onEvent for each app and project change until the api ends the stream.
+// The stream starts with the current state of the watched objects as ADDED events
+func (cl *Client) Watch(reqData *requests.Watch, onEvent func(event *responses.WatchEvent)) error {
+	u, err := url.Parse(cl.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %v : %v", cl.apiURL, err)
+	}
+
+	u.Path = path.Join(u.Path, "v1/watch")
+
+	q := u.Query()
+	if reqData.Project != "" {
+		q.Set("project", reqData.Project)
+	}
+	if reqData.App != "" {
+		q.Set("app", reqData.App)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new req: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cl.authToken)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := cl.doStream(req)
+	if err != nil {
+		return fmt.Errorf("req do: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		errData, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("http read: %v", err)
+		}
+
+		return fmt.Errorf("http: %v, %s", resp.StatusCode, string(errData))
+	}
+
+	// server-sent events are separated by blank lines, lines starting with : are comments
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	data := []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if len(data) == 0 {
+				continue
+			}
+
+			event := &responses.WatchEvent{}
+			err := json.Unmarshal([]byte(strings.Join(data, "\n")), event)
+			if err != nil {
+				return fmt.Errorf("decode event: %v", err)
+			}
+			data = data[:0]
+
+			onEvent(event)
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("read events: %v", err)
+	}
+
+	return nil
+}
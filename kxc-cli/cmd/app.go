@@ -3,11 +3,18 @@ package main
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-api/responses"
 	"github.com/didil/kubexcloud/kxc-cli/client"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 func buildAppsCmd() *cobra.Command {
@@ -22,9 +29,30 @@ func buildAppsCmd() *cobra.Command {
 	appsListCmd := buildAppsListCmd()
 	appsCmd.AddCommand(appsListCmd)
 
+	appGetCmd := buildAppGetCmd()
+	appsCmd.AddCommand(appGetCmd)
+
 	appRestartCmd := buildAppRestartCmd()
 	appsCmd.AddCommand(appRestartCmd)
 
+	appDeleteCmd := buildAppDeleteCmd()
+	appsCmd.AddCommand(appDeleteCmd)
+
+	appDomainsCmd := buildAppDomainsCmd()
+	appsCmd.AddCommand(appDomainsCmd)
+
+	appLogsCmd := buildAppLogsCmd()
+	appsCmd.AddCommand(appLogsCmd)
+
+	appWatchCmd := buildAppWatchCmd()
+	appsCmd.AddCommand(appWatchCmd)
+
+	appExecCmd := buildAppExecCmd()
+	appsCmd.AddCommand(appExecCmd)
+
+	appPortForwardCmd := buildAppPortForwardCmd()
+	appsCmd.AddCommand(appPortForwardCmd)
+
 	return appsCmd
 }
 
@@ -64,16 +92,190 @@ func listAppsRun(projectName string) error {
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name"})
+	table.SetHeader([]string{"Name", "Ready", "URLs", "Domains", "Volumes"})
+
+	for _, app := range appsList.Apps {
+		volumes := []string{}
+		for _, v := range app.Volumes {
+			volumes = append(volumes, fmt.Sprintf("%s (%s, %s)", v.Name, v.Size, v.ReclaimPolicy))
+		}
+
+		table.Append([]string{app.Name, formatReady(app.Conditions), strings.Join(app.ExternalURLs, ", "), strings.Join(app.Domains, ", "), strings.Join(volumes, ", ")})
+	}
+	table.Render()
+
+	return nil
+}
+
+func buildAppGetCmd() *cobra.Command {
+	var appGetCmd = &cobra.Command{
+		Use:   "get <app>",
+		Short: "KubeXCloud Apps Get, shows the app spec, status, pods, events and rollout",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := cmd.Flags().GetString("project")
+			if err != nil {
+				return err
+			}
+			if projectName == "" {
+				return fmt.Errorf("project name required")
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("app name required")
+			}
+
+			appName := args[0]
+
+			err = getAppRun(projectName, appName)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return appGetCmd
+}
+
+func getAppRun(projectName, appName string) error {
+	cl := client.NewClient()
+
+	app, err := cl.GetApp(projectName, appName)
+	if err != nil {
+		return fmt.Errorf("get app: %v", err)
+	}
+
+	replicas := strconv.Itoa(int(app.Spec.Replicas))
+	if as := app.Spec.Autoscaling; as != nil {
+		replicas = fmt.Sprintf("%d-%d (autoscaling at %d%% cpu, desired %d)", as.MinReplicas, as.MaxReplicas, as.TargetCPUUtilizationPercentage, app.Status.DesiredReplicas)
+	}
+
+	fmt.Printf("Name:     %s\n", app.Name)
+	fmt.Printf("Project:  %s\n", projectName)
+	fmt.Printf("Age:      %s\n", formatAge(app.CreatedAt))
+	fmt.Printf("Ready:    %s\n", formatReady(app.Status.Conditions))
+	fmt.Printf("Replicas: %s\n", replicas)
+	fmt.Printf("URLs:     %s\n", strings.Join(app.Status.ExternalURLs, ", "))
+	fmt.Printf("Domains:  %s\n", strings.Join(app.Spec.Domains, ", "))
+
+	if ro := app.Rollout; ro != nil {
+		fmt.Printf("Rollout:  revision %s, %s: %s (%d updated, %d ready, %d available of %d)\n",
+			ro.Revision, ro.State, ro.Message, ro.UpdatedReplicas, ro.ReadyReplicas, ro.AvailableReplicas, ro.Replicas)
+	}
+
+	fmt.Printf("\nContainers:\n")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Image", "Ports", "Requests", "Limits"})
+	for _, c := range app.Spec.Containers {
+		ports := []string{}
+		for _, p := range c.Ports {
+			port := fmt.Sprintf("%d/%s", p.Number, p.Protocol)
+			if p.ExposeExternally {
+				port += " (exposed)"
+			}
+			ports = append(ports, port)
+		}
+
+		var resRequests, resLimits string
+		if c.Resources != nil {
+			resRequests = formatResourceList(c.Resources.Requests)
+			resLimits = formatResourceList(c.Resources.Limits)
+		}
 
-	for _, proj := range appsList.Apps {
-		table.Append([]string{proj.Name})
+		table.Append([]string{c.Name, c.Image, strings.Join(ports, ", "), resRequests, resLimits})
+	}
+	table.Render()
+
+	if len(app.Spec.Volumes) > 0 {
+		fmt.Printf("\nVolumes:\n")
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Name", "Size", "Access Mode", "Reclaim Policy"})
+		for _, v := range app.Spec.Volumes {
+			table.Append([]string{v.Name, v.Size, v.AccessMode, v.ReclaimPolicy})
+		}
+		table.Render()
+	}
+
+	fmt.Printf("\nPods:\n")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Ready", "Status", "Restarts", "Node", "Age"})
+	for _, pod := range app.Pods {
+		status := pod.Phase
+		if pod.Reason != "" {
+			status = pod.Reason
+		}
+
+		table.Append([]string{
+			pod.Name,
+			fmt.Sprintf("%d/%d", pod.ReadyContainers, pod.Containers),
+			status,
+			strconv.Itoa(int(pod.Restarts)),
+			pod.Node,
+			formatAge(pod.CreatedAt),
+		})
+	}
+	table.Render()
+
+	fmt.Printf("\nConditions:\n")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Type", "Status", "Reason", "Message"})
+	for _, c := range app.Status.Conditions {
+		table.Append([]string{c.Type, c.Status, c.Reason, c.Message})
+	}
+	table.Render()
+
+	fmt.Printf("\nEvents:\n")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Last Seen", "Type", "Reason", "Object", "Message"})
+	for _, e := range app.Events {
+		lastSeen := formatAge(e.LastSeen)
+		if e.Count > 1 {
+			lastSeen = fmt.Sprintf("%s (x%d)", lastSeen, e.Count)
+		}
+
+		table.Append([]string{lastSeen, e.Type, e.Reason, e.Object, e.Message})
 	}
 	table.Render()
 
 	return nil
 }
 
+// formatResourceList formats cpu and memory quantities
+func formatResourceList(l *requests.ResourceList) string {
+	if l == nil {
+		return ""
+	}
+
+	values := []string{}
+	if l.CPU != "" {
+		values = append(values, "cpu "+l.CPU)
+	}
+	if l.Memory != "" {
+		values = append(values, "memory "+l.Memory)
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// formatAge formats the time elapsed since t, with the largest unit
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
 func buildAppRestartCmd() *cobra.Command {
 	var appsListCmd = &cobra.Command{
 		Use:   "restart <app>",
@@ -119,3 +321,512 @@ func restartAppRun(projectName, appName string) error {
 
 	return nil
 }
+
+func buildAppDomainsCmd() *cobra.Command {
+	var appDomainsCmd = &cobra.Command{
+		Use:   "domains",
+		Short: "KubeXCloud Apps Custom Domains",
+	}
+
+	appDomainsCmd.AddCommand(buildAppDomainAttachCmd())
+	appDomainsCmd.AddCommand(buildAppDomainDetachCmd())
+
+	return appDomainsCmd
+}
+
+func buildAppDomainAttachCmd() *cobra.Command {
+	var appDomainAttachCmd = &cobra.Command{
+		Use:   "attach <app> <domain>",
+		Short: "KubeXCloud Apps Domain Attach",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := cmd.Flags().GetString("project")
+			if err != nil {
+				return err
+			}
+			if projectName == "" {
+				return fmt.Errorf("project name required")
+			}
+
+			if len(args) < 2 {
+				return fmt.Errorf("app name and domain required")
+			}
+
+			err = attachAppDomainRun(projectName, args[0], args[1])
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return appDomainAttachCmd
+}
+
+func attachAppDomainRun(projectName, appName, domain string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Attaching domain %s to App %s [Project %v] ...\n", domain, appName, projectName)
+
+	err := cl.AttachAppDomain(projectName, appName, domain)
+	if err != nil {
+		return fmt.Errorf("attach domain: %v", err)
+	}
+
+	fmt.Printf("Domain attached successfully")
+
+	return nil
+}
+
+func buildAppDomainDetachCmd() *cobra.Command {
+	var appDomainDetachCmd = &cobra.Command{
+		Use:   "detach <app> <domain>",
+		Short: "KubeXCloud Apps Domain Detach",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := cmd.Flags().GetString("project")
+			if err != nil {
+				return err
+			}
+			if projectName == "" {
+				return fmt.Errorf("project name required")
+			}
+
+			if len(args) < 2 {
+				return fmt.Errorf("app name and domain required")
+			}
+
+			err = detachAppDomainRun(projectName, args[0], args[1])
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return appDomainDetachCmd
+}
+
+func detachAppDomainRun(projectName, appName, domain string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Detaching domain %s from App %s [Project %v] ...\n", domain, appName, projectName)
+
+	err := cl.DetachAppDomain(projectName, appName, domain)
+	if err != nil {
+		return fmt.Errorf("detach domain: %v", err)
+	}
+
+	fmt.Printf("Domain detached successfully")
+
+	return nil
+}
+
+func buildAppDeleteCmd() *cobra.Command {
+	var yes bool
+
+	var appDeleteCmd = &cobra.Command{
+		Use:   "delete <app>",
+		Short: "KubeXCloud Apps Delete",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := cmd.Flags().GetString("project")
+			if err != nil {
+				return err
+			}
+			if projectName == "" {
+				return fmt.Errorf("project name required")
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("app name required")
+			}
+
+			appName := args[0]
+
+			err = deleteAppRun(projectName, appName, yes)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	appDeleteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation")
+
+	return appDeleteCmd
+}
+
+func deleteAppRun(projectName, appName string, yes bool) error {
+	if !yes {
+		confirmed, err := confirmDeletion(fmt.Sprintf("Delete App %s [Project %v]", appName, projectName))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Printf("Aborted\n")
+			return nil
+		}
+	}
+
+	cl := client.NewClient()
+
+	fmt.Printf("Deleting App %s [Project %v] ...\n", appName, projectName)
+
+	err := cl.DeleteApp(projectName, appName)
+	if err != nil {
+		return fmt.Errorf("delete app: %v", err)
+	}
+
+	fmt.Printf("App deleted successfully\n")
+
+	return nil
+}
+
+func buildAppLogsCmd() *cobra.Command {
+	var container string
+	var follow bool
+	var tail int64
+	var since time.Duration
+
+	var appLogsCmd = &cobra.Command{
+		Use:   "logs <app>",
+		Short: "KubeXCloud Apps Logs, prints the logs of all the app replicas",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := cmd.Flags().GetString("project")
+			if err != nil {
+				return err
+			}
+			if projectName == "" {
+				return fmt.Errorf("project name required")
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("app name required")
+			}
+
+			appName := args[0]
+
+			reqData := &requests.AppLogs{
+				Container: container,
+				Follow:    follow,
+			}
+			if tail >= 0 {
+				reqData.TailLines = &tail
+			}
+			if since > 0 {
+				sinceSeconds := int64((since + time.Second - 1) / time.Second)
+				reqData.SinceSeconds = &sinceSeconds
+			}
+
+			err = appLogsRun(projectName, appName, reqData)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	appLogsCmd.Flags().StringVarP(&container, "container", "c", "", "container, required if the app has several")
+	appLogsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "stream new log lines")
+	appLogsCmd.Flags().Int64Var(&tail, "tail", -1, "lines to show from the end of the logs of each replica, all if negative")
+	appLogsCmd.Flags().DurationVar(&since, "since", 0, "only show logs newer than a duration such as 10m")
+
+	return appLogsCmd
+}
+
+func appLogsRun(projectName, appName string, reqData *requests.AppLogs) error {
+	cl := client.NewClient()
+
+	err := cl.AppLogs(projectName, appName, reqData, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("app logs: %v", err)
+	}
+
+	return nil
+}
+
+func buildAppWatchCmd() *cobra.Command {
+	var appWatchCmd = &cobra.Command{
+		Use:   "watch [app]",
+		Short: "KubeXCloud Apps Watch, prints the app status changes as they happen",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := cmd.Flags().GetString("project")
+			if err != nil {
+				return err
+			}
+			if projectName == "" {
+				return fmt.Errorf("project name required")
+			}
+
+			reqData := &requests.Watch{
+				Project: projectName,
+			}
+			if len(args) > 0 {
+				reqData.App = args[0]
+			}
+
+			err = appWatchRun(reqData)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return appWatchCmd
+}
+
+// appWatchReconnectDelay is the wait before reconnecting when the api ends the stream
+const appWatchReconnectDelay = 2 * time.Second
+
+func appWatchRun(reqData *requests.Watch) error {
+	cl := client.NewClient()
+
+	onEvent := func(event *responses.WatchEvent) {
+		if event.Kind != "App" || event.App == nil {
+			return
+		}
+
+		app := event.App
+		replicas := fmt.Sprintf("%d/%d available", app.AvailableReplicas, app.AvailableReplicas+app.UnavailableReplicas)
+		if app.Autoscaling {
+			replicas = fmt.Sprintf("%s (desired %d)", replicas, app.DesiredReplicas)
+		}
+
+		fmt.Printf("%s  %-8s  %s  Ready: %s  %s  %s\n",
+			time.Now().Format("15:04:05"), event.Type, app.Name, formatReady(app.Conditions), replicas, strings.Join(app.ExternalURLs, ", "))
+	}
+
+	for {
+		err := cl.Watch(reqData, onEvent)
+		if err != nil {
+			return fmt.Errorf("watch: %v", err)
+		}
+
+		// the api ends the streams of clients falling behind, the new stream starts with the current state
+		fmt.Fprintf(os.Stderr, "Stream ended, reconnecting ...\n")
+		time.Sleep(appWatchReconnectDelay)
+	}
+}
+
+func buildAppExecCmd() *cobra.Command {
+	var container string
+	var pod string
+	var stdin bool
+	var tty bool
+
+	var appExecCmd = &cobra.Command{
+		Use:   "exec <app> -- <command> [args...]",
+		Short: "KubeXCloud Apps Exec, runs a command in an app replica",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := cmd.Flags().GetString("project")
+			if err != nil {
+				return err
+			}
+			if projectName == "" {
+				return fmt.Errorf("project name required")
+			}
+
+			dashIndex := cmd.ArgsLenAtDash()
+			if dashIndex < 0 {
+				return fmt.Errorf("command required, after --")
+			}
+			if dashIndex == 0 {
+				return fmt.Errorf("app name required")
+			}
+			if len(args) == dashIndex {
+				return fmt.Errorf("command required, after --")
+			}
+
+			appName := args[0]
+
+			reqData := &requests.AppExec{
+				Pod:       pod,
+				Container: container,
+				Command:   args[dashIndex:],
+				Stdin:     stdin,
+				TTY:       tty,
+			}
+
+			exitCode, err := appExecRun(projectName, appName, reqData)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			os.Exit(exitCode)
+
+			return nil
+		},
+	}
+
+	appExecCmd.Flags().StringVarP(&container, "container", "c", "", "container, required if the app has several")
+	appExecCmd.Flags().StringVar(&pod, "pod", "", "replica pod, the first running replica if empty")
+	appExecCmd.Flags().BoolVarP(&stdin, "stdin", "i", false, "pass stdin to the command")
+	appExecCmd.Flags().BoolVarP(&tty, "tty", "t", false, "allocate a tty, stdin must be a terminal")
+
+	return appExecCmd
+}
+
+func appExecRun(projectName, appName string, reqData *requests.AppExec) (int, error) {
+	cl := client.NewClient()
+
+	var resizes chan client.TerminalSize
+
+	if reqData.TTY {
+		fd := int(os.Stdin.Fd())
+		if !terminal.IsTerminal(fd) {
+			return 0, fmt.Errorf("tty requested but stdin isn't a terminal")
+		}
+
+		state, err := terminal.MakeRaw(fd)
+		if err != nil {
+			return 0, fmt.Errorf("raw terminal: %v", err)
+		}
+		defer terminal.Restore(fd, state)
+
+		resizes = make(chan client.TerminalSize, 1)
+		stop := watchTerminalSize(fd, resizes)
+		defer stop()
+	}
+
+	exitCode, err := cl.ExecApp(projectName, appName, reqData, os.Stdin, os.Stdout, os.Stderr, resizes)
+	if err != nil {
+		return 0, fmt.Errorf("app exec: %v", err)
+	}
+
+	return exitCode, nil
+}
+
+func buildAppPortForwardCmd() *cobra.Command {
+	var pod string
+	var address string
+
+	var appPortForwardCmd = &cobra.Command{
+		Use:   "port-forward <app> [local:]remote...",
+		Short: "KubeXCloud Apps Port Forward, forwards local ports to app ports, including the ones not exposed externally",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectName, err := cmd.Flags().GetString("project")
+			if err != nil {
+				return err
+			}
+			if projectName == "" {
+				return fmt.Errorf("project name required")
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("app name required")
+			}
+			if len(args) == 1 {
+				return fmt.Errorf("port required, such as 5432 or 15432:5432")
+			}
+
+			appName := args[0]
+
+			forwards := []portForward{}
+			for _, arg := range args[1:] {
+				fwd, err := parsePortForward(arg)
+				if err != nil {
+					return err
+				}
+				forwards = append(forwards, fwd)
+			}
+
+			err = appPortForwardRun(projectName, appName, pod, address, forwards)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	appPortForwardCmd.Flags().StringVar(&pod, "pod", "", "replica pod, the first running replica if empty")
+	appPortForwardCmd.Flags().StringVar(&address, "address", "127.0.0.1", "local address to listen on")
+
+	return appPortForwardCmd
+}
+
+// portForward maps a local port to an app port
+type portForward struct {
+	local  int
+	remote int32
+}
+
+// parsePortForward parses [local:]remote, local is the remote port if omitted
+func parsePortForward(s string) (portForward, error) {
+	parts := strings.SplitN(s, ":", 2)
+	remotePart := parts[len(parts)-1]
+
+	remote, err := strconv.ParseUint(remotePart, 10, 16)
+	if err != nil || remote == 0 {
+		return portForward{}, fmt.Errorf("invalid remote port: %s", remotePart)
+	}
+
+	local := remote
+	if len(parts) == 2 {
+		// 0 picks a free local port
+		local, err = strconv.ParseUint(parts[0], 10, 16)
+		if err != nil {
+			return portForward{}, fmt.Errorf("invalid local port: %s", parts[0])
+		}
+	}
+
+	return portForward{local: int(local), remote: int32(remote)}, nil
+}
+
+func appPortForwardRun(projectName, appName, pod, address string, forwards []portForward) error {
+	cl := client.NewClient()
+
+	type accepted struct {
+		conn   net.Conn
+		remote int32
+	}
+	conns := make(chan accepted)
+
+	for _, fwd := range forwards {
+		listener, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(fwd.local)))
+		if err != nil {
+			return fmt.Errorf("listen: %v", err)
+		}
+		defer listener.Close()
+
+		fmt.Printf("Forwarding from %s -> %d\n", listener.Addr(), fwd.remote)
+
+		go func(listener net.Listener, remote int32) {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				conns <- accepted{conn: conn, remote: remote}
+			}
+		}(listener, fwd.remote)
+	}
+
+	// tunnels are opened one at a time as the client session isn't safe for concurrent refreshes
+	for c := range conns {
+		reqData := &requests.AppPortForward{
+			Pod:  pod,
+			Port: c.remote,
+		}
+
+		pf, err := cl.OpenAppPortForward(projectName, appName, reqData)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "port forward %d: %v\n", c.remote, err)
+			c.conn.Close()
+			continue
+		}
+
+		go func(conn net.Conn, remote int32) {
+			err := pf.Forward(conn)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "port forward %d: %v\n", remote, err)
+			}
+		}(c.conn, c.remote)
+	}
+
+	return nil
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/didil/kubexcloud/kxc-api/responses"
 	"github.com/didil/kubexcloud/kxc-cli/client"
 	"github.com/didil/kubexcloud/kxc-cli/config"
 	"github.com/manifoldco/promptui"
@@ -12,12 +15,18 @@ import (
 
 func buildAuthCmd() *cobra.Command {
 	var apiURL, userName, password string
+	var oidc bool
 
 	var authCmd = &cobra.Command{
 		Use:   "auth",
 		Short: "KubeXCloud Auth",
 		Run: func(cmd *cobra.Command, args []string) {
-			err := authRun(apiURL, userName, password)
+			var err error
+			if oidc {
+				err = authOIDCRun(apiURL)
+			} else {
+				err = authRun(apiURL, userName, password)
+			}
 			if err != nil {
 				log.Fatalf("run: %v", err)
 			}
@@ -27,11 +36,12 @@ func buildAuthCmd() *cobra.Command {
 	authCmd.Flags().StringVarP(&apiURL, "apiurl", "a", "", "apiurl")
 	authCmd.Flags().StringVarP(&userName, "username", "u", "", "username")
 	authCmd.Flags().StringVarP(&password, "password", "p", "", "password")
+	authCmd.Flags().BoolVar(&oidc, "oidc", false, "authenticate in the browser with the api OpenID Connect provider")
 
 	return authCmd
 }
 
-func authRun(apiURL, userName, password string) error {
+func promptApiURL(apiURL string) (string, error) {
 	// prompt for api endpoint if missing
 	if apiURL == "" {
 		prompt := promptui.Prompt{
@@ -40,12 +50,21 @@ func authRun(apiURL, userName, password string) error {
 
 		apiURLResult, err := prompt.Run()
 		if err != nil {
-			return fmt.Errorf("api url prompt failed: %v", err)
+			return "", fmt.Errorf("api url prompt failed: %v", err)
 		}
 
 		apiURL = apiURLResult
 	}
 
+	return apiURL, nil
+}
+
+func authRun(apiURL, userName, password string) error {
+	apiURL, err := promptApiURL(apiURL)
+	if err != nil {
+		return err
+	}
+
 	// prompt for username if missing
 	if userName == "" {
 		prompt := promptui.Prompt{
@@ -79,15 +98,56 @@ func authRun(apiURL, userName, password string) error {
 
 	fmt.Printf("Authenticating ...\n")
 
-	token, err := cl.LoginUser(apiURL, userName, password)
+	respData, err := cl.LoginUser(apiURL, userName, password)
+	if err != nil {
+		return fmt.Errorf("auth: %v", err)
+	}
+
+	return saveSession(apiURL, respData)
+}
+
+// oidc logins wait at most oidcLoginTimeout for the browser callback
+const oidcLoginTimeout = 5 * time.Minute
+
+func authOIDCRun(apiURL string) error {
+	apiURL, err := promptApiURL(apiURL)
+	if err != nil {
+		return err
+	}
+
+	cl := client.NewClient()
+
+	oidcConfig, err := cl.GetOIDCConfig(apiURL)
+	if err != nil {
+		return fmt.Errorf("get oidc config: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcLoginTimeout)
+	defer cancel()
+
+	idToken, err := cl.OIDCLogin(ctx, oidcConfig.Issuer, oidcConfig.ClientID, func(authURL string) {
+		fmt.Printf("Open this url in your browser to authenticate:\n\n%s\n\n", authURL)
+	})
+	if err != nil {
+		return fmt.Errorf("oidc: %v", err)
+	}
+
+	fmt.Printf("Authenticating ...\n")
+
+	respData, err := cl.LoginUserOIDC(apiURL, idToken)
 	if err != nil {
 		return fmt.Errorf("auth: %v", err)
 	}
 
+	return saveSession(apiURL, respData)
+}
+
+func saveSession(apiURL string, respData *responses.LoginUser) error {
 	config.SetApiUrl(apiURL)
-	config.SetAuthToken(token)
+	config.SetAuthToken(respData.Token)
+	config.SetRefreshToken(respData.RefreshToken)
 
-	err = config.WriteConfig()
+	err := config.WriteConfig()
 	if err != nil {
 		return fmt.Errorf("writeconfig: %v", err)
 	}
@@ -96,3 +156,41 @@ func authRun(apiURL, userName, password string) error {
 
 	return nil
 }
+
+func buildLogoutCmd() *cobra.Command {
+	var logoutCmd = &cobra.Command{
+		Use:   "logout",
+		Short: "KubeXCloud Logout, ends all the sessions of the current user",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := logoutRun()
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+		},
+	}
+
+	return logoutCmd
+}
+
+func logoutRun() error {
+	cl := client.NewClient()
+
+	fmt.Printf("Logging out ...\n")
+
+	err := cl.LogoutUser()
+	if err != nil {
+		return fmt.Errorf("logout: %v", err)
+	}
+
+	config.SetAuthToken("")
+	config.SetRefreshToken("")
+
+	err = config.WriteConfig()
+	if err != nil {
+		return fmt.Errorf("writeconfig: %v", err)
+	}
+
+	fmt.Printf("Logged out successfully\n")
+
+	return nil
+}
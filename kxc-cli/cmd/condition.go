@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/didil/kubexcloud/kxc-api/responses"
+)
+
+// formatReady formats the ready condition for tables, with the reason when not ready
+func formatReady(conditions []responses.Condition) string {
+	for _, c := range conditions {
+		if c.Type != "Ready" {
+			continue
+		}
+
+		if c.Status == "True" {
+			return c.Status
+		}
+		if c.Message != "" {
+			return fmt.Sprintf("%s (%s: %s)", c.Status, c.Reason, c.Message)
+		}
+		return fmt.Sprintf("%s (%s)", c.Status, c.Reason)
+	}
+
+	return "Unknown"
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
+	"github.com/didil/kubexcloud/kxc-api/requests"
 	"github.com/didil/kubexcloud/kxc-cli/client"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -19,6 +21,15 @@ func buildProjectsCmd() *cobra.Command {
 	projectsListCmd := buildProjectsListCmd()
 	projectsCmd.AddCommand(projectsListCmd)
 
+	projectDeleteCmd := buildProjectDeleteCmd()
+	projectsCmd.AddCommand(projectDeleteCmd)
+
+	projectQuotaCmd := buildProjectQuotaCmd()
+	projectsCmd.AddCommand(projectQuotaCmd)
+
+	projectMembersCmd := buildProjectMembersCmd()
+	projectsCmd.AddCommand(projectMembersCmd)
+
 	return projectsCmd
 }
 
@@ -48,12 +59,340 @@ func listProjectsRun() error {
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name"})
+	table.SetHeader([]string{"Name", "Role", "Ready"})
 
 	for _, proj := range projectsList.Projects {
-		table.Append([]string{proj.Name})
+		table.Append([]string{proj.Name, proj.Role, formatReady(proj.Conditions)})
 	}
 	table.Render()
 
 	return nil
 }
+
+func buildProjectDeleteCmd() *cobra.Command {
+	var yes bool
+
+	var projectDeleteCmd = &cobra.Command{
+		Use:   "delete <project>",
+		Short: "KubeXCloud Projects Delete",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("project name required")
+			}
+
+			err := deleteProjectRun(args[0], yes)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	projectDeleteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation")
+
+	return projectDeleteCmd
+}
+
+func deleteProjectRun(projectName string, yes bool) error {
+	if !yes {
+		confirmed, err := confirmDeletion(fmt.Sprintf("Delete Project %s and all its apps", projectName))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Printf("Aborted\n")
+			return nil
+		}
+	}
+
+	cl := client.NewClient()
+
+	fmt.Printf("Deleting Project %s ...\n", projectName)
+
+	err := cl.DeleteProject(projectName)
+	if err != nil {
+		return fmt.Errorf("delete project: %v", err)
+	}
+
+	fmt.Printf("Project deleted successfully\n")
+
+	return nil
+}
+
+func buildProjectQuotaCmd() *cobra.Command {
+	var remove bool
+	reqData := &requests.SetProjectQuota{}
+
+	var projectQuotaCmd = &cobra.Command{
+		Use:   "quota <project>",
+		Short: "KubeXCloud Projects Quota, shows the quota and usage or sets it with flags (admin only)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("project name required")
+			}
+
+			projectName := args[0]
+
+			var err error
+			switch {
+			case remove:
+				err = setProjectQuotaRun(projectName, &requests.SetProjectQuota{})
+			case cmd.Flags().NFlag() > 0:
+				err = setProjectQuotaRun(projectName, reqData)
+			default:
+				err = showProjectQuotaRun(projectName)
+			}
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	projectQuotaCmd.Flags().StringVar(&reqData.CPU, "cpu", "", "total cpu requests")
+	projectQuotaCmd.Flags().StringVar(&reqData.Memory, "memory", "", "total memory requests")
+	projectQuotaCmd.Flags().Int32Var(&reqData.Pods, "pods", 0, "max pods")
+	projectQuotaCmd.Flags().StringVar(&reqData.Storage, "storage", "", "total volumes storage")
+	projectQuotaCmd.Flags().Int32Var(&reqData.Apps, "apps", 0, "max apps")
+	projectQuotaCmd.Flags().BoolVar(&remove, "remove", false, "remove the quota")
+
+	return projectQuotaCmd
+}
+
+func setProjectQuotaRun(projectName string, reqData *requests.SetProjectQuota) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Setting Quota for Project %s ...\n", projectName)
+
+	err := cl.SetProjectQuota(projectName, reqData)
+	if err != nil {
+		return fmt.Errorf("set project quota: %v", err)
+	}
+
+	fmt.Printf("Project quota set successfully\n")
+
+	return nil
+}
+
+func showProjectQuotaRun(projectName string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Fetching Quota for Project %s ...\n", projectName)
+
+	projectsList, err := cl.ListProjects()
+	if err != nil {
+		return fmt.Errorf("list projects: %v", err)
+	}
+
+	for _, proj := range projectsList.Projects {
+		if proj.Name != projectName {
+			continue
+		}
+
+		if proj.Quota == nil {
+			fmt.Printf("No quota set\n")
+			return nil
+		}
+
+		var cpu, memory, storage string
+		var pods, apps int32
+		if proj.Usage != nil {
+			cpu, memory, storage = proj.Usage.CPU, proj.Usage.Memory, proj.Usage.Storage
+			pods, apps = proj.Usage.Pods, proj.Usage.Apps
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Resource", "Used", "Limit"})
+		table.Append([]string{"CPU", cpu, proj.Quota.CPU})
+		table.Append([]string{"Memory", memory, proj.Quota.Memory})
+		table.Append([]string{"Storage", storage, proj.Quota.Storage})
+		table.Append([]string{"Pods", strconv.Itoa(int(pods)), formatQuotaCount(proj.Quota.Pods)})
+		table.Append([]string{"Apps", strconv.Itoa(int(apps)), formatQuotaCount(proj.Quota.Apps)})
+		table.Render()
+
+		if proj.Quota.DefaultRequests != nil {
+			fmt.Printf("Default container requests: cpu %s, memory %s\n", proj.Quota.DefaultRequests.CPU, proj.Quota.DefaultRequests.Memory)
+		}
+		if proj.Quota.DefaultLimits != nil {
+			fmt.Printf("Default container limits: cpu %s, memory %s\n", proj.Quota.DefaultLimits.CPU, proj.Quota.DefaultLimits.Memory)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("project not found: %s", projectName)
+}
+
+// formatQuotaCount formats a count limit, 0 meaning unlimited
+func formatQuotaCount(n int32) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(n))
+}
+
+func buildProjectMembersCmd() *cobra.Command {
+	var projectMembersCmd = &cobra.Command{
+		Use:   "members",
+		Short: "KubeXCloud Projects Members",
+	}
+
+	projectMembersCmd.AddCommand(buildProjectMembersListCmd())
+	projectMembersCmd.AddCommand(buildProjectMemberAddCmd())
+	projectMembersCmd.AddCommand(buildProjectMemberSetRoleCmd())
+	projectMembersCmd.AddCommand(buildProjectMemberRemoveCmd())
+
+	return projectMembersCmd
+}
+
+func buildProjectMembersListCmd() *cobra.Command {
+	var projectMembersListCmd = &cobra.Command{
+		Use:   "list <project>",
+		Short: "KubeXCloud Projects Members List",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("project name required")
+			}
+
+			err := listProjectMembersRun(args[0])
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return projectMembersListCmd
+}
+
+func listProjectMembersRun(projectName string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Fetching Members for Project %s ...\n", projectName)
+
+	proj, err := cl.GetProject(projectName)
+	if err != nil {
+		return fmt.Errorf("get project: %v", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"User", "Role"})
+
+	for _, member := range proj.Members {
+		table.Append([]string{member.UserName, member.Role})
+	}
+	table.Render()
+
+	return nil
+}
+
+func buildProjectMemberAddCmd() *cobra.Command {
+	var projectMemberAddCmd = &cobra.Command{
+		Use:   "add <project> <user> <role>",
+		Short: "KubeXCloud Projects Member Add, role is one of owner, developer or viewer",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 3 {
+				return fmt.Errorf("project name, user name and role required")
+			}
+
+			err := addProjectMemberRun(args[0], args[1], args[2])
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return projectMemberAddCmd
+}
+
+func addProjectMemberRun(projectName, userName, role string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Adding %s as %s to Project %s ...\n", userName, role, projectName)
+
+	err := cl.AddProjectMember(projectName, userName, role)
+	if err != nil {
+		return fmt.Errorf("add project member: %v", err)
+	}
+
+	fmt.Printf("Member added successfully\n")
+
+	return nil
+}
+
+func buildProjectMemberSetRoleCmd() *cobra.Command {
+	var projectMemberSetRoleCmd = &cobra.Command{
+		Use:   "set-role <project> <user> <role>",
+		Short: "KubeXCloud Projects Member Set Role, role is one of owner, developer or viewer",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 3 {
+				return fmt.Errorf("project name, user name and role required")
+			}
+
+			err := setProjectMemberRoleRun(args[0], args[1], args[2])
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return projectMemberSetRoleCmd
+}
+
+func setProjectMemberRoleRun(projectName, userName, role string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Setting role of %s to %s in Project %s ...\n", userName, role, projectName)
+
+	err := cl.UpdateProjectMember(projectName, userName, role)
+	if err != nil {
+		return fmt.Errorf("update project member: %v", err)
+	}
+
+	fmt.Printf("Member role updated successfully\n")
+
+	return nil
+}
+
+func buildProjectMemberRemoveCmd() *cobra.Command {
+	var projectMemberRemoveCmd = &cobra.Command{
+		Use:   "remove <project> <user>",
+		Short: "KubeXCloud Projects Member Remove",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return fmt.Errorf("project name and user name required")
+			}
+
+			err := removeProjectMemberRun(args[0], args[1])
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return projectMemberRemoveCmd
+}
+
+func removeProjectMemberRun(projectName, userName string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Removing %s from Project %s ...\n", userName, projectName)
+
+	err := cl.RemoveProjectMember(projectName, userName)
+	if err != nil {
+		return fmt.Errorf("remove project member: %v", err)
+	}
+
+	fmt.Printf("Member removed successfully\n")
+
+	return nil
+}
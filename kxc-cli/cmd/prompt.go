@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+)
+
+// confirmDeletion asks the user to confirm a deletion, returns false if declined
+func confirmDeletion(label string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+
+	_, err := prompt.Run()
+	if err == promptui.ErrAbort {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("confirmation prompt failed: %v", err)
+	}
+
+	return true, nil
+}
@@ -24,6 +24,9 @@ func Execute() error {
 	authCmd := buildAuthCmd()
 	rootCmd.AddCommand(authCmd)
 
+	logoutCmd := buildLogoutCmd()
+	rootCmd.AddCommand(logoutCmd)
+
 	projectsCmd := buildProjectsCmd()
 	rootCmd.AddCommand(projectsCmd)
 
@@ -33,6 +36,9 @@ func Execute() error {
 	usersCmd := buildUsersCmd()
 	rootCmd.AddCommand(usersCmd)
 
+	tokensCmd := buildTokensCmd()
+	rootCmd.AddCommand(tokensCmd)
+
 	err = rootCmd.Execute()
 	if err != nil {
 		return fmt.Errorf("execute: %v", err)
@@ -0,0 +1,48 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/didil/kubexcloud/kxc-cli/client"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// watchTerminalSize sends the terminal size to resizes now and on each SIGWINCH until stop is called
+func watchTerminalSize(fd int, resizes chan<- client.TerminalSize) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGWINCH)
+	done := make(chan struct{})
+
+	send := func() {
+		width, height, err := terminal.GetSize(fd)
+		if err != nil {
+			return
+		}
+		select {
+		case resizes <- client.TerminalSize{Width: uint16(width), Height: uint16(height)}:
+		default:
+		}
+	}
+
+	send()
+	go func() {
+		for {
+			select {
+			case <-sigs:
+				send()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"github.com/didil/kubexcloud/kxc-cli/client"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// watchTerminalSize sends the terminal size to resizes once, windows has no resize signal
+func watchTerminalSize(fd int, resizes chan<- client.TerminalSize) (stop func()) {
+	width, height, err := terminal.GetSize(fd)
+	if err == nil {
+		resizes <- client.TerminalSize{Width: uint16(width), Height: uint16(height)}
+	}
+
+	return func() {}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/didil/kubexcloud/kxc-api/requests"
+	"github.com/didil/kubexcloud/kxc-cli/client"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func buildTokensCmd() *cobra.Command {
+	var tokensCmd = &cobra.Command{
+		Use:   "tokens",
+		Short: "KubeXCloud Personal Access Tokens",
+	}
+
+	tokensCreateCmd := buildTokensCreateCmd()
+	tokensCmd.AddCommand(tokensCreateCmd)
+
+	tokensListCmd := buildTokensListCmd()
+	tokensCmd.AddCommand(tokensListCmd)
+
+	tokensRevokeCmd := buildTokensRevokeCmd()
+	tokensCmd.AddCommand(tokensRevokeCmd)
+
+	return tokensCmd
+}
+
+func buildTokensCreateCmd() *cobra.Command {
+	reqData := &requests.CreateToken{}
+
+	var tokensCreateCmd = &cobra.Command{
+		Use:   "create <name>",
+		Short: "KubeXCloud Tokens Create, the token can be used with the KXC_TOKEN env variable",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("token name required")
+			}
+
+			reqData.Name = args[0]
+
+			err := createTokenRun(reqData)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	tokensCreateCmd.Flags().IntVar(&reqData.ExpiresInDays, "expires-in-days", 0, "days before the token expires, never if not set")
+	tokensCreateCmd.Flags().BoolVar(&reqData.ReadOnly, "read-only", false, "only allow read requests")
+	tokensCreateCmd.Flags().StringSliceVar(&reqData.Projects, "project", nil, "limit the token to projects, can be repeated")
+
+	return tokensCreateCmd
+}
+
+func createTokenRun(reqData *requests.CreateToken) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Creating Token %s ...\n", reqData.Name)
+
+	respData, err := cl.CreateToken(reqData)
+	if err != nil {
+		return fmt.Errorf("create token: %v", err)
+	}
+
+	fmt.Printf("Token created successfully, it won't be shown again:\n%s\n", respData.Token)
+
+	return nil
+}
+
+func buildTokensListCmd() *cobra.Command {
+	var tokensListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "KubeXCloud Tokens List",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := listTokensRun()
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+		},
+	}
+
+	return tokensListCmd
+}
+
+func listTokensRun() error {
+	cl := client.NewClient()
+
+	fmt.Printf("Fetching Tokens ...\n")
+
+	tokensList, err := cl.ListTokens()
+	if err != nil {
+		return fmt.Errorf("list tokens: %v", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Name", "Read Only", "Projects", "Created", "Expires"})
+
+	for _, token := range tokensList.Tokens {
+		readOnly := "no"
+		if token.ReadOnly {
+			readOnly = "yes"
+		}
+
+		projects := "all"
+		if len(token.Projects) > 0 {
+			projects = strings.Join(token.Projects, ", ")
+		}
+
+		expires := "never"
+		if token.ExpiresAt != nil {
+			expires = token.ExpiresAt.Format(time.RFC3339)
+		}
+
+		table.Append([]string{token.ID, token.Name, readOnly, projects, token.CreatedAt.Format(time.RFC3339), expires})
+	}
+	table.Render()
+
+	return nil
+}
+
+func buildTokensRevokeCmd() *cobra.Command {
+	var tokensRevokeCmd = &cobra.Command{
+		Use:   "revoke <id>",
+		Short: "KubeXCloud Tokens Revoke",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("token id required")
+			}
+
+			err := revokeTokenRun(args[0])
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return tokensRevokeCmd
+}
+
+func revokeTokenRun(tokenID string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Revoking Token %s ...\n", tokenID)
+
+	err := cl.RevokeToken(tokenID)
+	if err != nil {
+		return fmt.Errorf("revoke token: %v", err)
+	}
+
+	fmt.Printf("Token revoked successfully\n")
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/didil/kubexcloud/kxc-cli/client"
 	"github.com/olekukonko/tablewriter"
@@ -22,6 +23,12 @@ func buildUsersCmd() *cobra.Command {
 	usersListCmd := buildUsersListCmd()
 	usersCmd.AddCommand(usersListCmd)
 
+	usersDeleteCmd := buildUsersDeleteCmd()
+	usersCmd.AddCommand(usersDeleteCmd)
+
+	usersUnlockCmd := buildUsersUnlockCmd()
+	usersCmd.AddCommand(usersUnlockCmd)
+
 	return usersCmd
 }
 
@@ -98,12 +105,104 @@ func listUsersRun() error {
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name", "Role"})
+	table.SetHeader([]string{"Name", "Role", "Ready", "Locked Until"})
 
 	for _, user := range usersList.Users {
-		table.Append([]string{user.Name, user.Role})
+		lockedUntil := ""
+		if user.LockedUntil != nil {
+			lockedUntil = user.LockedUntil.Format(time.RFC3339)
+		}
+
+		table.Append([]string{user.Name, user.Role, formatReady(user.Conditions), lockedUntil})
 	}
 	table.Render()
 
 	return nil
 }
+
+func buildUsersDeleteCmd() *cobra.Command {
+	var yes bool
+
+	var usersDeleteCmd = &cobra.Command{
+		Use:   "delete <username>",
+		Short: "KubeXCloud Users Delete (admin only)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("username required")
+			}
+
+			err := deleteUsersRun(args[0], yes)
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	usersDeleteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation")
+
+	return usersDeleteCmd
+}
+
+func deleteUsersRun(userName string, yes bool) error {
+	if !yes {
+		confirmed, err := confirmDeletion(fmt.Sprintf("Delete User %s", userName))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Printf("Aborted\n")
+			return nil
+		}
+	}
+
+	cl := client.NewClient()
+
+	fmt.Printf("Deleting User %s ...\n", userName)
+
+	err := cl.DeleteUser(userName)
+	if err != nil {
+		return fmt.Errorf("delete user: %v", err)
+	}
+
+	fmt.Printf("User deleted successfully\n")
+
+	return nil
+}
+
+func buildUsersUnlockCmd() *cobra.Command {
+	var usersUnlockCmd = &cobra.Command{
+		Use:   "unlock <username>",
+		Short: "KubeXCloud Users Unlock, lets a user locked out after failed logins log in again (admin only)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("username required")
+			}
+
+			err := unlockUsersRun(args[0])
+			if err != nil {
+				log.Fatalf("run: %v", err)
+			}
+
+			return nil
+		},
+	}
+
+	return usersUnlockCmd
+}
+
+func unlockUsersRun(userName string) error {
+	cl := client.NewClient()
+
+	fmt.Printf("Unlocking User %s ...\n", userName)
+
+	err := cl.UnlockUser(userName)
+	if err != nil {
+		return fmt.Errorf("unlock user: %v", err)
+	}
+
+	fmt.Printf("User unlocked successfully\n")
+
+	return nil
+}
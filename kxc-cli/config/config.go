@@ -26,6 +26,16 @@ func InitConfig() error {
 
 	viper.AddConfigPath(configPath)
 
+	// allow ci pipelines to pass credentials without a config file
+	err = viper.BindEnv(apiURLKey, "KXC_API_URL")
+	if err != nil {
+		return fmt.Errorf("bind env: %v", err)
+	}
+	err = viper.BindEnv(authTokenKey, "KXC_TOKEN")
+	if err != nil {
+		return fmt.Errorf("bind env: %v", err)
+	}
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -41,6 +51,7 @@ func InitConfig() error {
 
 const apiURLKey = "apiURL"
 const authTokenKey = "authToken"
+const refreshTokenKey = "refreshToken"
 
 func SetApiUrl(apiURL string) {
 	viper.Set(apiURLKey, apiURL)
@@ -50,6 +61,10 @@ func SetAuthToken(authToken string) {
 	viper.Set(authTokenKey, authToken)
 }
 
+func SetRefreshToken(refreshToken string) {
+	viper.Set(refreshTokenKey, refreshToken)
+}
+
 func GetApiUrl() string {
 	return viper.GetString(apiURLKey)
 }
@@ -58,6 +73,10 @@ func GetAuthToken() string {
 	return viper.GetString(authTokenKey)
 }
 
+func GetRefreshToken() string {
+	return viper.GetString(refreshTokenKey)
+}
+
 func WriteConfig() error {
 	err := viper.WriteConfig()
 	if _, ok := err.(viper.ConfigFileNotFoundError); ok {
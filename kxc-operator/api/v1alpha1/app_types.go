@@ -33,8 +33,72 @@ type AppSpec struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinItems=1
 	Containers []Container `json:"containers"`
+
+	Volumes []Volume `json:"volumes,omitempty"`
+
+	// when set, replicas are managed by a HorizontalPodAutoscaler and the replicas field is ignored
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+
+	// custom hostnames routed to the externally exposed port, in addition to the default one
+	Domains []string `json:"domains,omitempty"`
+
+	// tls through cert-manager for the externally exposed port,
+	// defaults to the operator wildcard certificate when configured
+	TLS *TLS `json:"tls,omitempty"`
+}
+
+// TLS object, only one of issuer or clusterIssuer can be set
+type TLS struct {
+	// cert-manager Issuer in the app namespace
+	Issuer string `json:"issuer,omitempty"`
+	// cert-manager ClusterIssuer
+	ClusterIssuer string `json:"clusterIssuer,omitempty"`
+}
+
+// Autoscaling object
+type Autoscaling struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas int32 `json:"minReplicas"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// average cpu usage across pods, relative to the containers cpu requests
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	TargetCPUUtilizationPercentage int32 `json:"targetCPUUtilizationPercentage"`
 }
 
+// Volume object, backed by a PersistentVolumeClaim created by the app
+type Volume struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// storage size e.g. 1Gi, can be increased but not decreased
+	// +kubebuilder:validation:Required
+	Size string `json:"size"`
+
+	// defaults to ReadWriteOnce, can't be changed once created
+	// +kubebuilder:validation:Enum=ReadWriteOnce;ReadOnlyMany;ReadWriteMany
+	AccessMode corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+
+	// defaults to the cluster default storage class, can't be changed once created
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// Delete (default) removes the claim along with the app, Retain keeps it
+	// +kubebuilder:validation:Enum=Delete;Retain
+	ReclaimPolicy VolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
+
+// VolumeReclaimPolicy describes what happens to a volume claim when the app or volume is removed
+type VolumeReclaimPolicy string
+
+const (
+	VolumeReclaimDelete VolumeReclaimPolicy = "Delete"
+	VolumeReclaimRetain VolumeReclaimPolicy = "Retain"
+)
+
 // Container object
 type Container struct {
 	// +kubebuilder:validation:Required
@@ -45,6 +109,27 @@ type Container struct {
 	Command []string `json:"command,omitempty"`
 
 	Ports []Port `json:"ports,omitempty"`
+
+	Env []EnvVar `json:"env,omitempty"`
+
+	Resources *Resources `json:"resources,omitempty"`
+
+	LivenessProbe  *Probe `json:"livenessProbe,omitempty"`
+	ReadinessProbe *Probe `json:"readinessProbe,omitempty"`
+	StartupProbe   *Probe `json:"startupProbe,omitempty"`
+
+	VolumeMounts []VolumeMount `json:"volumeMounts,omitempty"`
+}
+
+// VolumeMount object
+type VolumeMount struct {
+	// name of an app volume
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	MountPath string `json:"mountPath"`
+
+	ReadOnly bool `json:"readOnly,omitempty"`
 }
 
 // Port object
@@ -58,13 +143,111 @@ type Port struct {
 
 	// only valid for http (through TCP protocol)
 	ExposeExternally bool `json:"exposeExternally"`
+
+	// url path prefix routed to this port, defaults to /
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// suffix appended to the app default host label, routes a dedicated host to this port
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	HostSuffix string `json:"hostSuffix,omitempty"`
+}
+
+// EnvVar object
+type EnvVar struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// literal value, exclusive with the refs below
+	Value string `json:"value,omitempty"`
+
+	// reference to a key of a Secret in the app namespace
+	SecretKeyRef *KeyRef `json:"secretKeyRef,omitempty"`
+
+	// reference to a key of a ConfigMap in the app namespace
+	ConfigMapKeyRef *KeyRef `json:"configMapKeyRef,omitempty"`
+}
+
+// KeyRef object
+type KeyRef struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+}
+
+// Resources object
+type Resources struct {
+	Requests *ResourceList `json:"requests,omitempty"`
+	Limits   *ResourceList `json:"limits,omitempty"`
+}
+
+// ResourceList object, values are kubernetes quantities e.g. 250m, 512Mi
+type ResourceList struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// Probe object, exactly one of httpGet, tcpSocket or exec must be set
+type Probe struct {
+	HTTPGet   *HTTPGetProbe   `json:"httpGet,omitempty"`
+	TCPSocket *TCPSocketProbe `json:"tcpSocket,omitempty"`
+	Exec      *ExecProbe      `json:"exec,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// HTTPGetProbe object
+type HTTPGetProbe struct {
+	Path string `json:"path,omitempty"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+}
+
+// TCPSocketProbe object
+type TCPSocketProbe struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+}
+
+// ExecProbe object
+type ExecProbe struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
 }
 
 // AppStatus defines the observed state of App
 type AppStatus struct {
-	ExternalURL         string `json:"externalUrl,omitempty"`
-	AvailableReplicas   int32  `json:"availableReplicas,omitempty"`
-	UnavailableReplicas int32  `json:"unavailableReplicas,omitempty"`
+	// first external url, kept for display
+	ExternalURL string `json:"externalUrl,omitempty"`
+	// urls of every exposed port on every host
+	ExternalURLs []string `json:"externalUrls,omitempty"`
+
+	AvailableReplicas   int32 `json:"availableReplicas,omitempty"`
+	UnavailableReplicas int32 `json:"unavailableReplicas,omitempty"`
+
+	// reported by the HorizontalPodAutoscaler when autoscaling is enabled
+	CurrentReplicas int32 `json:"currentReplicas,omitempty"`
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+
+	// generation of the app last processed by the reconciler
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -72,6 +255,7 @@ type AppStatus struct {
 // +kubebuilder:printcolumn:name="ExternalURL",type=string,JSONPath=`.status.externalUrl`
 // +kubebuilder:printcolumn:name="AvailableReplicas",type=integer,JSONPath=`.status.availableReplicas`
 // +kubebuilder:printcolumn:name="UnavailableReplicas",type=integer,JSONPath=`.status.unavailableReplicas`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 
 // App is the Schema for the apps API
 type App struct {
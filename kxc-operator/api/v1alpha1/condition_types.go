@@ -0,0 +1,94 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types
+const (
+	// ConditionReady is true when the resource is fully reconciled and available
+	ConditionReady = "Ready"
+	// ConditionProgressing is true while the resource is being reconciled or rolled out
+	ConditionProgressing = "Progressing"
+)
+
+// Condition contains details for one aspect of the current state of a resource,
+// it mirrors metav1.Condition which isn't available in our apimachinery version
+type Condition struct {
+	// +kubebuilder:validation:Required
+	Type string `json:"type"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	Status metav1.ConditionStatus `json:"status"`
+	// generation of the resource the condition was set for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// last time the condition status changed
+	// +kubebuilder:validation:Required
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// CamelCase reason for the last transition
+	// +kubebuilder:validation:Required
+	Reason string `json:"reason"`
+	// human readable details about the transition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SetCondition adds or updates newCondition in conditions,
+// the transition time is only changed when the status changes.
+// Returns true if conditions were changed
+func SetCondition(conditions *[]Condition, newCondition Condition) bool {
+	existing := FindCondition(*conditions, newCondition.Type)
+	if existing == nil {
+		if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, newCondition)
+		return true
+	}
+
+	if existing.Status == newCondition.Status && existing.Reason == newCondition.Reason &&
+		existing.Message == newCondition.Message && existing.ObservedGeneration == newCondition.ObservedGeneration {
+		return false
+	}
+
+	if existing.Status != newCondition.Status {
+		existing.Status = newCondition.Status
+		existing.LastTransitionTime = newCondition.LastTransitionTime
+		if existing.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		}
+	}
+	existing.Reason = newCondition.Reason
+	existing.Message = newCondition.Message
+	existing.ObservedGeneration = newCondition.ObservedGeneration
+
+	return true
+}
+
+// FindCondition returns the condition of the given type or nil if not found
+func FindCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+
+	return nil
+}
@@ -25,15 +25,104 @@ import (
 
 // ProjectSpec defines the desired state of Project
 type ProjectSpec struct {
+	// users allowed to access the project, projects created before members were introduced
+	// are owned by the user of their user_account_cr label
+	// +optional
+	Members []ProjectMember `json:"members,omitempty"`
+
+	// resource limits of the project namespace, unlimited when not set
+	// +optional
+	Quota *ProjectQuota `json:"quota,omitempty"`
+}
+
+// ProjectRole is the role of a member in a project
+// +kubebuilder:validation:Enum=owner;developer;viewer
+type ProjectRole string
+
+const (
+	// ProjectRoleOwner can manage apps and members and delete the project
+	ProjectRoleOwner ProjectRole = "owner"
+	// ProjectRoleDeveloper can manage apps
+	ProjectRoleDeveloper ProjectRole = "developer"
+	// ProjectRoleViewer can only read
+	ProjectRoleViewer ProjectRole = "viewer"
+)
+
+// ProjectMember object
+type ProjectMember struct {
+	// +kubebuilder:validation:Required
+	UserName string `json:"userName"`
+	// +kubebuilder:validation:Required
+	Role ProjectRole `json:"role"`
 }
 
+// ProjectQuota object
+type ProjectQuota struct {
+	// total cpu requests, e.g. 2 or 500m
+	// +optional
+	CPU string `json:"cpu,omitempty"`
+	// total memory requests, e.g. 4Gi
+	// +optional
+	Memory string `json:"memory,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Pods int32 `json:"pods,omitempty"`
+	// total persistent volume claims storage, e.g. 10Gi
+	// +optional
+	Storage string `json:"storage,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Apps int32 `json:"apps,omitempty"`
+
+	// requests and limits applied to containers that don't set them
+	// +optional
+	DefaultRequests *ResourceList `json:"defaultRequests,omitempty"`
+	// +optional
+	DefaultLimits *ResourceList `json:"defaultLimits,omitempty"`
+}
+
+// ProjectUsage object
+type ProjectUsage struct {
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Pods    int32  `json:"pods,omitempty"`
+	Storage string `json:"storage,omitempty"`
+	Apps    int32  `json:"apps,omitempty"`
+}
+
+// ProjectPhase is the lifecycle phase of a project
+// +kubebuilder:validation:Enum=Active;Terminating
+type ProjectPhase string
+
+const (
+	// ProjectActive means the project resources are provisioned
+	ProjectActive ProjectPhase = "Active"
+	// ProjectTerminating means the project is being deleted and its resources cleaned up
+	ProjectTerminating ProjectPhase = "Terminating"
+)
+
 // ProjectStatus defines the observed state of Project
 type ProjectStatus struct {
+	// +optional
+	Phase ProjectPhase `json:"phase,omitempty"`
+	// number of apps left to delete while terminating
+	// +optional
+	RemainingApps int32 `json:"remainingApps,omitempty"`
+	// resources used in the project namespace, reported when a quota is set
+	// +optional
+	Usage *ProjectUsage `json:"usage,omitempty"`
+
+	// generation of the project last processed by the reconciler
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope="Cluster"
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 
 // Project is the Schema for the projects API
 type Project struct {
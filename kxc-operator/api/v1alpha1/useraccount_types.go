@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,20 +26,47 @@ import (
 
 // UserAccountSpec defines the desired state of UserAccount
 type UserAccountSpec struct {
-	Password string `json:"password"`
+	// Deprecated: bcrypt hash of the password, moved to the secret referenced by passwordSecretRef
+	// by the user account reconciler
+	// +optional
+	Password string `json:"password,omitempty"`
+	// secret in the kxc system namespace holding the password hash
+	// +optional
+	PasswordSecretRef *corev1.SecretReference `json:"passwordSecretRef,omitempty"`
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Enum=regular;admin
 	Role string `json:"role"`
+	// identity provider the user authenticates with, local password if not set
+	// +kubebuilder:validation:Enum=local;oidc;ldap
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// subject of the external identity, later logins with another identity of the same user name are refused
+	// +optional
+	Subject string `json:"subject,omitempty"`
+	// incremented to revoke all the sessions of the user, personal access tokens are revoked separately
+	// +optional
+	TokenVersion int64 `json:"tokenVersion,omitempty"`
 }
 
 // UserAccountStatus defines the observed state of UserAccount
 type UserAccountStatus struct {
+	// generation of the user account last processed by the reconciler
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// consecutive failed password logins, reset on success or unlock
+	// +optional
+	FailedLoginAttempts int32 `json:"failedLoginAttempts,omitempty"`
+	// password logins are refused until this time
+	// +optional
+	LockedUntil *metav1.Time `json:"lockedUntil,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope="Cluster"
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Role",type=string,JSONPath=`.spec.role`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 
 // UserAccount is the Schema for the useraccounts API
 type UserAccount struct {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -33,6 +34,7 @@ import (
 	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	netv1beta1 "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,6 +51,10 @@ type AppReconciler struct {
 // +kubebuilder:rbac:groups=cloud.kubexcloud.com,resources=apps/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;create;update;delete
 
 func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -63,13 +69,159 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
 			log.Info("App resource not found. Ignoring since object must be deleted")
-			return ctrl.Result{}, nil
+
+			// the wildcard certificate copy isn't owned by the app, drop it with the last app using it
+			_, err = r.cleanupWildcardTLSSecret(ctx, log, req.Namespace)
+			return ctrl.Result{}, err
 		}
 		// Error reading the object - requeue the request.
 		log.Error(err, "Failed to get app")
 		return ctrl.Result{}, err
 	}
 
+	result, err := r.reconcileApp(ctx, log, app)
+	if err != nil {
+		// conflicts only mean we worked on a stale object, don't report them
+		if errors.IsConflict(err) {
+			return result, err
+		}
+
+		conditionsChanged := setReconcileFailedConditions(&app.Status.Conditions, app.Generation, err)
+		if conditionsChanged || app.Status.ObservedGeneration != app.Generation {
+			app.Status.ObservedGeneration = app.Generation
+
+			if statusErr := r.Status().Update(ctx, app); statusErr != nil {
+				log.Error(statusErr, "Failed to update app status conditions")
+			}
+		}
+		return result, err
+	}
+	if result.Requeue {
+		return result, nil
+	}
+
+	// all resources are in place, report the rollout state
+	dep := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, dep)
+	if err != nil {
+		log.Error(err, "Failed to get Deployment")
+		return ctrl.Result{}, err
+	}
+
+	ready, progressing, reason, message := AppRolloutState(dep)
+	conditionsChanged := setReconciledConditions(&app.Status.Conditions, app.Generation, ready, progressing, reason, message)
+	if conditionsChanged || app.Status.ObservedGeneration != app.Generation {
+		app.Status.ObservedGeneration = app.Generation
+
+		err := r.Status().Update(ctx, app)
+		if err != nil {
+			log.Error(err, "Failed to update app status conditions")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileApp creates or updates the app resources one at a time, requeuing after each change
+func (r *AppReconciler) reconcileApp(ctx context.Context, log logr.Logger, app *cloudv1alpha1.App) (ctrl.Result, error) {
+	var err error
+
+	// Check if the volume claims already exist, if not create new ones
+	for _, v := range app.Spec.Volumes {
+		claimName := appVolumeClaimName(app.Name, v.Name)
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = r.Get(ctx, types.NamespacedName{Name: claimName, Namespace: app.Namespace}, pvc)
+		if err != nil && errors.IsNotFound(err) {
+			// Define a new claim
+			pvc, err := r.pvcForAppVolume(app, v)
+			if err != nil {
+				log.Error(err, "Failed to build new PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", app.Namespace, "PersistentVolumeClaim.Name", claimName)
+				return ctrl.Result{}, err
+			}
+
+			log.Info("Creating a new PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
+			err = r.Create(ctx, pvc)
+			if err != nil {
+				log.Error(err, "Failed to create new PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
+				return ctrl.Result{}, err
+			}
+
+			// Claim created successfully - return and requeue
+			return ctrl.Result{Requeue: true}, nil
+		} else if err != nil {
+			log.Error(err, "Failed to get PersistentVolumeClaim")
+			return ctrl.Result{}, err
+		}
+
+		targetPVC, err := r.pvcForAppVolume(app, v)
+		if err != nil {
+			log.Error(err, "Failed to build target PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
+			return ctrl.Result{}, err
+		}
+
+		// expand the claim if the requested size increased, claims can't be shrunk
+		size, targetSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage], targetPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+		if size.Cmp(targetSize) < 0 {
+			log.Info("Expanding PersistentVolumeClaim",
+				"PersistentVolumeClaim.Namespace", pvc.Namespace,
+				"PersistentVolumeClaim.Name", pvc.Name,
+				"old", size.String(),
+				"new", targetSize.String())
+
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = targetSize
+			err = r.Update(ctx, pvc)
+			if err != nil {
+				log.Error(err, "Failed to update PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
+				return ctrl.Result{}, err
+			}
+			// Spec updated - return and requeue
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		// ensure the claim is owned by the app only if it should be deleted with it
+		if metav1.IsControlledBy(pvc, app) != metav1.IsControlledBy(targetPVC, app) {
+			pvc.OwnerReferences = targetPVC.OwnerReferences
+
+			log.Info("Updating PersistentVolumeClaim reclaim policy",
+				"PersistentVolumeClaim.Namespace", pvc.Namespace,
+				"PersistentVolumeClaim.Name", pvc.Name,
+				"policy", appVolumeReclaimPolicy(v))
+
+			err = r.Update(ctx, pvc)
+			if err != nil {
+				log.Error(err, "Failed to update PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
+				return ctrl.Result{}, err
+			}
+			// Spec updated - return and requeue
+			return ctrl.Result{Requeue: true}, nil
+		}
+	}
+
+	// delete the owned claims of volumes removed from the app, retained claims are left alone
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	err = r.List(ctx, pvcList, client.InNamespace(app.Namespace), client.MatchingLabels(LabelsForApp(AppProjectName(app), app.Name)))
+	if err != nil {
+		log.Error(err, "Failed to list PersistentVolumeClaims")
+		return ctrl.Result{}, err
+	}
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		if !metav1.IsControlledBy(pvc, app) || appHasVolumeClaim(app, pvc.Name) {
+			continue
+		}
+
+		log.Info("Deleting PersistentVolumeClaim of removed volume", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
+		err = r.Delete(ctx, pvc)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
+			return ctrl.Result{}, err
+		}
+		// Claim deleted - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	// Check if the deployment already exists, if not create a new one
 	dep := &appsv1.Deployment{}
 	err = r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, dep)
@@ -77,7 +229,7 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		// Define a new deployment
 		dep, err := r.deploymentForApp(app)
 		if err != nil {
-			log.Error(err, "Failed to build new Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
+			log.Error(err, "Failed to build new Deployment", "Deployment.Namespace", app.Namespace, "Deployment.Name", app.Name)
 			return ctrl.Result{}, err
 		}
 
@@ -101,8 +253,8 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
-	// Ensure the deployment replicas are the same
-	if *dep.Spec.Replicas != *targetDep.Spec.Replicas {
+	// Ensure the deployment replicas are the same, unless they are managed by the autoscaler
+	if app.Spec.Autoscaling == nil && *dep.Spec.Replicas != *targetDep.Spec.Replicas {
 		log.Info("Updating deployment size",
 			"Deployment.Namespace", dep.Namespace,
 			"Deployment.Name", dep.Name,
@@ -122,6 +274,8 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	// ensure the deployment containers are the same
 	if !r.containersEqual(dep.Spec.Template.Spec.Containers, targetDep.Spec.Template.Spec.Containers) {
 		dep.Spec.Template.Spec.Containers = targetDep.Spec.Template.Spec.Containers
+		// volume mounts must match the pod volumes
+		dep.Spec.Template.Spec.Volumes = targetDep.Spec.Template.Spec.Volumes
 
 		log.Info("Updating deployment containers",
 			"Deployment.Namespace", dep.Namespace,
@@ -136,6 +290,24 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// ensure the deployment volumes are the same
+	if !r.volumesEqual(dep.Spec.Template.Spec.Volumes, targetDep.Spec.Template.Spec.Volumes) {
+		dep.Spec.Template.Spec.Volumes = targetDep.Spec.Template.Spec.Volumes
+		dep.Spec.Template.Spec.Containers = targetDep.Spec.Template.Spec.Containers
+
+		log.Info("Updating deployment volumes",
+			"Deployment.Namespace", dep.Namespace,
+			"Deployment.Name", dep.Name)
+
+		err = r.Update(ctx, dep)
+		if err != nil {
+			log.Error(err, "Failed to update Deployment volumes", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
+			return ctrl.Result{}, err
+		}
+		// Spec updated - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	// ensure the deployment template restart annotations are the same
 	if dep.Spec.Template.Annotations[AppRestartAnnotationKey] != app.Annotations[AppRestartAnnotationKey] {
 		if dep.Spec.Template.Annotations == nil {
@@ -156,6 +328,68 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	hpa := &autoscalingv1.HorizontalPodAutoscaler{}
+	err = r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, hpa)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get HorizontalPodAutoscaler")
+		return ctrl.Result{}, err
+	}
+	hpaFound := err == nil
+
+	if app.Spec.Autoscaling != nil {
+		// Check if the autoscaler already exists, if not create a new one
+		if !hpaFound {
+			// Define a new autoscaler
+			hpa, err := r.hpaForApp(app)
+			if err != nil {
+				log.Error(err, "Failed to build new HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", app.Namespace, "HorizontalPodAutoscaler.Name", app.Name)
+				return ctrl.Result{}, err
+			}
+
+			log.Info("Creating a new HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+			err = r.Create(ctx, hpa)
+			if err != nil {
+				log.Error(err, "Failed to create new HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+				return ctrl.Result{}, err
+			}
+
+			// Autoscaler created successfully - return and requeue
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		targetHPA, err := r.hpaForApp(app)
+		if err != nil {
+			log.Error(err, "Failed to build target HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+			return ctrl.Result{}, err
+		}
+
+		// ensure the autoscaler bounds and target are the same
+		if !r.hpaSpecEqual(hpa.Spec, targetHPA.Spec) {
+			hpa.Spec = targetHPA.Spec
+
+			log.Info("Updating HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+
+			err = r.Update(ctx, hpa)
+			if err != nil {
+				log.Error(err, "Failed to update HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+				return ctrl.Result{}, err
+			}
+			// Spec updated - return and requeue
+			return ctrl.Result{Requeue: true}, nil
+		}
+	} else if hpaFound && metav1.IsControlledBy(hpa, app) {
+		// autoscaling was disabled, hand replicas back to the app spec
+		log.Info("Deleting HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+
+		err = r.Delete(ctx, hpa)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+			return ctrl.Result{}, err
+		}
+		// Autoscaler deleted - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	// Check if the service already exists, if not create a new one
 	svc := &corev1.Service{}
 	err = r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, svc)
@@ -202,7 +436,53 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	if portToExpose := appPortToExposeExternally(app); portToExpose != nil {
+	if len(appPortsToExposeExternally(app)) > 0 {
+		// copy the wildcard certificate to the app namespace, ingresses can only reference local secrets
+		if appUsesWildcardTLS(app) {
+			srcNamespace, srcName := wildcardTLSSecret()
+
+			srcSecret := &corev1.Secret{}
+			err = r.Get(ctx, types.NamespacedName{Name: srcName, Namespace: srcNamespace}, srcSecret)
+			if err != nil {
+				log.Error(err, "Failed to get wildcard TLS Secret", "Secret.Namespace", srcNamespace, "Secret.Name", srcName)
+				return ctrl.Result{}, err
+			}
+
+			secret := &corev1.Secret{}
+			err = r.Get(ctx, types.NamespacedName{Name: wildcardTLSSecretCopyName, Namespace: app.Namespace}, secret)
+			if err != nil && errors.IsNotFound(err) {
+				secret = wildcardTLSSecretCopy(srcSecret, app.Namespace)
+
+				log.Info("Creating a new wildcard TLS Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+				err = r.Create(ctx, secret)
+				if err != nil {
+					log.Error(err, "Failed to create new wildcard TLS Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+					return ctrl.Result{}, err
+				}
+
+				// secret created successfully - return and requeue
+				return ctrl.Result{Requeue: true}, nil
+			} else if err != nil {
+				log.Error(err, "Failed to get wildcard TLS Secret copy")
+				return ctrl.Result{}, err
+			}
+
+			// keep the copy in sync when the certificate is renewed
+			if !secretDataEqual(secret.Data, srcSecret.Data) {
+				secret.Data = srcSecret.Data
+
+				log.Info("Updating wildcard TLS Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+				err = r.Update(ctx, secret)
+				if err != nil {
+					log.Error(err, "Failed to update wildcard TLS Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+					return ctrl.Result{}, err
+				}
+
+				// secret updated - return and requeue
+				return ctrl.Result{Requeue: true}, nil
+			}
+		}
+
 		// Check if the ingress does not already exist and create a new one
 		ingr := &netv1beta1.Ingress{}
 		err = r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, ingr)
@@ -228,10 +508,27 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			return ctrl.Result{}, err
 		}
 
+		targetIngr, err := r.ingressForApp(app)
+		if err != nil {
+			log.Error(err, "Failed to build target ingress", "Ingress.Namespace", ingr.Namespace, "Ingress.Name", ingr.Name)
+			return ctrl.Result{}, err
+		}
+
 		// update ingress if necessary
-		if len(ingr.Spec.Rules) > 0 && len(ingr.Spec.Rules[0].IngressRuleValue.HTTP.Paths) > 0 && ingr.Spec.Rules[0].IngressRuleValue.HTTP.Paths[0].Backend.ServicePort.IntVal != *portToExpose {
-			// update port
-			ingr.Spec.Rules[0].IngressRuleValue.HTTP.Paths[0].Backend.ServicePort = intstr.FromInt(int(*portToExpose))
+		if !r.ingressEqual(ingr, targetIngr) {
+			ingr.Spec.Rules = targetIngr.Spec.Rules
+			ingr.Spec.TLS = targetIngr.Spec.TLS
+
+			if ingr.Annotations == nil {
+				ingr.Annotations = map[string]string{}
+			}
+			for _, key := range ingressManagedAnnotationKeys {
+				if value, ok := targetIngr.Annotations[key]; ok {
+					ingr.Annotations[key] = value
+				} else {
+					delete(ingr.Annotations, key)
+				}
+			}
 
 			log.Info("Updating ingress", "Ingress.Namespace", ingr.Namespace, "Ingress.Name", ingr.Name)
 
@@ -245,9 +542,45 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			return ctrl.Result{Requeue: true}, nil
 		}
 
-		// update app status (external url) if necessary
-		if app.Status.ExternalURL != appURL(app) {
-			app.Status.ExternalURL = appURL(app)
+		// update app status (external urls) if necessary
+		urls := appURLs(app)
+		if app.Status.ExternalURL != urls[0] || strings.Join(app.Status.ExternalURLs, ",") != strings.Join(urls, ",") {
+			app.Status.ExternalURL = urls[0]
+			app.Status.ExternalURLs = urls
+
+			err := r.Status().Update(ctx, app)
+			if err != nil {
+				log.Error(err, "Failed to update app status")
+				return ctrl.Result{}, err
+			}
+
+			// Status updated - return and requeue
+			return ctrl.Result{Requeue: true}, nil
+		}
+	} else {
+		// no port exposed anymore, remove the ingress if we own it
+		ingr := &netv1beta1.Ingress{}
+		err = r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, ingr)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to get ingress")
+			return ctrl.Result{}, err
+		}
+		if err == nil && metav1.IsControlledBy(ingr, app) {
+			log.Info("Deleting ingress", "Ingress.Namespace", ingr.Namespace, "Ingress.Name", ingr.Name)
+			err = r.Delete(ctx, ingr)
+			if err != nil && !errors.IsNotFound(err) {
+				log.Error(err, "Failed to delete ingress", "Ingress.Namespace", ingr.Namespace, "Ingress.Name", ingr.Name)
+				return ctrl.Result{}, err
+			}
+
+			// ingress deleted - return and requeue
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		// clear app status (external urls) if necessary
+		if app.Status.ExternalURL != "" || len(app.Status.ExternalURLs) > 0 {
+			app.Status.ExternalURL = ""
+			app.Status.ExternalURLs = nil
 
 			err := r.Status().Update(ctx, app)
 			if err != nil {
@@ -260,10 +593,31 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		}
 	}
 
+	if !appUsesWildcardTLS(app) {
+		deleted, err := r.cleanupWildcardTLSSecret(ctx, log, app.Namespace)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if deleted {
+			// secret deleted - return and requeue
+			return ctrl.Result{Requeue: true}, nil
+		}
+	}
+
+	// current and desired replicas come from the autoscaler when enabled
+	var currentReplicas, desiredReplicas int32
+	if app.Spec.Autoscaling != nil {
+		currentReplicas = hpa.Status.CurrentReplicas
+		desiredReplicas = hpa.Status.DesiredReplicas
+	}
+
 	// update replicas status if necessary
-	if app.Status.AvailableReplicas != dep.Status.AvailableReplicas || app.Status.UnavailableReplicas != dep.Status.UnavailableReplicas {
+	if app.Status.AvailableReplicas != dep.Status.AvailableReplicas || app.Status.UnavailableReplicas != dep.Status.UnavailableReplicas ||
+		app.Status.CurrentReplicas != currentReplicas || app.Status.DesiredReplicas != desiredReplicas {
 		app.Status.AvailableReplicas = dep.Status.AvailableReplicas
 		app.Status.UnavailableReplicas = dep.Status.UnavailableReplicas
+		app.Status.CurrentReplicas = currentReplicas
+		app.Status.DesiredReplicas = desiredReplicas
 
 		err := r.Status().Update(ctx, app)
 		if err != nil {
@@ -278,6 +632,34 @@ func (r *AppReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	return ctrl.Result{}, nil
 }
 
+// AppRolloutState returns the ready and progressing condition statuses of the app from its deployment
+func AppRolloutState(dep *appsv1.Deployment) (ready, progressing metav1.ConditionStatus, reason, message string) {
+	var replicas int32 = 1
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+
+	message = fmt.Sprintf("%d/%d replicas available", dep.Status.AvailableReplicas, replicas)
+
+	// the deployment controller gave up waiting for the rollout
+	for _, c := range dep.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse {
+			return metav1.ConditionFalse, metav1.ConditionFalse, c.Reason, c.Message
+		}
+	}
+
+	if dep.Status.ObservedGeneration < dep.Generation || dep.Status.UpdatedReplicas < replicas ||
+		dep.Status.Replicas > dep.Status.UpdatedReplicas {
+		return metav1.ConditionFalse, metav1.ConditionTrue, "RollingOut", message
+	}
+
+	if dep.Status.AvailableReplicas < replicas || dep.Status.UnavailableReplicas > 0 {
+		return metav1.ConditionFalse, metav1.ConditionTrue, "ReplicasUnavailable", message
+	}
+
+	return metav1.ConditionTrue, metav1.ConditionFalse, "Available", message
+}
+
 const AppRestartAnnotationKey = "cloud.kubexcloud.com/restartedAt"
 
 func (r *AppReconciler) containersEqual(containers, targetContainers []corev1.Container) bool {
@@ -310,81 +692,512 @@ func (r *AppReconciler) containersEqual(containers, targetContainers []corev1.Co
 				return false
 			}
 		}
-	}
-
-	return true
-}
-
-func AppProjectName(app *cloudv1alpha1.App) string {
-	return app.ObjectMeta.Labels[projectCRKey]
-}
 
-// deploymentForApp returns an app Deployment object
-func (r *AppReconciler) deploymentForApp(app *cloudv1alpha1.App) (*appsv1.Deployment, error) {
-	projectName := AppProjectName(app)
-	labels := LabelsForApp(projectName, app.Name)
-	replicas := app.Spec.Replicas
+		if !r.envEqual(c.Env, targetC.Env) {
+			return false
+		}
 
-	dep := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      app.Name,
-			Namespace: app.Namespace,
-			Labels:    labels,
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-			Selector: &metav1.LabelSelector{
-				MatchLabels: labels,
-			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{},
-				},
-			},
-		},
-	}
+		if !resourceListEqual(c.Resources.Requests, targetC.Resources.Requests) || !resourceListEqual(c.Resources.Limits, targetC.Resources.Limits) {
+			return false
+		}
 
-	containers := []corev1.Container{}
-	for _, c := range app.Spec.Containers {
-		container := corev1.Container{
-			Image:   c.Image,
-			Name:    c.Name,
-			Command: c.Command,
-			Ports:   []corev1.ContainerPort{},
+		if !probeEqual(c.LivenessProbe, targetC.LivenessProbe) || !probeEqual(c.ReadinessProbe, targetC.ReadinessProbe) || !probeEqual(c.StartupProbe, targetC.StartupProbe) {
+			return false
 		}
 
-		for _, p := range c.Ports {
-			container.Ports = append(container.Ports, corev1.ContainerPort{
-				Protocol:      p.Protocol,
-				ContainerPort: p.Number,
-			})
+		if len(c.VolumeMounts) != len(targetC.VolumeMounts) {
+			return false
 		}
 
-		containers = append(containers, container)
-	}
-	dep.Spec.Template.Spec.Containers = containers
+		for i, m := range c.VolumeMounts {
+			targetM := targetC.VolumeMounts[i]
 
-	// Set app instance as the owner and controller
-	err := ctrl.SetControllerReference(app, dep, r.Scheme)
-	if err != nil {
-		return nil, err
+			if m.Name != targetM.Name || m.MountPath != targetM.MountPath || m.ReadOnly != targetM.ReadOnly {
+				return false
+			}
+		}
 	}
-	return dep, nil
+
+	return true
 }
 
-// LabelsForApp returns the labels for an app
-func LabelsForApp(projectName, appName string) map[string]string {
-	return map[string]string{
-		"app":        appName,
-		projectCRKey: projectName,
+func (r *AppReconciler) volumesEqual(volumes, targetVolumes []corev1.Volume) bool {
+	if len(volumes) != len(targetVolumes) {
+		return false
 	}
-}
 
-// serviceForApp returns an app Service object
-func (r *AppReconciler) serviceForApp(app *cloudv1alpha1.App) (*corev1.Service, error) {
+	for i, v := range volumes {
+		targetV := targetVolumes[i]
+
+		if v.Name != targetV.Name {
+			return false
+		}
+
+		claim, targetClaim := v.PersistentVolumeClaim, targetV.PersistentVolumeClaim
+		if (claim == nil) != (targetClaim == nil) {
+			return false
+		}
+		if claim != nil && claim.ClaimName != targetClaim.ClaimName {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resourceListEqual compares quantities semantically, since the api server normalizes them (e.g. 0.5 => 500m)
+func resourceListEqual(list, targetList corev1.ResourceList) bool {
+	if len(list) != len(targetList) {
+		return false
+	}
+
+	for name, q := range list {
+		targetQ, ok := targetList[name]
+		if !ok || q.Cmp(targetQ) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// probeEqual expects the target probe to be built by probeForContainer, with api server defaults filled in
+func probeEqual(probe, targetProbe *corev1.Probe) bool {
+	if probe == nil || targetProbe == nil {
+		return probe == targetProbe
+	}
+
+	if probe.InitialDelaySeconds != targetProbe.InitialDelaySeconds ||
+		probe.PeriodSeconds != targetProbe.PeriodSeconds ||
+		probe.TimeoutSeconds != targetProbe.TimeoutSeconds ||
+		probe.FailureThreshold != targetProbe.FailureThreshold ||
+		probe.SuccessThreshold != targetProbe.SuccessThreshold {
+		return false
+	}
+
+	httpGet, targetHTTPGet := probe.HTTPGet, targetProbe.HTTPGet
+	if (httpGet == nil) != (targetHTTPGet == nil) {
+		return false
+	}
+	if httpGet != nil && (httpGet.Path != targetHTTPGet.Path || httpGet.Port != targetHTTPGet.Port || httpGet.Scheme != targetHTTPGet.Scheme) {
+		return false
+	}
+
+	tcpSocket, targetTCPSocket := probe.TCPSocket, targetProbe.TCPSocket
+	if (tcpSocket == nil) != (targetTCPSocket == nil) {
+		return false
+	}
+	if tcpSocket != nil && tcpSocket.Port != targetTCPSocket.Port {
+		return false
+	}
+
+	exec, targetExec := probe.Exec, targetProbe.Exec
+	if (exec == nil) != (targetExec == nil) {
+		return false
+	}
+	if exec != nil && strings.Join(exec.Command, " ") != strings.Join(targetExec.Command, " ") {
+		return false
+	}
+
+	return true
+}
+
+func (r *AppReconciler) envEqual(env, targetEnv []corev1.EnvVar) bool {
+	if len(env) != len(targetEnv) {
+		return false
+	}
+
+	for i, e := range env {
+		targetE := targetEnv[i]
+
+		if e.Name != targetE.Name || e.Value != targetE.Value {
+			return false
+		}
+
+		if (e.ValueFrom == nil) != (targetE.ValueFrom == nil) {
+			return false
+		}
+		if e.ValueFrom == nil {
+			continue
+		}
+
+		secretRef, targetSecretRef := e.ValueFrom.SecretKeyRef, targetE.ValueFrom.SecretKeyRef
+		if (secretRef == nil) != (targetSecretRef == nil) {
+			return false
+		}
+		if secretRef != nil && (secretRef.Name != targetSecretRef.Name || secretRef.Key != targetSecretRef.Key) {
+			return false
+		}
+
+		configMapRef, targetConfigMapRef := e.ValueFrom.ConfigMapKeyRef, targetE.ValueFrom.ConfigMapKeyRef
+		if (configMapRef == nil) != (targetConfigMapRef == nil) {
+			return false
+		}
+		if configMapRef != nil && (configMapRef.Name != targetConfigMapRef.Name || configMapRef.Key != targetConfigMapRef.Key) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func AppProjectName(app *cloudv1alpha1.App) string {
+	return app.ObjectMeta.Labels[projectCRKey]
+}
+
+// deploymentForApp returns an app Deployment object
+func (r *AppReconciler) deploymentForApp(app *cloudv1alpha1.App) (*appsv1.Deployment, error) {
+	projectName := AppProjectName(app)
+	labels := LabelsForApp(projectName, app.Name)
+	replicas := app.Spec.Replicas
+	if app.Spec.Autoscaling != nil {
+		// initial size, the autoscaler takes over afterwards
+		replicas = app.Spec.Autoscaling.MinReplicas
+	}
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app.Name,
+			Namespace: app.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{},
+				},
+			},
+		},
+	}
+
+	containers := []corev1.Container{}
+	for _, c := range app.Spec.Containers {
+		container := corev1.Container{
+			Image:   c.Image,
+			Name:    c.Name,
+			Command: c.Command,
+			Ports:   []corev1.ContainerPort{},
+		}
+
+		for _, p := range c.Ports {
+			container.Ports = append(container.Ports, corev1.ContainerPort{
+				Protocol:      p.Protocol,
+				ContainerPort: p.Number,
+			})
+		}
+
+		container.Env = envForContainer(c)
+
+		resources, err := resourcesForContainer(c)
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %v", c.Name, err)
+		}
+		container.Resources = resources
+
+		container.LivenessProbe = probeForContainer(c.LivenessProbe)
+		container.ReadinessProbe = probeForContainer(c.ReadinessProbe)
+		container.StartupProbe = probeForContainer(c.StartupProbe)
+
+		for _, m := range c.VolumeMounts {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      m.Name,
+				MountPath: m.MountPath,
+				ReadOnly:  m.ReadOnly,
+			})
+		}
+
+		containers = append(containers, container)
+	}
+	dep.Spec.Template.Spec.Containers = containers
+
+	for _, v := range app.Spec.Volumes {
+		dep.Spec.Template.Spec.Volumes = append(dep.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: v.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: appVolumeClaimName(app.Name, v.Name),
+				},
+			},
+		})
+	}
+
+	// Set app instance as the owner and controller
+	err := ctrl.SetControllerReference(app, dep, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+// envForContainer returns the container env vars, resolving secret and configmap refs
+func envForContainer(c cloudv1alpha1.Container) []corev1.EnvVar {
+	if len(c.Env) == 0 {
+		return nil
+	}
+
+	env := []corev1.EnvVar{}
+	for _, e := range c.Env {
+		envVar := corev1.EnvVar{
+			Name:  e.Name,
+			Value: e.Value,
+		}
+
+		if e.SecretKeyRef != nil {
+			envVar.ValueFrom = &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: e.SecretKeyRef.Name},
+					Key:                  e.SecretKeyRef.Key,
+				},
+			}
+		} else if e.ConfigMapKeyRef != nil {
+			envVar.ValueFrom = &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: e.ConfigMapKeyRef.Name},
+					Key:                  e.ConfigMapKeyRef.Key,
+				},
+			}
+		}
+
+		env = append(env, envVar)
+	}
+
+	return env
+}
+
+// resourcesForContainer returns the container resource requirements
+func resourcesForContainer(c cloudv1alpha1.Container) (corev1.ResourceRequirements, error) {
+	resources := corev1.ResourceRequirements{}
+	if c.Resources == nil {
+		return resources, nil
+	}
+
+	var err error
+	resources.Requests, err = resourceListForContainer(c.Resources.Requests)
+	if err != nil {
+		return resources, fmt.Errorf("requests: %v", err)
+	}
+
+	resources.Limits, err = resourceListForContainer(c.Resources.Limits)
+	if err != nil {
+		return resources, fmt.Errorf("limits: %v", err)
+	}
+
+	return resources, nil
+}
+
+func resourceListForContainer(l *cloudv1alpha1.ResourceList) (corev1.ResourceList, error) {
+	if l == nil || (l.CPU == "" && l.Memory == "") {
+		return nil, nil
+	}
+
+	list := corev1.ResourceList{}
+	if l.CPU != "" {
+		q, err := resource.ParseQuantity(l.CPU)
+		if err != nil {
+			return nil, fmt.Errorf("cpu: %v", err)
+		}
+		list[corev1.ResourceCPU] = q
+	}
+	if l.Memory != "" {
+		q, err := resource.ParseQuantity(l.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("memory: %v", err)
+		}
+		list[corev1.ResourceMemory] = q
+	}
+
+	return list, nil
+}
+
+// probe defaults applied by the api server, set explicitly so that containersEqual doesn't detect drift
+const (
+	defaultProbePeriodSeconds    = 10
+	defaultProbeTimeoutSeconds   = 1
+	defaultProbeSuccessThreshold = 1
+	defaultProbeFailureThreshold = 3
+)
+
+// probeForContainer returns a container probe
+func probeForContainer(p *cloudv1alpha1.Probe) *corev1.Probe {
+	if p == nil {
+		return nil
+	}
+
+	probe := &corev1.Probe{
+		InitialDelaySeconds: p.InitialDelaySeconds,
+		PeriodSeconds:       p.PeriodSeconds,
+		TimeoutSeconds:      p.TimeoutSeconds,
+		FailureThreshold:    p.FailureThreshold,
+		SuccessThreshold:    defaultProbeSuccessThreshold,
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = defaultProbePeriodSeconds
+	}
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = defaultProbeTimeoutSeconds
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = defaultProbeFailureThreshold
+	}
+
+	if p.HTTPGet != nil {
+		probe.Handler.HTTPGet = &corev1.HTTPGetAction{
+			Path:   p.HTTPGet.Path,
+			Port:   intstr.FromInt(int(p.HTTPGet.Port)),
+			Scheme: corev1.URISchemeHTTP,
+		}
+	} else if p.TCPSocket != nil {
+		probe.Handler.TCPSocket = &corev1.TCPSocketAction{
+			Port: intstr.FromInt(int(p.TCPSocket.Port)),
+		}
+	} else if p.Exec != nil {
+		probe.Handler.Exec = &corev1.ExecAction{
+			Command: p.Exec.Command,
+		}
+	}
+
+	return probe
+}
+
+// LabelsForApp returns the labels for an app
+func LabelsForApp(projectName, appName string) map[string]string {
+	return map[string]string{
+		"app":        appName,
+		projectCRKey: projectName,
+	}
+}
+
+// appVolumeClaimName returns the name of the claim backing an app volume
+func appVolumeClaimName(appName, volumeName string) string {
+	return appName + "-" + volumeName
+}
+
+func appHasVolumeClaim(app *cloudv1alpha1.App, claimName string) bool {
+	for _, v := range app.Spec.Volumes {
+		if appVolumeClaimName(app.Name, v.Name) == claimName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appVolumeReclaimPolicy(v cloudv1alpha1.Volume) cloudv1alpha1.VolumeReclaimPolicy {
+	if v.ReclaimPolicy == "" {
+		return cloudv1alpha1.VolumeReclaimDelete
+	}
+
+	return v.ReclaimPolicy
+}
+
+// pvcForAppVolume returns an app volume PersistentVolumeClaim object
+func (r *AppReconciler) pvcForAppVolume(app *cloudv1alpha1.App, v cloudv1alpha1.Volume) (*corev1.PersistentVolumeClaim, error) {
+	projectName := AppProjectName(app)
+	labels := LabelsForApp(projectName, app.Name)
+
+	size, err := resource.ParseQuantity(v.Size)
+	if err != nil {
+		return nil, fmt.Errorf("volume %s size: %v", v.Name, err)
+	}
+
+	accessMode := v.AccessMode
+	if accessMode == "" {
+		accessMode = corev1.ReadWriteOnce
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appVolumeClaimName(app.Name, v.Name),
+			Namespace: app.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{accessMode},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+
+	if v.StorageClassName != "" {
+		storageClassName := v.StorageClassName
+		pvc.Spec.StorageClassName = &storageClassName
+	}
+
+	// retained claims outlive the app, so they are not owned by it
+	if appVolumeReclaimPolicy(v) == cloudv1alpha1.VolumeReclaimRetain {
+		return pvc, nil
+	}
+
+	// Set app instance as the owner and controller
+	err = ctrl.SetControllerReference(app, pvc, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return pvc, nil
+}
+
+// hpaForApp returns an app HorizontalPodAutoscaler object
+func (r *AppReconciler) hpaForApp(app *cloudv1alpha1.App) (*autoscalingv1.HorizontalPodAutoscaler, error) {
+	projectName := AppProjectName(app)
+	labels := LabelsForApp(projectName, app.Name)
+
+	minReplicas := app.Spec.Autoscaling.MinReplicas
+	targetCPUUtilizationPercentage := app.Spec.Autoscaling.TargetCPUUtilizationPercentage
+
+	hpa := &autoscalingv1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app.Name,
+			Namespace: app.Namespace,
+			Labels:    labels,
+		},
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       app.Name,
+			},
+			MinReplicas:                    &minReplicas,
+			MaxReplicas:                    app.Spec.Autoscaling.MaxReplicas,
+			TargetCPUUtilizationPercentage: &targetCPUUtilizationPercentage,
+		},
+	}
+
+	// Set app instance as the owner and controller
+	err := ctrl.SetControllerReference(app, hpa, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return hpa, nil
+}
+
+func (r *AppReconciler) hpaSpecEqual(spec, targetSpec autoscalingv1.HorizontalPodAutoscalerSpec) bool {
+	if spec.ScaleTargetRef != targetSpec.ScaleTargetRef || spec.MaxReplicas != targetSpec.MaxReplicas {
+		return false
+	}
+
+	if spec.MinReplicas == nil || *spec.MinReplicas != *targetSpec.MinReplicas {
+		return false
+	}
+
+	if spec.TargetCPUUtilizationPercentage == nil || *spec.TargetCPUUtilizationPercentage != *targetSpec.TargetCPUUtilizationPercentage {
+		return false
+	}
+
+	return true
+}
+
+// serviceForApp returns an app Service object
+func (r *AppReconciler) serviceForApp(app *cloudv1alpha1.App) (*corev1.Service, error) {
 	projectName := AppProjectName(app)
 	labels := LabelsForApp(projectName, app.Name)
 
@@ -438,28 +1251,146 @@ func (r *AppReconciler) servicePortsEqual(ports, targetPorts []corev1.ServicePor
 	return true
 }
 
-func appPortToExposeExternally(app *cloudv1alpha1.App) *int32 {
-	// find the first tcp port to expose externally
+// appPortsToExposeExternally returns the tcp ports to expose externally
+func appPortsToExposeExternally(app *cloudv1alpha1.App) []cloudv1alpha1.Port {
+	ports := []cloudv1alpha1.Port{}
+
 	for _, c := range app.Spec.Containers {
 		for _, p := range c.Ports {
 			if p.ExposeExternally && p.Protocol == corev1.ProtocolTCP {
-				return &p.Number
+				ports = append(ports, p)
 			}
 		}
 	}
 
-	return nil
+	return ports
 }
 
 const defaultRootDomain = "127.0.0.1.xip.io"
 
+const (
+	certManagerIssuerAnnotationKey        = "cert-manager.io/issuer"
+	certManagerClusterIssuerAnnotationKey = "cert-manager.io/cluster-issuer"
+)
+
+// ingressManagedAnnotationKeys are the ingress annotations kept in sync by the reconciler
+var ingressManagedAnnotationKeys = []string{certManagerIssuerAnnotationKey, certManagerClusterIssuerAnnotationKey}
+
+type tlsMode int
+
+const (
+	tlsModeNone tlsMode = iota
+	tlsModeWildcard
+	tlsModeCertManager
+)
+
+// appTLSMode returns how the app external url is secured
+func appTLSMode(app *cloudv1alpha1.App) tlsMode {
+	if app.Spec.TLS != nil && (app.Spec.TLS.Issuer != "" || app.Spec.TLS.ClusterIssuer != "") {
+		return tlsModeCertManager
+	}
+
+	if _, name := wildcardTLSSecret(); name != "" {
+		return tlsModeWildcard
+	}
+
+	return tlsModeNone
+}
+
+// wildcardTLSSecret returns the operator wide certificate for ROOT_DOMAIN, if configured
+func wildcardTLSSecret() (namespace, name string) {
+	namespace = os.Getenv("TLS_SECRET_NAMESPACE")
+	if namespace == "" {
+		namespace = ingressControllerNamespaceName()
+	}
+
+	return namespace, os.Getenv("TLS_SECRET_NAME")
+}
+
+// wildcardTLSSecretCopyName is the name of the wildcard certificate copy in project namespaces
+const wildcardTLSSecretCopyName = "kxc-wildcard-tls"
+
+func wildcardTLSSecretCopy(src *corev1.Secret, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wildcardTLSSecretCopyName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "kxc"},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: src.Data,
+	}
+}
+
+// appUsesWildcardTLS returns true if the app ingress references the wildcard certificate copy
+func appUsesWildcardTLS(app *cloudv1alpha1.App) bool {
+	return len(appPortsToExposeExternally(app)) > 0 && appTLSMode(app) == tlsModeWildcard
+}
+
+// cleanupWildcardTLSSecret deletes the wildcard certificate copy of the namespace once no app uses it,
+// returns true if it was deleted
+func (r *AppReconciler) cleanupWildcardTLSSecret(ctx context.Context, log logr.Logger, namespace string) (bool, error) {
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: wildcardTLSSecretCopyName, Namespace: namespace}, secret)
+	if err != nil && errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get wildcard TLS Secret copy")
+		return false, err
+	}
+
+	appList := &cloudv1alpha1.AppList{}
+	err = r.List(ctx, appList, client.InNamespace(namespace))
+	if err != nil {
+		log.Error(err, "Failed to list apps", "Namespace", namespace)
+		return false, err
+	}
+	for i := range appList.Items {
+		if appList.Items[i].DeletionTimestamp == nil && appUsesWildcardTLS(&appList.Items[i]) {
+			return false, nil
+		}
+	}
+
+	log.Info("Deleting wildcard TLS Secret copy", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+	err = r.Delete(ctx, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to delete wildcard TLS Secret copy", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func secretDataEqual(data, targetData map[string][]byte) bool {
+	if len(data) != len(targetData) {
+		return false
+	}
+
+	for k, v := range data {
+		targetV, ok := targetData[k]
+		if !ok || string(v) != string(targetV) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// appTLSSecretName returns the name of the secret holding the app certificate
+func appTLSSecretName(app *cloudv1alpha1.App) string {
+	if appTLSMode(app) == tlsModeWildcard {
+		return wildcardTLSSecretCopyName
+	}
+
+	// created by cert-manager
+	return app.Name + "-tls"
+}
+
 // ingressForApp returns an app Ingress object
 func (r *AppReconciler) ingressForApp(app *cloudv1alpha1.App) (*netv1beta1.Ingress, error) {
 	projectName := AppProjectName(app)
 	labels := LabelsForApp(projectName, app.Name)
 
-	port := appPortToExposeExternally(app)
-
 	ingr := &netv1beta1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      app.Name,
@@ -467,24 +1398,63 @@ func (r *AppReconciler) ingressForApp(app *cloudv1alpha1.App) (*netv1beta1.Ingre
 			Labels:    labels,
 		},
 		Spec: netv1beta1.IngressSpec{
-			Rules: []netv1beta1.IngressRule{
-				netv1beta1.IngressRule{
-					Host: appURLHost(app),
-					IngressRuleValue: netv1beta1.IngressRuleValue{
-						HTTP: &netv1beta1.HTTPIngressRuleValue{
-							Paths: []netv1beta1.HTTPIngressPath{
-								netv1beta1.HTTPIngressPath{
-									Backend: netv1beta1.IngressBackend{
-										ServiceName: app.Name,
-										ServicePort: intstr.FromInt(int(*port)),
-									},
-								},
-							},
-						},
-					},
+			Rules: []netv1beta1.IngressRule{},
+		},
+	}
+
+	// one rule per host, with one path per port routed to that host
+	for _, route := range appRoutes(app) {
+		rule := netv1beta1.IngressRule{
+			Host: route.host,
+			IngressRuleValue: netv1beta1.IngressRuleValue{
+				HTTP: &netv1beta1.HTTPIngressRuleValue{
+					Paths: []netv1beta1.HTTPIngressPath{},
 				},
 			},
-		},
+		}
+
+		for _, p := range route.ports {
+			path := netv1beta1.HTTPIngressPath{
+				Backend: netv1beta1.IngressBackend{
+					ServiceName: app.Name,
+					ServicePort: intstr.FromInt(int(p.Number)),
+				},
+			}
+			if p.Path != "" {
+				pathType := netv1beta1.PathTypePrefix
+				path.Path = p.Path
+				path.PathType = &pathType
+			}
+
+			rule.HTTP.Paths = append(rule.HTTP.Paths, path)
+		}
+
+		ingr.Spec.Rules = append(ingr.Spec.Rules, rule)
+	}
+
+	if appTLSMode(app) == tlsModeCertManager {
+		ingr.Annotations = map[string]string{}
+		if app.Spec.TLS.Issuer != "" {
+			ingr.Annotations[certManagerIssuerAnnotationKey] = app.Spec.TLS.Issuer
+		} else {
+			ingr.Annotations[certManagerClusterIssuerAnnotationKey] = app.Spec.TLS.ClusterIssuer
+		}
+	}
+
+	if appTLSMode(app) != tlsModeNone {
+		hosts := []string{}
+		for _, route := range appRoutes(app) {
+			if appRouteTLS(app, route) {
+				hosts = append(hosts, route.host)
+			}
+		}
+
+		ingr.Spec.TLS = []netv1beta1.IngressTLS{
+			netv1beta1.IngressTLS{
+				Hosts:      hosts,
+				SecretName: appTLSSecretName(app),
+			},
+		}
 	}
 
 	// Set app instance as the owner and controller
@@ -495,16 +1465,169 @@ func (r *AppReconciler) ingressForApp(app *cloudv1alpha1.App) (*netv1beta1.Ingre
 	return ingr, nil
 }
 
-func appURLHost(app *cloudv1alpha1.App) string {
+func (r *AppReconciler) ingressEqual(ingr, targetIngr *netv1beta1.Ingress) bool {
+	for _, key := range ingressManagedAnnotationKeys {
+		if ingr.Annotations[key] != targetIngr.Annotations[key] {
+			return false
+		}
+	}
+
+	if len(ingr.Spec.TLS) != len(targetIngr.Spec.TLS) {
+		return false
+	}
+
+	for i, tls := range ingr.Spec.TLS {
+		targetTLS := targetIngr.Spec.TLS[i]
+
+		if tls.SecretName != targetTLS.SecretName || strings.Join(tls.Hosts, ",") != strings.Join(targetTLS.Hosts, ",") {
+			return false
+		}
+	}
+
+	if len(ingr.Spec.Rules) != len(targetIngr.Spec.Rules) {
+		return false
+	}
+
+	for i, rule := range ingr.Spec.Rules {
+		targetRule := targetIngr.Spec.Rules[i]
+
+		if rule.Host != targetRule.Host || rule.HTTP == nil || len(rule.HTTP.Paths) != len(targetRule.HTTP.Paths) {
+			return false
+		}
+
+		for j, path := range rule.HTTP.Paths {
+			targetPath := targetRule.HTTP.Paths[j]
+
+			if path.Path != targetPath.Path || path.Backend.ServiceName != targetPath.Backend.ServiceName || path.Backend.ServicePort != targetPath.Backend.ServicePort {
+				return false
+			}
+
+			// the path type is defaulted by the api server when not set
+			if targetPath.PathType != nil && (path.PathType == nil || *path.PathType != *targetPath.PathType) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// AppRootDomain returns the domain under which apps default hosts are created
+func AppRootDomain() string {
 	rootDomain := os.Getenv("ROOT_DOMAIN")
 	if rootDomain == "" {
 		rootDomain = defaultRootDomain
 	}
-	return app.Name + "." + rootDomain
+	return rootDomain
+}
+
+// AppHostLabel returns the first label of the app default host,
+// suffixed by the project name so that apps with the same name in different projects don't collide.
+// Names can contain dashes so the label alone is ambiguous, the api rejects apps whose labels are already in use.
+// Apps created before the project suffix was added move from <app>.<root domain> to this host on their next reconcile
+func AppHostLabel(projectName, appName string) string {
+	if projectName == "" {
+		return appName
+	}
+	return appName + "-" + projectName
+}
+
+func appURLHost(app *cloudv1alpha1.App) string {
+	return AppHostLabel(AppProjectName(app), app.Name) + "." + AppRootDomain()
+}
+
+// appHosts returns the default host followed by the custom domains
+func appHosts(app *cloudv1alpha1.App) []string {
+	return append([]string{appURLHost(app)}, app.Spec.Domains...)
+}
+
+// AppSuffixedHostLabel returns the first label of the dedicated host of a port with a host suffix
+func AppSuffixedHostLabel(projectName, appName, suffix string) string {
+	return AppHostLabel(projectName, appName) + "-" + suffix
+}
+
+// appSuffixedHost returns the dedicated host of a port with a host suffix
+func appSuffixedHost(app *cloudv1alpha1.App, suffix string) string {
+	return AppSuffixedHostLabel(AppProjectName(app), app.Name, suffix) + "." + AppRootDomain()
+}
+
+// appRoute is a host and the ports routed to it
+type appRoute struct {
+	host string
+	// rootDomain is true if the host is directly under the root domain
+	rootDomain bool
+	ports      []cloudv1alpha1.Port
+}
+
+// appRoutes returns the hosts of the app and the ports routed to them:
+// ports without a host suffix are routed on the default host and the custom domains,
+// ports with a host suffix get a dedicated host
+func appRoutes(app *cloudv1alpha1.App) []appRoute {
+	routes := []appRoute{}
+	routeIndex := map[string]int{}
+
+	addPort := func(host string, rootDomain bool, p cloudv1alpha1.Port) {
+		i, ok := routeIndex[host]
+		if !ok {
+			routes = append(routes, appRoute{host: host, rootDomain: rootDomain})
+			i = len(routes) - 1
+			routeIndex[host] = i
+		}
+		routes[i].ports = append(routes[i].ports, p)
+	}
+
+	ports := appPortsToExposeExternally(app)
+
+	for i, host := range appHosts(app) {
+		for _, p := range ports {
+			if p.HostSuffix == "" {
+				addPort(host, i == 0, p)
+			}
+		}
+	}
+
+	for _, p := range ports {
+		if p.HostSuffix != "" {
+			addPort(appSuffixedHost(app, p.HostSuffix), true, p)
+		}
+	}
+
+	return routes
+}
+
+// appRouteTLS returns true if the route host is covered by the app certificate,
+// the wildcard certificate only covers hosts directly under the root domain
+func appRouteTLS(app *cloudv1alpha1.App, route appRoute) bool {
+	switch appTLSMode(app) {
+	case tlsModeCertManager:
+		return true
+	case tlsModeWildcard:
+		return route.rootDomain
+	default:
+		return false
+	}
 }
 
-func appURL(app *cloudv1alpha1.App) string {
-	return "http://" + appURLHost(app) + "/"
+// appURLs returns the external urls of the app, one per routed host and port,
+// https is only advertised for the hosts covered by the app certificate
+func appURLs(app *cloudv1alpha1.App) []string {
+	urls := []string{}
+	for _, route := range appRoutes(app) {
+		scheme := "http"
+		if appRouteTLS(app, route) {
+			scheme = "https"
+		}
+
+		for _, p := range route.ports {
+			path := p.Path
+			if path == "" {
+				path = "/"
+			}
+			urls = append(urls, scheme+"://"+route.host+path)
+		}
+	}
+
+	return urls
 }
 
 func (r *AppReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -512,5 +1635,8 @@ func (r *AppReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&cloudv1alpha1.App{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&autoscalingv1.HorizontalPodAutoscaler{}).
+		Owns(&netv1beta1.Ingress{}).
 		Complete(r)
 }
@@ -2,17 +2,21 @@ package controllers
 
 import (
 	"context"
+	"os"
 	"time"
 
 	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	netv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 var _ = Describe("App controller", func() {
@@ -69,12 +73,64 @@ var _ = Describe("App controller", func() {
 									ExposeExternally: true,
 								},
 							},
+							Env: []cloudv1alpha1.EnvVar{
+								cloudv1alpha1.EnvVar{
+									Name:  "LOG_LEVEL",
+									Value: "debug",
+								},
+								cloudv1alpha1.EnvVar{
+									Name: "DB_PASSWORD",
+									SecretKeyRef: &cloudv1alpha1.KeyRef{
+										Name: "db-credentials",
+										Key:  "password",
+									},
+								},
+							},
+							Resources: &cloudv1alpha1.Resources{
+								Requests: &cloudv1alpha1.ResourceList{CPU: "0.1", Memory: "64Mi"},
+								Limits:   &cloudv1alpha1.ResourceList{CPU: "500m", Memory: "128Mi"},
+							},
+							ReadinessProbe: &cloudv1alpha1.Probe{
+								HTTPGet: &cloudv1alpha1.HTTPGetProbe{
+									Path: "/healthz",
+									Port: 9123,
+								},
+								InitialDelaySeconds: 5,
+							},
+							VolumeMounts: []cloudv1alpha1.VolumeMount{
+								cloudv1alpha1.VolumeMount{
+									Name:      "data",
+									MountPath: "/data",
+								},
+							},
+						},
+					},
+					Volumes: []cloudv1alpha1.Volume{
+						cloudv1alpha1.Volume{
+							Name: "data",
+							Size: "1Gi",
 						},
 					},
 				},
 			}
 			Expect(k8sClient.Create(ctx, app)).Should(Succeed())
 
+			// check volume claim
+
+			createdPVC := &corev1.PersistentVolumeClaim{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName + "-data", Namespace: NamespaceName}, createdPVC)
+				if err != nil {
+					return false
+				}
+				return true
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(createdPVC.Spec.AccessModes).Should(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}))
+			Expect(createdPVC.Spec.Resources.Requests.Storage().String()).Should(Equal("1Gi"))
+			Expect(createdPVC.OwnerReferences).Should(HaveLen(1))
+			Expect(createdPVC.OwnerReferences[0].UID).Should(Equal(app.UID))
+
 			// check deployment
 
 			createdDeployment := &appsv1.Deployment{}
@@ -103,6 +159,56 @@ var _ = Describe("App controller", func() {
 					ContainerPort: 9123,
 				},
 			}))
+			Expect(cont.Env).Should(Equal([]corev1.EnvVar{
+				corev1.EnvVar{
+					Name:  "LOG_LEVEL",
+					Value: "debug",
+				},
+				corev1.EnvVar{
+					Name: "DB_PASSWORD",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+							Key:                  "password",
+						},
+					},
+				},
+			}))
+			Expect(cont.Resources.Requests.Cpu().String()).Should(Equal("100m"))
+			Expect(cont.Resources.Requests.Memory().String()).Should(Equal("64Mi"))
+			Expect(cont.Resources.Limits.Cpu().String()).Should(Equal("500m"))
+			Expect(cont.Resources.Limits.Memory().String()).Should(Equal("128Mi"))
+			Expect(cont.ReadinessProbe).Should(Equal(&corev1.Probe{
+				Handler: corev1.Handler{
+					HTTPGet: &corev1.HTTPGetAction{
+						Path:   "/healthz",
+						Port:   intstr.FromInt(9123),
+						Scheme: corev1.URISchemeHTTP,
+					},
+				},
+				InitialDelaySeconds: 5,
+				PeriodSeconds:       10,
+				TimeoutSeconds:      1,
+				SuccessThreshold:    1,
+				FailureThreshold:    3,
+			}))
+			Expect(cont.LivenessProbe).Should(BeNil())
+			Expect(cont.VolumeMounts).Should(Equal([]corev1.VolumeMount{
+				corev1.VolumeMount{
+					Name:      "data",
+					MountPath: "/data",
+				},
+			}))
+			Expect(createdDeployment.Spec.Template.Spec.Volumes).Should(Equal([]corev1.Volume{
+				corev1.Volume{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: AppName + "-data",
+						},
+					},
+				},
+			}))
 
 			Expect(createdDeployment.Labels).Should(Equal(map[string]string{
 				"app":        AppName,
@@ -160,7 +266,7 @@ var _ = Describe("App controller", func() {
 
 			Expect(createdIngress.Spec.Rules).Should(Equal([]netv1beta1.IngressRule{
 				netv1beta1.IngressRule{
-					Host: "test-app.127.0.0.1.xip.io",
+					Host: "test-app-test-proj-for-app.127.0.0.1.xip.io",
 					IngressRuleValue: netv1beta1.IngressRuleValue{
 						HTTP: &netv1beta1.HTTPIngressRuleValue{
 							Paths: []netv1beta1.HTTPIngressPath{
@@ -188,7 +294,337 @@ var _ = Describe("App controller", func() {
 					return ""
 				}
 				return app.Status.ExternalURL
-			}, timeout, interval).Should(Equal("http://test-app.127.0.0.1.xip.io/"))
+			}, timeout, interval).Should(Equal("http://test-app-test-proj-for-app.127.0.0.1.xip.io/"))
+
+			// stop exposing the port, the ingress should be removed
+
+			Eventually(func() error {
+				app := &cloudv1alpha1.App{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: NamespaceName}, app)
+				if err != nil {
+					return err
+				}
+				for i := range app.Spec.Containers {
+					for j := range app.Spec.Containers[i].Ports {
+						app.Spec.Containers[i].Ports[j].ExposeExternally = false
+					}
+				}
+				return k8sClient.Update(ctx, app)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: NamespaceName}, &netv1beta1.Ingress{})
+				return errors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+
+			Eventually(func() string {
+				app := &cloudv1alpha1.App{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: NamespaceName}, app)
+				if err != nil {
+					return "error"
+				}
+				return app.Status.ExternalURL
+			}, timeout, interval).Should(Equal(""))
+		})
+
+		AfterEach(func() {
+			ctx := context.Background()
+			Expect(k8sClient.Delete(ctx, app)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, proj)).Should(Succeed())
+		})
+	})
+
+	Context("When creating an autoscaled app", func() {
+		const (
+			AutoscaledProjectName   = "test-proj-for-autoscaled-app"
+			AutoscaledNamespaceName = "kxc-proj-test-proj-for-autoscaled-app"
+		)
+
+		var proj *cloudv1alpha1.Project
+		var app *cloudv1alpha1.App
+
+		It("Should create a horizontal pod autoscaler", func() {
+			ctx := context.Background()
+			proj = &cloudv1alpha1.Project{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: AutoscaledProjectName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, proj)).Should(Succeed())
+
+			// wait for namespace creation
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AutoscaledNamespaceName}, &corev1.Namespace{})
+				if err != nil {
+					return false
+				}
+				return true
+			}, timeout, interval).Should(BeTrue())
+
+			app = &cloudv1alpha1.App{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AppName,
+					Namespace: AutoscaledNamespaceName,
+					Labels:    LabelsForApp(AutoscaledProjectName, AppName),
+				},
+				Spec: cloudv1alpha1.AppSpec{
+					Replicas: 1,
+					Containers: []cloudv1alpha1.Container{
+						cloudv1alpha1.Container{
+							Image: "busybox",
+							Name:  "test-container",
+							Resources: &cloudv1alpha1.Resources{
+								Requests: &cloudv1alpha1.ResourceList{CPU: "100m"},
+							},
+						},
+					},
+					Autoscaling: &cloudv1alpha1.Autoscaling{
+						MinReplicas:                    2,
+						MaxReplicas:                    5,
+						TargetCPUUtilizationPercentage: 80,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, app)).Should(Succeed())
+
+			createdDeployment := &appsv1.Deployment{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: DeploymentName, Namespace: AutoscaledNamespaceName}, createdDeployment)
+				if err != nil {
+					return false
+				}
+				return true
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(*createdDeployment.Spec.Replicas).Should(Equal(int32(2)))
+
+			createdHPA := &autoscalingv1.HorizontalPodAutoscaler{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: AutoscaledNamespaceName}, createdHPA)
+				if err != nil {
+					return false
+				}
+				return true
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(createdHPA.Spec.ScaleTargetRef).Should(Equal(autoscalingv1.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       DeploymentName,
+			}))
+			Expect(*createdHPA.Spec.MinReplicas).Should(Equal(int32(2)))
+			Expect(createdHPA.Spec.MaxReplicas).Should(Equal(int32(5)))
+			Expect(*createdHPA.Spec.TargetCPUUtilizationPercentage).Should(Equal(int32(80)))
+
+			Expect(createdHPA.OwnerReferences).Should(HaveLen(1))
+			Expect(createdHPA.OwnerReferences[0].UID).Should(Equal(app.UID))
+
+			// check app status, the deployment isn't rolled out in the test environment
+
+			Eventually(func() string {
+				app := &cloudv1alpha1.App{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: AutoscaledNamespaceName}, app)
+				if err != nil {
+					return ""
+				}
+				ready := cloudv1alpha1.FindCondition(app.Status.Conditions, cloudv1alpha1.ConditionReady)
+				if ready == nil || app.Status.ObservedGeneration != app.Generation {
+					return ""
+				}
+				return string(ready.Status) + "/" + ready.Reason
+			}, timeout, interval).Should(Equal("False/RollingOut"))
+		})
+
+		AfterEach(func() {
+			ctx := context.Background()
+			Expect(k8sClient.Delete(ctx, app)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, proj)).Should(Succeed())
+		})
+	})
+
+	Context("When creating an app exposing multiple ports", func() {
+		const (
+			MultiPortProjectName   = "test-proj-for-multi-port-app"
+			MultiPortNamespaceName = "kxc-proj-test-proj-for-multi-port-app"
+		)
+
+		var proj *cloudv1alpha1.Project
+		var app *cloudv1alpha1.App
+
+		It("Should route every exposed port", func() {
+			ctx := context.Background()
+			proj = &cloudv1alpha1.Project{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: MultiPortProjectName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, proj)).Should(Succeed())
+
+			// wait for namespace creation
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: MultiPortNamespaceName}, &corev1.Namespace{})
+				if err != nil {
+					return false
+				}
+				return true
+			}, timeout, interval).Should(BeTrue())
+
+			app = &cloudv1alpha1.App{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AppName,
+					Namespace: MultiPortNamespaceName,
+					Labels:    LabelsForApp(MultiPortProjectName, AppName),
+				},
+				Spec: cloudv1alpha1.AppSpec{
+					Replicas: 1,
+					Containers: []cloudv1alpha1.Container{
+						cloudv1alpha1.Container{
+							Image: "busybox",
+							Name:  "test-container",
+							Ports: []cloudv1alpha1.Port{
+								cloudv1alpha1.Port{Number: 8080, Protocol: corev1.ProtocolTCP, ExposeExternally: true},
+								cloudv1alpha1.Port{Number: 9090, Protocol: corev1.ProtocolTCP, ExposeExternally: true, Path: "/api"},
+								cloudv1alpha1.Port{Number: 9100, Protocol: corev1.ProtocolTCP, ExposeExternally: true, HostSuffix: "metrics"},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, app)).Should(Succeed())
+
+			createdIngress := &netv1beta1.Ingress{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: MultiPortNamespaceName}, createdIngress)
+				if err != nil {
+					return false
+				}
+				return true
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(createdIngress.Spec.Rules).Should(HaveLen(2))
+
+			defaultRule := createdIngress.Spec.Rules[0]
+			Expect(defaultRule.Host).Should(Equal("test-app-test-proj-for-multi-port-app.127.0.0.1.xip.io"))
+			Expect(defaultRule.HTTP.Paths).Should(HaveLen(2))
+			Expect(defaultRule.HTTP.Paths[0].Path).Should(Equal(""))
+			Expect(defaultRule.HTTP.Paths[0].Backend.ServicePort).Should(Equal(intstr.FromInt(8080)))
+			Expect(defaultRule.HTTP.Paths[1].Path).Should(Equal("/api"))
+			Expect(*defaultRule.HTTP.Paths[1].PathType).Should(Equal(netv1beta1.PathTypePrefix))
+			Expect(defaultRule.HTTP.Paths[1].Backend.ServicePort).Should(Equal(intstr.FromInt(9090)))
+
+			suffixRule := createdIngress.Spec.Rules[1]
+			Expect(suffixRule.Host).Should(Equal("test-app-test-proj-for-multi-port-app-metrics.127.0.0.1.xip.io"))
+			Expect(suffixRule.HTTP.Paths).Should(HaveLen(1))
+			Expect(suffixRule.HTTP.Paths[0].Backend.ServicePort).Should(Equal(intstr.FromInt(9100)))
+
+			// check app status
+
+			Eventually(func() []string {
+				app := &cloudv1alpha1.App{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: MultiPortNamespaceName}, app)
+				if err != nil {
+					return nil
+				}
+				return app.Status.ExternalURLs
+			}, timeout, interval).Should(Equal([]string{
+				"http://test-app-test-proj-for-multi-port-app.127.0.0.1.xip.io/",
+				"http://test-app-test-proj-for-multi-port-app.127.0.0.1.xip.io/api",
+				"http://test-app-test-proj-for-multi-port-app-metrics.127.0.0.1.xip.io/",
+			}))
+		})
+
+		AfterEach(func() {
+			ctx := context.Background()
+			Expect(k8sClient.Delete(ctx, app)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, proj)).Should(Succeed())
+		})
+	})
+
+	Context("When creating an app with cert-manager tls and a custom domain", func() {
+		const (
+			TLSProjectName   = "test-proj-for-tls-app"
+			TLSNamespaceName = "kxc-proj-test-proj-for-tls-app"
+		)
+
+		var proj *cloudv1alpha1.Project
+		var app *cloudv1alpha1.App
+
+		It("Should create an ingress with a tls section", func() {
+			ctx := context.Background()
+			proj = &cloudv1alpha1.Project{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: TLSProjectName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, proj)).Should(Succeed())
+
+			// wait for namespace creation
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: TLSNamespaceName}, &corev1.Namespace{})
+				if err != nil {
+					return false
+				}
+				return true
+			}, timeout, interval).Should(BeTrue())
+
+			app = &cloudv1alpha1.App{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AppName,
+					Namespace: TLSNamespaceName,
+					Labels:    LabelsForApp(TLSProjectName, AppName),
+				},
+				Spec: cloudv1alpha1.AppSpec{
+					Replicas: 1,
+					Containers: []cloudv1alpha1.Container{
+						cloudv1alpha1.Container{
+							Image: "busybox",
+							Name:  "test-container",
+							Ports: []cloudv1alpha1.Port{
+								cloudv1alpha1.Port{
+									Number:           9123,
+									Protocol:         "TCP",
+									ExposeExternally: true,
+								},
+							},
+						},
+					},
+					Domains: []string{"test-app.example.com"},
+					TLS: &cloudv1alpha1.TLS{
+						ClusterIssuer: "letsencrypt",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, app)).Should(Succeed())
+
+			createdIngress := &netv1beta1.Ingress{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: TLSNamespaceName}, createdIngress)
+				if err != nil {
+					return false
+				}
+				return true
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(createdIngress.Spec.Rules).Should(HaveLen(2))
+			Expect(createdIngress.Spec.Rules[0].Host).Should(Equal("test-app-test-proj-for-tls-app.127.0.0.1.xip.io"))
+			Expect(createdIngress.Spec.Rules[1].Host).Should(Equal("test-app.example.com"))
+
+			Expect(createdIngress.Annotations).Should(HaveKeyWithValue("cert-manager.io/cluster-issuer", "letsencrypt"))
+			Expect(createdIngress.Spec.TLS).Should(Equal([]netv1beta1.IngressTLS{
+				netv1beta1.IngressTLS{
+					Hosts:      []string{"test-app-test-proj-for-tls-app.127.0.0.1.xip.io", "test-app.example.com"},
+					SecretName: "test-app-tls",
+				},
+			}))
+
+			Eventually(func() string {
+				app := &cloudv1alpha1.App{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: AppName, Namespace: TLSNamespaceName}, app)
+				if err != nil {
+					return ""
+				}
+				return app.Status.ExternalURL
+			}, timeout, interval).Should(Equal("https://test-app-test-proj-for-tls-app.127.0.0.1.xip.io/"))
 		})
 
 		AfterEach(func() {
@@ -198,4 +634,91 @@ var _ = Describe("App controller", func() {
 		})
 	})
 
+	Context("When computing the urls of an app with a custom domain in wildcard tls mode", func() {
+		BeforeEach(func() {
+			os.Setenv("TLS_SECRET_NAME", "wildcard-tls")
+		})
+
+		It("Should only advertise https for the hosts covered by the wildcard certificate", func() {
+			app := &cloudv1alpha1.App{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AppName,
+					Namespace: NamespaceName,
+					Labels:    LabelsForApp(ProjectName, AppName),
+				},
+				Spec: cloudv1alpha1.AppSpec{
+					Containers: []cloudv1alpha1.Container{
+						cloudv1alpha1.Container{
+							Image: "busybox",
+							Name:  "test-container",
+							Ports: []cloudv1alpha1.Port{
+								cloudv1alpha1.Port{Number: 8080, Protocol: corev1.ProtocolTCP, ExposeExternally: true},
+							},
+						},
+					},
+					Domains: []string{"test-app.example.com"},
+				},
+			}
+
+			Expect(appURLs(app)).Should(Equal([]string{
+				"https://test-app-test-proj-for-app.127.0.0.1.xip.io/",
+				"http://test-app.example.com/",
+			}))
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("TLS_SECRET_NAME")
+		})
+	})
+
+	Context("When no app of a namespace uses the wildcard certificate anymore", func() {
+		BeforeEach(func() {
+			os.Setenv("TLS_SECRET_NAME", "wildcard-tls")
+		})
+
+		It("Should delete the wildcard certificate copy", func() {
+			app := &cloudv1alpha1.App{
+				Spec: cloudv1alpha1.AppSpec{
+					Containers: []cloudv1alpha1.Container{
+						cloudv1alpha1.Container{
+							Image: "busybox",
+							Name:  "test-container",
+							Ports: []cloudv1alpha1.Port{
+								cloudv1alpha1.Port{Number: 8080, Protocol: corev1.ProtocolTCP, ExposeExternally: true},
+							},
+						},
+					},
+				},
+			}
+			Expect(appUsesWildcardTLS(app)).Should(BeTrue())
+
+			// apps switching to cert-manager or not exposing ports anymore release it
+			certManagerApp := app.DeepCopy()
+			certManagerApp.Spec.TLS = &cloudv1alpha1.TLS{ClusterIssuer: "letsencrypt"}
+			Expect(appUsesWildcardTLS(certManagerApp)).Should(BeFalse())
+
+			internalApp := app.DeepCopy()
+			internalApp.Spec.Containers[0].Ports[0].ExposeExternally = false
+			Expect(appUsesWildcardTLS(internalApp)).Should(BeFalse())
+
+			// no app of the default namespace exposes ports
+			ctx := context.Background()
+			namespace := "default"
+
+			secret := wildcardTLSSecretCopy(&corev1.Secret{Data: map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key")}}, namespace)
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			r := &AppReconciler{Client: k8sClient, Log: ctrl.Log.WithName("test")}
+			deleted, err := r.cleanupWildcardTLSSecret(ctx, r.Log, namespace)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deleted).Should(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: wildcardTLSSecretCopyName, Namespace: namespace}, &corev1.Secret{})
+			Expect(errors.IsNotFound(err)).Should(BeTrue())
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("TLS_SECRET_NAME")
+		})
+	})
 })
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewClient creates the manager client, like the default one it reads from the informers cache
+// except for secrets which are read from the api server, so that the operator doesn't cache all the secrets of the cluster
+func NewClient(cache cache.Cache, config *rest.Config, options client.Options) (client.Client, error) {
+	c, err := client.New(config, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client.DelegatingClient{
+		Reader: &uncachedSecretsReader{
+			DelegatingReader: client.DelegatingReader{
+				CacheReader:  cache,
+				ClientReader: c,
+			},
+		},
+		Writer:       c,
+		StatusClient: c,
+	}, nil
+}
+
+type uncachedSecretsReader struct {
+	client.DelegatingReader
+}
+
+func (r *uncachedSecretsReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if _, ok := obj.(*corev1.Secret); ok {
+		return r.ClientReader.Get(ctx, key, obj)
+	}
+	return r.DelegatingReader.Get(ctx, key, obj)
+}
+
+func (r *uncachedSecretsReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if _, ok := list.(*corev1.SecretList); ok {
+		return r.ClientReader.List(ctx, list, opts...)
+	}
+	return r.DelegatingReader.List(ctx, list, opts...)
+}
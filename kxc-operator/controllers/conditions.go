@@ -0,0 +1,76 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+)
+
+const reasonReconcileFailed = "ReconcileFailed"
+
+// reconcileErrorReason returns the api status reason of err when known
+func reconcileErrorReason(err error) string {
+	if reason := errors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	return reasonReconcileFailed
+}
+
+// setReconcileFailedConditions marks the resource as not ready because of err.
+// Returns true if conditions were changed
+func setReconcileFailedConditions(conditions *[]cloudv1alpha1.Condition, generation int64, err error) bool {
+	readyChanged := cloudv1alpha1.SetCondition(conditions, cloudv1alpha1.Condition{
+		Type:               cloudv1alpha1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             reconcileErrorReason(err),
+		Message:            err.Error(),
+	})
+	progressingChanged := cloudv1alpha1.SetCondition(conditions, cloudv1alpha1.Condition{
+		Type:               cloudv1alpha1.ConditionProgressing,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             reasonReconcileFailed,
+		Message:            err.Error(),
+	})
+
+	return readyChanged || progressingChanged
+}
+
+// setReconciledConditions sets the ready and progressing conditions once all resources are in place.
+// Returns true if conditions were changed
+func setReconciledConditions(conditions *[]cloudv1alpha1.Condition, generation int64, ready, progressing metav1.ConditionStatus, reason, message string) bool {
+	readyChanged := cloudv1alpha1.SetCondition(conditions, cloudv1alpha1.Condition{
+		Type:               cloudv1alpha1.ConditionReady,
+		Status:             ready,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	progressingChanged := cloudv1alpha1.SetCondition(conditions, cloudv1alpha1.Condition{
+		Type:               cloudv1alpha1.ConditionProgressing,
+		Status:             progressing,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+
+	return readyChanged || progressingChanged
+}
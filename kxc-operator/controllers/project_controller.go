@@ -18,17 +18,21 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 
 	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
@@ -47,6 +51,8 @@ type ProjectReconciler struct {
 // +kubebuilder:rbac:groups=cloud.kubexcloud.com,resources=projects,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cloud.kubexcloud.com,resources=projects/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cloud.kubexcloud.com,resources=apps,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=core,resources=resourcequotas;limitranges,verbs=get;list;watch;create;update;patch;delete
 
 func (r *ProjectReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -68,9 +74,68 @@ func (r *ProjectReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
+	// the project is being deleted, clean up before releasing it
+	if !project.DeletionTimestamp.IsZero() {
+		return r.finalizeProject(ctx, log, project)
+	}
+
+	// make sure we get to clean up when the project is deleted
+	if !controllerutil.ContainsFinalizer(project, projectFinalizer) {
+		controllerutil.AddFinalizer(project, projectFinalizer)
+
+		log.Info("Adding Project finalizer")
+		err = r.Update(ctx, project)
+		if err != nil {
+			log.Error(err, "Failed to add Project finalizer")
+			return ctrl.Result{}, err
+		}
+
+		// finalizer added - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	result, err := r.reconcileProject(ctx, log, project)
+	if err != nil {
+		// conflicts only mean we worked on a stale object, don't report them
+		if errors.IsConflict(err) {
+			return result, err
+		}
+
+		conditionsChanged := setReconcileFailedConditions(&project.Status.Conditions, project.Generation, err)
+		if conditionsChanged || project.Status.ObservedGeneration != project.Generation {
+			project.Status.ObservedGeneration = project.Generation
+
+			if statusErr := r.Status().Update(ctx, project); statusErr != nil {
+				log.Error(statusErr, "Failed to update Project status conditions")
+			}
+		}
+		return result, err
+	}
+	if result.Requeue {
+		return result, nil
+	}
+
+	conditionsChanged := setReconciledConditions(&project.Status.Conditions, project.Generation,
+		metav1.ConditionTrue, metav1.ConditionFalse, "Provisioned", "namespace "+ProjectNamespaceName(project.Name)+" is ready")
+	if conditionsChanged || project.Status.ObservedGeneration != project.Generation || project.Status.Phase != cloudv1alpha1.ProjectActive {
+		project.Status.ObservedGeneration = project.Generation
+		project.Status.Phase = cloudv1alpha1.ProjectActive
+
+		err := r.Status().Update(ctx, project)
+		if err != nil {
+			log.Error(err, "Failed to update Project status conditions")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileProject creates the project resources one at a time, requeuing after each change
+func (r *ProjectReconciler) reconcileProject(ctx context.Context, log logr.Logger, project *cloudv1alpha1.Project) (ctrl.Result, error) {
 	// Check if the namespace already exists, if not create a new one
 	namespace := &corev1.Namespace{}
-	err = r.Get(ctx, types.NamespacedName{Name: ProjectNamespaceName(project.Name)}, namespace)
+	err := r.Get(ctx, types.NamespacedName{Name: ProjectNamespaceName(project.Name)}, namespace)
 	if err != nil && errors.IsNotFound(err) {
 		// Define new
 		namespace, err := r.namespaceForProject(project)
@@ -117,9 +182,403 @@ func (r *ProjectReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
+	if project.Spec.Quota == nil {
+		return r.removeProjectQuota(ctx, log, project)
+	}
+
+	// Check if the resource quota already exists, if not create a new one
+	targetQuota, err := r.resourceQuotaForProject(project)
+	if err != nil {
+		log.Error(err, "Failed to build ResourceQuota", "ResourceQuota.Namespace", namespace.Name, "ResourceQuota.Name", projectResourceQuotaName)
+		return ctrl.Result{}, err
+	}
+
+	quota := &corev1.ResourceQuota{}
+	err = r.Get(ctx, types.NamespacedName{Name: projectResourceQuotaName, Namespace: namespace.Name}, quota)
+	if err != nil && errors.IsNotFound(err) {
+		log.Info("Creating a new ResourceQuota", "ResourceQuota.Namespace", targetQuota.Namespace, "ResourceQuota.Name", targetQuota.Name)
+		err = r.Create(ctx, targetQuota)
+		if err != nil {
+			log.Error(err, "Failed to create new ResourceQuota", "ResourceQuota.Namespace", targetQuota.Namespace, "ResourceQuota.Name", targetQuota.Name)
+			return ctrl.Result{}, err
+		}
+
+		// created successfully - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ResourceQuota")
+		return ctrl.Result{}, err
+	}
+
+	// update resource quota if necessary
+	if !resourceListEqual(quota.Spec.Hard, targetQuota.Spec.Hard) {
+		quota.Spec.Hard = targetQuota.Spec.Hard
+
+		log.Info("Updating ResourceQuota", "ResourceQuota.Namespace", quota.Namespace, "ResourceQuota.Name", quota.Name)
+		err = r.Update(ctx, quota)
+		if err != nil {
+			log.Error(err, "Failed to update ResourceQuota", "ResourceQuota.Namespace", quota.Namespace, "ResourceQuota.Name", quota.Name)
+			return ctrl.Result{}, err
+		}
+
+		// Spec updated - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// Check if the limit range already exists, if not create a new one
+	targetLimitRange, err := r.limitRangeForProject(project)
+	if err != nil {
+		log.Error(err, "Failed to build LimitRange", "LimitRange.Namespace", namespace.Name, "LimitRange.Name", projectLimitRangeName)
+		return ctrl.Result{}, err
+	}
+
+	limitRange := &corev1.LimitRange{}
+	err = r.Get(ctx, types.NamespacedName{Name: projectLimitRangeName, Namespace: namespace.Name}, limitRange)
+	if err != nil && errors.IsNotFound(err) {
+		log.Info("Creating a new LimitRange", "LimitRange.Namespace", targetLimitRange.Namespace, "LimitRange.Name", targetLimitRange.Name)
+		err = r.Create(ctx, targetLimitRange)
+		if err != nil {
+			log.Error(err, "Failed to create new LimitRange", "LimitRange.Namespace", targetLimitRange.Namespace, "LimitRange.Name", targetLimitRange.Name)
+			return ctrl.Result{}, err
+		}
+
+		// created successfully - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get LimitRange")
+		return ctrl.Result{}, err
+	}
+
+	// update limit range if necessary
+	if !limitRangeItemsEqual(limitRange.Spec.Limits, targetLimitRange.Spec.Limits) {
+		limitRange.Spec.Limits = targetLimitRange.Spec.Limits
+
+		log.Info("Updating LimitRange", "LimitRange.Namespace", limitRange.Namespace, "LimitRange.Name", limitRange.Name)
+		err = r.Update(ctx, limitRange)
+		if err != nil {
+			log.Error(err, "Failed to update LimitRange", "LimitRange.Namespace", limitRange.Namespace, "LimitRange.Name", limitRange.Name)
+			return ctrl.Result{}, err
+		}
+
+		// Spec updated - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// update project status (usage) if necessary
+	usage := projectUsageFromQuota(quota)
+	if project.Status.Usage == nil || *project.Status.Usage != *usage {
+		project.Status.Usage = usage
+
+		err := r.Status().Update(ctx, project)
+		if err != nil {
+			log.Error(err, "Failed to update Project status")
+			return ctrl.Result{}, err
+		}
+
+		// Status updated - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// removeProjectQuota deletes the resource quota and limit range of a project without quota
+func (r *ProjectReconciler) removeProjectQuota(ctx context.Context, log logr.Logger, project *cloudv1alpha1.Project) (ctrl.Result, error) {
+	namespaceName := ProjectNamespaceName(project.Name)
+
+	quota := &corev1.ResourceQuota{}
+	err := r.Get(ctx, types.NamespacedName{Name: projectResourceQuotaName, Namespace: namespaceName}, quota)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get ResourceQuota")
+		return ctrl.Result{}, err
+	}
+	if err == nil && metav1.IsControlledBy(quota, project) {
+		log.Info("Deleting ResourceQuota", "ResourceQuota.Namespace", quota.Namespace, "ResourceQuota.Name", quota.Name)
+		err = r.Delete(ctx, quota)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete ResourceQuota", "ResourceQuota.Namespace", quota.Namespace, "ResourceQuota.Name", quota.Name)
+			return ctrl.Result{}, err
+		}
+
+		// deleted - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	limitRange := &corev1.LimitRange{}
+	err = r.Get(ctx, types.NamespacedName{Name: projectLimitRangeName, Namespace: namespaceName}, limitRange)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get LimitRange")
+		return ctrl.Result{}, err
+	}
+	if err == nil && metav1.IsControlledBy(limitRange, project) {
+		log.Info("Deleting LimitRange", "LimitRange.Namespace", limitRange.Namespace, "LimitRange.Name", limitRange.Name)
+		err = r.Delete(ctx, limitRange)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete LimitRange", "LimitRange.Namespace", limitRange.Namespace, "LimitRange.Name", limitRange.Name)
+			return ctrl.Result{}, err
+		}
+
+		// deleted - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// clear project status (usage) if necessary
+	if project.Status.Usage != nil {
+		project.Status.Usage = nil
+
+		err := r.Status().Update(ctx, project)
+		if err != nil {
+			log.Error(err, "Failed to update Project status")
+			return ctrl.Result{}, err
+		}
+
+		// Status updated - return and requeue
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+const (
+	projectResourceQuotaName = "kxc-quota"
+	projectLimitRangeName    = "kxc-limits"
+)
+
+// appCountResourceName counts the apps of a namespace in resource quotas
+const appCountResourceName corev1.ResourceName = "count/apps.cloud.kubexcloud.com"
+
+// container defaults applied by the limit range when the project quota doesn't set them
+var (
+	defaultContainerRequests = cloudv1alpha1.ResourceList{CPU: "100m", Memory: "128Mi"}
+	defaultContainerLimits   = cloudv1alpha1.ResourceList{CPU: "500m", Memory: "512Mi"}
+)
+
+// resourceQuotaForProject returns a ResourceQuota object enforcing the project quota
+func (r *ProjectReconciler) resourceQuotaForProject(project *cloudv1alpha1.Project) (*corev1.ResourceQuota, error) {
+	q := project.Spec.Quota
+
+	hard := corev1.ResourceList{}
+	quantities := map[corev1.ResourceName]string{
+		corev1.ResourceRequestsCPU:     q.CPU,
+		corev1.ResourceRequestsMemory:  q.Memory,
+		corev1.ResourceRequestsStorage: q.Storage,
+	}
+	for name, value := range quantities {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		hard[name] = quantity
+	}
+	if q.Pods > 0 {
+		hard[corev1.ResourcePods] = *resource.NewQuantity(int64(q.Pods), resource.DecimalSI)
+	}
+	if q.Apps > 0 {
+		hard[appCountResourceName] = *resource.NewQuantity(int64(q.Apps), resource.DecimalSI)
+	}
+
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      projectResourceQuotaName,
+			Namespace: ProjectNamespaceName(project.Name),
+			Labels:    LabelsForNamespace(project.Name),
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: hard,
+		},
+	}
+
+	// Set Project instance as the owner and controller
+	err := ctrl.SetControllerReference(project, quota, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+// limitRangeForProject returns a LimitRange object setting default container requests and limits
+func (r *ProjectReconciler) limitRangeForProject(project *cloudv1alpha1.Project) (*corev1.LimitRange, error) {
+	requests := defaultContainerRequests
+	if project.Spec.Quota.DefaultRequests != nil {
+		requests = *project.Spec.Quota.DefaultRequests
+	}
+	limits := defaultContainerLimits
+	if project.Spec.Quota.DefaultLimits != nil {
+		limits = *project.Spec.Quota.DefaultLimits
+	}
+
+	defaultRequest, err := resourceListForContainer(&requests)
+	if err != nil {
+		return nil, fmt.Errorf("default requests: %v", err)
+	}
+	defaultLimit, err := resourceListForContainer(&limits)
+	if err != nil {
+		return nil, fmt.Errorf("default limits: %v", err)
+	}
+
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      projectLimitRangeName,
+			Namespace: ProjectNamespaceName(project.Name),
+			Labels:    LabelsForNamespace(project.Name),
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				corev1.LimitRangeItem{
+					Type:           corev1.LimitTypeContainer,
+					DefaultRequest: defaultRequest,
+					Default:        defaultLimit,
+				},
+			},
+		},
+	}
+
+	// Set Project instance as the owner and controller
+	err = ctrl.SetControllerReference(project, limitRange, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return limitRange, nil
+}
+
+func limitRangeItemsEqual(items, targetItems []corev1.LimitRangeItem) bool {
+	if len(items) != len(targetItems) {
+		return false
+	}
+
+	for i, item := range items {
+		targetItem := targetItems[i]
+
+		if item.Type != targetItem.Type || !resourceListEqual(item.DefaultRequest, targetItem.DefaultRequest) || !resourceListEqual(item.Default, targetItem.Default) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// projectUsageFromQuota returns the project usage tracked by the resource quota
+func projectUsageFromQuota(quota *corev1.ResourceQuota) *cloudv1alpha1.ProjectUsage {
+	usage := &cloudv1alpha1.ProjectUsage{}
+
+	if q, ok := quota.Status.Used[corev1.ResourceRequestsCPU]; ok {
+		usage.CPU = q.String()
+	}
+	if q, ok := quota.Status.Used[corev1.ResourceRequestsMemory]; ok {
+		usage.Memory = q.String()
+	}
+	if q, ok := quota.Status.Used[corev1.ResourceRequestsStorage]; ok {
+		usage.Storage = q.String()
+	}
+	if q, ok := quota.Status.Used[corev1.ResourcePods]; ok {
+		usage.Pods = int32(q.Value())
+	}
+	if q, ok := quota.Status.Used[appCountResourceName]; ok {
+		usage.Apps = int32(q.Value())
+	}
+
+	return usage
+}
+
+const projectFinalizer = "cloud.kubexcloud.com/project-cleanup"
+
+// projectCleanupRequeueDelay is how often cleanup progress is checked while terminating
+const projectCleanupRequeueDelay = 5 * time.Second
+
+// finalizeProject deletes the project apps, then its namespace, and releases the project once both are gone
+func (r *ProjectReconciler) finalizeProject(ctx context.Context, log logr.Logger, project *cloudv1alpha1.Project) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(project, projectFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	namespaceName := ProjectNamespaceName(project.Name)
+
+	// delete apps first so that their ingresses, services and claims are drained before the namespace
+	appList := &cloudv1alpha1.AppList{}
+	err := r.List(ctx, appList, client.InNamespace(namespaceName))
+	if err != nil {
+		log.Error(err, "Failed to list Apps")
+		return ctrl.Result{}, err
+	}
+	for i := range appList.Items {
+		app := &appList.Items[i]
+		if !app.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		log.Info("Deleting App", "App.Namespace", app.Namespace, "App.Name", app.Name)
+		err = r.Delete(ctx, app)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete App", "App.Namespace", app.Namespace, "App.Name", app.Name)
+			return ctrl.Result{}, err
+		}
+	}
+	if len(appList.Items) > 0 {
+		err = r.updateTerminatingStatus(ctx, project, int32(len(appList.Items)), fmt.Sprintf("waiting for %d app(s) to be deleted", len(appList.Items)))
+		if err != nil {
+			log.Error(err, "Failed to update Project status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{RequeueAfter: projectCleanupRequeueDelay}, nil
+	}
+
+	// then delete the namespace and wait for it to terminate
+	namespace := &corev1.Namespace{}
+	err = r.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get Namespace")
+		return ctrl.Result{}, err
+	}
+	if err == nil {
+		if namespace.DeletionTimestamp.IsZero() {
+			log.Info("Deleting Namespace", "Namespace.Name", namespace.Name)
+			err = r.Delete(ctx, namespace)
+			if err != nil && !errors.IsNotFound(err) {
+				log.Error(err, "Failed to delete Namespace", "Namespace.Name", namespace.Name)
+				return ctrl.Result{}, err
+			}
+		}
+
+		err = r.updateTerminatingStatus(ctx, project, 0, fmt.Sprintf("waiting for namespace %s to terminate", namespaceName))
+		if err != nil {
+			log.Error(err, "Failed to update Project status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{RequeueAfter: projectCleanupRequeueDelay}, nil
+	}
+
+	// everything is cleaned up, release the project
+	controllerutil.RemoveFinalizer(project, projectFinalizer)
+
+	log.Info("Removing Project finalizer")
+	err = r.Update(ctx, project)
+	if err != nil {
+		log.Error(err, "Failed to remove Project finalizer")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// updateTerminatingStatus reports the cleanup progress of a project being deleted
+func (r *ProjectReconciler) updateTerminatingStatus(ctx context.Context, project *cloudv1alpha1.Project, remainingApps int32, message string) error {
+	conditionsChanged := setReconciledConditions(&project.Status.Conditions, project.Generation,
+		metav1.ConditionFalse, metav1.ConditionTrue, string(cloudv1alpha1.ProjectTerminating), message)
+	if !conditionsChanged && project.Status.Phase == cloudv1alpha1.ProjectTerminating && project.Status.RemainingApps == remainingApps {
+		return nil
+	}
+
+	project.Status.Phase = cloudv1alpha1.ProjectTerminating
+	project.Status.RemainingApps = remainingApps
+
+	return r.Status().Update(ctx, project)
+}
+
 // namespaceForProject returns a Namespace object
 func (r *ProjectReconciler) namespaceForProject(project *cloudv1alpha1.Project) (*corev1.Namespace, error) {
 	labels := LabelsForNamespace(project.Name)
@@ -240,9 +699,46 @@ func ProjectUserName(project *cloudv1alpha1.Project) string {
 	return project.Labels[userAccountCRKey]
 }
 
+// ProjectMemberRole returns the role of a user in a project, empty if the user isn't a member
+func ProjectMemberRole(project *cloudv1alpha1.Project, userName string) cloudv1alpha1.ProjectRole {
+	if project == nil {
+		return ""
+	}
+
+	// projects without members are owned by the user who created them
+	if len(project.Spec.Members) == 0 {
+		if ProjectUserName(project) == userName {
+			return cloudv1alpha1.ProjectRoleOwner
+		}
+		return ""
+	}
+
+	for _, m := range project.Spec.Members {
+		if m.UserName == userName {
+			return m.Role
+		}
+	}
+
+	return ""
+}
+
+// projectRoleRanks orders roles by the permissions they grant
+var projectRoleRanks = map[cloudv1alpha1.ProjectRole]int{
+	cloudv1alpha1.ProjectRoleViewer:    1,
+	cloudv1alpha1.ProjectRoleDeveloper: 2,
+	cloudv1alpha1.ProjectRoleOwner:     3,
+}
+
+// ProjectRoleAllows returns true if role grants at least the permissions of minRole
+func ProjectRoleAllows(role, minRole cloudv1alpha1.ProjectRole) bool {
+	return projectRoleRanks[role] > 0 && projectRoleRanks[role] >= projectRoleRanks[minRole]
+}
+
 func (r *ProjectReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&cloudv1alpha1.Project{}).
 		Owns(&corev1.Namespace{}).
+		Owns(&corev1.ResourceQuota{}).
+		Owns(&corev1.LimitRange{}).
 		Complete(r)
 }
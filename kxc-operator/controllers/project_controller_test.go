@@ -17,6 +17,9 @@ var _ = Describe("Project controller", func() {
 		ProjectName   = "test-project"
 		NamespaceName = "kxc-proj-test-project"
 
+		TerminatingProjectName   = "test-terminating-project"
+		TerminatingNamespaceName = "kxc-proj-test-terminating-project"
+
 		timeout  = time.Second * 10
 		duration = time.Second * 10
 		interval = time.Millisecond * 250
@@ -49,6 +52,60 @@ var _ = Describe("Project controller", func() {
 				"app":        "kxc",
 				"project_cr": "test-project",
 			}))
+
+			// check project status
+
+			Eventually(func() bool {
+				project := &cloudv1alpha1.Project{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: ProjectName}, project)
+				if err != nil {
+					return false
+				}
+				return project.Status.ObservedGeneration == project.Generation &&
+					cloudv1alpha1.FindCondition(project.Status.Conditions, cloudv1alpha1.ConditionReady) != nil
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("Should clean up the namespace on deletion", func() {
+			ctx := context.Background()
+			proj = &cloudv1alpha1.Project{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: TerminatingProjectName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, proj)).Should(Succeed())
+
+			// wait for the finalizer and namespace
+			Eventually(func() bool {
+				project := &cloudv1alpha1.Project{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: TerminatingProjectName}, project)
+				if err != nil || len(project.Finalizers) == 0 {
+					return false
+				}
+				err = k8sClient.Get(ctx, types.NamespacedName{Name: TerminatingNamespaceName}, &corev1.Namespace{})
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(k8sClient.Delete(ctx, proj)).Should(Succeed())
+
+			// the namespace never finishes terminating in the test environment
+			Eventually(func() bool {
+				namespace := &corev1.Namespace{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: TerminatingNamespaceName}, namespace)
+				if err != nil {
+					return false
+				}
+				return !namespace.DeletionTimestamp.IsZero()
+			}, timeout, interval).Should(BeTrue())
+
+			Eventually(func() cloudv1alpha1.ProjectPhase {
+				project := &cloudv1alpha1.Project{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: TerminatingProjectName}, project)
+				if err != nil {
+					return ""
+				}
+				return project.Status.Phase
+			}, timeout, interval).Should(Equal(cloudv1alpha1.ProjectTerminating))
 		})
 
 		AfterEach(func() {
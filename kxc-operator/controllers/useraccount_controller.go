@@ -17,12 +17,22 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
 )
@@ -36,18 +46,215 @@ type UserAccountReconciler struct {
 
 // +kubebuilder:rbac:groups=cloud.kubexcloud.com,resources=useraccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cloud.kubexcloud.com,resources=useraccounts/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;create;update
+// the secrets of the system namespace are listed and watched with the role of config/system
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create
 
 func (r *UserAccountReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	_ = context.Background()
-	_ = r.Log.WithValues("useraccount", req.NamespacedName)
+	ctx := context.Background()
+	log := r.Log.WithValues("useraccount", req.NamespacedName)
 
-	// your logic here
+	// Fetch the UserAccount instance
+	userAccount := &cloudv1alpha1.UserAccount{}
+	err := r.Get(ctx, req.NamespacedName, userAccount)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Return and don't requeue
+			log.Info("UserAccount resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		log.Error(err, "Failed to get UserAccount")
+		return ctrl.Result{}, err
+	}
+
+	// move the password hash from the spec to a secret
+	if userAccount.Spec.Password != "" {
+		err = r.migratePassword(ctx, log, userAccount)
+		if err != nil {
+			return r.setFailedStatus(ctx, log, userAccount, err)
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if userAccount.Spec.PasswordSecretRef != nil {
+		secret := &corev1.Secret{}
+		err = r.Get(ctx, types.NamespacedName{Namespace: userAccount.Spec.PasswordSecretRef.Namespace, Name: userAccount.Spec.PasswordSecretRef.Name}, secret)
+		if err != nil {
+			log.Error(err, "Failed to get password Secret")
+			return r.setFailedStatus(ctx, log, userAccount, err)
+		}
+	}
+
+	conditionsChanged := setReconciledConditions(&userAccount.Status.Conditions, userAccount.Generation,
+		metav1.ConditionTrue, metav1.ConditionFalse, "Active", "user account is active")
+	if conditionsChanged || userAccount.Status.ObservedGeneration != userAccount.Generation {
+		userAccount.Status.ObservedGeneration = userAccount.Generation
+
+		err := r.Status().Update(ctx, userAccount)
+		if err != nil {
+			log.Error(err, "Failed to update UserAccount status conditions")
+			return ctrl.Result{}, err
+		}
+	}
 
 	return ctrl.Result{}, nil
 }
 
+// setFailedStatus reports err in the user account conditions
+func (r *UserAccountReconciler) setFailedStatus(ctx context.Context, log logr.Logger, userAccount *cloudv1alpha1.UserAccount, err error) (ctrl.Result, error) {
+	conditionsChanged := setReconcileFailedConditions(&userAccount.Status.Conditions, userAccount.Generation, err)
+	if conditionsChanged || userAccount.Status.ObservedGeneration != userAccount.Generation {
+		userAccount.Status.ObservedGeneration = userAccount.Generation
+
+		statusErr := r.Status().Update(ctx, userAccount)
+		if statusErr != nil && !errors.IsConflict(statusErr) {
+			log.Error(statusErr, "Failed to update UserAccount status conditions")
+		}
+	}
+
+	return ctrl.Result{}, err
+}
+
+// migratePassword stores the password hash of the spec in the user secret and references it
+func (r *UserAccountReconciler) migratePassword(ctx context.Context, log logr.Logger, userAccount *cloudv1alpha1.UserAccount) error {
+	err := EnsureSystemNamespace(ctx, r.Client)
+	if err != nil {
+		log.Error(err, "Failed to ensure system Namespace", "Namespace.Name", SystemNamespaceName())
+		return err
+	}
+
+	secret := &corev1.Secret{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: SystemNamespaceName(), Name: UserAccountSecretName(userAccount.Name)}, secret)
+	if err != nil && errors.IsNotFound(err) {
+		secret, err = UserAccountSecret(userAccount, []byte(userAccount.Spec.Password), r.Scheme)
+		if err != nil {
+			log.Error(err, "Failed to build new Secret", "Secret.Namespace", SystemNamespaceName(), "Secret.Name", UserAccountSecretName(userAccount.Name))
+			return err
+		}
+
+		log.Info("Creating a new Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+		err = r.Create(ctx, secret)
+		if err != nil {
+			log.Error(err, "Failed to create new Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+			return err
+		}
+	} else if err != nil {
+		log.Error(err, "Failed to get Secret")
+		return err
+	} else if !bytes.Equal(secret.Data[PasswordHashSecretKey], []byte(userAccount.Spec.Password)) {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[PasswordHashSecretKey] = []byte(userAccount.Spec.Password)
+
+		log.Info("Updating Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+		err = r.Update(ctx, secret)
+		if err != nil {
+			log.Error(err, "Failed to update Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+			return err
+		}
+	}
+
+	log.Info("Moving password hash to Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+	userAccount.Spec.Password = ""
+	userAccount.Spec.PasswordSecretRef = &corev1.SecretReference{Namespace: secret.Namespace, Name: secret.Name}
+	err = r.Update(ctx, userAccount)
+	if err != nil {
+		log.Error(err, "Failed to update UserAccount password secret ref")
+		return err
+	}
+
+	return nil
+}
+
+// PasswordHashSecretKey is the key of the password hash in user secrets
+const PasswordHashSecretKey = "passwordHash"
+
+const defaultSystemNamespace = "kxc-system"
+
+// SystemNamespaceName returns the namespace holding kxc internal resources such as user credentials
+func SystemNamespaceName() string {
+	n := os.Getenv("KXC_SYSTEM_NAMESPACE")
+	if n == "" {
+		return defaultSystemNamespace
+	}
+	return n
+}
+
+// UserAccountSecretName returns the name of the secret holding the credentials of a user
+func UserAccountSecretName(userName string) string {
+	return "kxc-user-" + userName
+}
+
+// UserAccountSecret builds the credentials secret of a user, owned by the user account
+func UserAccountSecret(userAccount *cloudv1alpha1.UserAccount, passwordHash []byte, scheme *runtime.Scheme) (*corev1.Secret, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      UserAccountSecretName(userAccount.Name),
+			Namespace: SystemNamespaceName(),
+			Labels:    map[string]string{"app": "kxc", userAccountCRKey: userAccount.Name},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			PasswordHashSecretKey: passwordHash,
+		},
+	}
+	// Set UserAccount instance as the owner and controller
+	err := ctrl.SetControllerReference(userAccount, secret, scheme)
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// EnsureSystemNamespace creates the kxc system namespace if it doesn't exist
+func EnsureSystemNamespace(ctx context.Context, c client.Client) error {
+	namespace := &corev1.Namespace{}
+	err := c.Get(ctx, types.NamespacedName{Name: SystemNamespaceName()}, namespace)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("get system namespace: %v", err)
+	}
+
+	namespace = &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   SystemNamespaceName(),
+			Labels: map[string]string{"app": "kxc"},
+		},
+	}
+	err = c.Create(ctx, namespace)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("create system namespace: %v", err)
+	}
+
+	return nil
+}
+
 func (r *UserAccountReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// the user secrets are watched with a cache of the system namespace only, instead of all the secrets of the cluster
+	secretCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: SystemNamespaceName(),
+	})
+	if err != nil {
+		return err
+	}
+	// started by the manager with the controllers
+	err = mgr.Add(secretCache)
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&cloudv1alpha1.UserAccount{}).
+		Watches(source.NewKindWithCache(&corev1.Secret{}, secretCache), &handler.EnqueueRequestForOwner{
+			OwnerType:    &cloudv1alpha1.UserAccount{},
+			IsController: true,
+		}).
 		Complete(r)
 }
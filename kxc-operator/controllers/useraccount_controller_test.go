@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	cloudv1alpha1 "github.com/didil/kubexcloud/kxc-operator/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("UserAccount controller", func() {
+	const (
+		UserName     = "test-legacy-user"
+		PasswordHash = "$2a$04$MTQ1N6ZQbYcWx3zpuSuvlO3EuIx0KHrVHTIAt0tBfFQWhWBHFGU6a"
+
+		timeout  = time.Second * 10
+		interval = time.Millisecond * 250
+	)
+
+	Context("When creating a user account with a password hash in its spec", func() {
+		It("Should move the password hash to a secret", func() {
+			ctx := context.Background()
+			user := &cloudv1alpha1.UserAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: UserName,
+				},
+				Spec: cloudv1alpha1.UserAccountSpec{
+					Password: PasswordHash,
+					Role:     "regular",
+				},
+			}
+			Expect(k8sClient.Create(ctx, user)).Should(Succeed())
+
+			userLookupKey := types.NamespacedName{Name: UserName}
+			createdUser := &cloudv1alpha1.UserAccount{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, userLookupKey, createdUser)
+				if err != nil {
+					return false
+				}
+				return createdUser.Spec.PasswordSecretRef != nil
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(createdUser.Spec.Password).To(BeEmpty())
+			Expect(createdUser.Spec.PasswordSecretRef.Namespace).To(Equal(SystemNamespaceName()))
+			Expect(createdUser.Spec.PasswordSecretRef.Name).To(Equal(UserAccountSecretName(UserName)))
+
+			secret := &corev1.Secret{}
+			secretLookupKey := types.NamespacedName{Namespace: SystemNamespaceName(), Name: UserAccountSecretName(UserName)}
+			Expect(k8sClient.Get(ctx, secretLookupKey, secret)).Should(Succeed())
+			Expect(string(secret.Data[PasswordHashSecretKey])).To(Equal(PasswordHash))
+			Expect(secret.OwnerReferences).To(HaveLen(1))
+			Expect(secret.OwnerReferences[0].Name).To(Equal(UserName))
+		})
+	})
+})